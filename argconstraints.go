@@ -0,0 +1,110 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// argConstraints are the constraints of an argument
+// from the struct tags ArgMinTag, ArgMaxTag,
+// ArgMinLengthTag, ArgMaxLengthTag, and ArgPatternTag
+type argConstraints struct {
+	min, max             *float64
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+}
+
+// parseArgConstraints returns the constraints
+// of the argument with index of def
+func (def *ArgsDef) parseArgConstraints(index int) (c argConstraints, err error) {
+	parseFloat := func(tag string) (*float64, error) {
+		str := def.ArgTag(index, tag)
+		if str == "" {
+			return nil, nil
+		}
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag %q of argument <%s>: %w", tag, str, def.argInfos[index].Name, err)
+		}
+		return &f, nil
+	}
+	parseInt := func(tag string) (*int, error) {
+		str := def.ArgTag(index, tag)
+		if str == "" {
+			return nil, nil
+		}
+		i, err := strconv.Atoi(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag %q of argument <%s>: %w", tag, str, def.argInfos[index].Name, err)
+		}
+		return &i, nil
+	}
+	if c.min, err = parseFloat(ArgMinTag); err != nil {
+		return c, err
+	}
+	if c.max, err = parseFloat(ArgMaxTag); err != nil {
+		return c, err
+	}
+	if c.minLength, err = parseInt(ArgMinLengthTag); err != nil {
+		return c, err
+	}
+	if c.maxLength, err = parseInt(ArgMaxLengthTag); err != nil {
+		return c, err
+	}
+	if pattern := def.ArgTag(index, ArgPatternTag); pattern != "" {
+		c.pattern, err = regexp.Compile(pattern)
+		if err != nil {
+			return c, fmt.Errorf("invalid %s tag of argument <%s>: %w", ArgPatternTag, def.argInfos[index].Name, err)
+		}
+	}
+	return c, nil
+}
+
+// checkConstraints returns an ArgError if the number argument argVal
+// with index is not within its minimum and maximum or the string
+// argument argVal does not match its length and pattern constraints.
+// Nil pointers are not checked.
+func (def *ArgsDef) checkConstraints(index int, argVal reflect.Value) error {
+	c := &def.constraints[index]
+	name := def.argInfos[index].Name
+	for argVal.Kind() == reflect.Ptr {
+		if argVal.IsNil() {
+			return nil
+		}
+		argVal = argVal.Elem()
+	}
+	var number float64
+	switch argVal.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(argVal.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number = float64(argVal.Uint())
+	case reflect.Float32, reflect.Float64:
+		number = argVal.Float()
+	case reflect.String:
+		str := argVal.String()
+		length := utf8.RuneCountInString(str)
+		if c.minLength != nil && length < *c.minLength {
+			return ArgError{Arg: name, Err: fmt.Errorf("argument <%s> must have at least %d characters, but has %d", name, *c.minLength, length)}
+		}
+		if c.maxLength != nil && length > *c.maxLength {
+			return ArgError{Arg: name, Err: fmt.Errorf("argument <%s> must have at most %d characters, but has %d", name, *c.maxLength, length)}
+		}
+		if c.pattern != nil && !c.pattern.MatchString(str) {
+			return ArgError{Arg: name, Err: fmt.Errorf("argument <%s> must match the pattern %s, but is %q", name, c.pattern, str)}
+		}
+		return nil
+	default:
+		return nil
+	}
+	if c.min != nil && number < *c.min {
+		return ArgError{Arg: name, Err: fmt.Errorf("argument <%s> must be at least %g, but is %g", name, *c.min, number)}
+	}
+	if c.max != nil && number > *c.max {
+		return ArgError{Arg: name, Err: fmt.Errorf("argument <%s> must be at most %g, but is %g", name, *c.max, number)}
+	}
+	return nil
+}
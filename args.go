@@ -15,4 +15,33 @@ type Arg struct {
 	Name        string
 	Description string
 	Type        reflect.Type
+
+	// Default is the value used if the argument is not passed
+	Default string
+	// Env is the name of an environment variable
+	// used if the argument is not passed
+	Env string
+	// Required arguments must be passed or have an Env or Default value
+	Required bool
+	// Enum lists the allowed values if not empty
+	Enum []string
+	// Example is an example value for the help page
+	Example string
+	// Secret arguments are read without echo when prompted
+	Secret bool
+}
+
+// ArgError is returned for an argument
+// that could not be parsed or is invalid
+type ArgError struct {
+	Arg string
+	Err error
+}
+
+func (e ArgError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ArgError) Unwrap() error {
+	return e.Err
 }
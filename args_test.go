@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -86,6 +87,89 @@ func Test_GetStringMapArgsFunc(t *testing.T) {
 	assert.Equal(t, true, passedArgsCollector.Bool2, "bool2")
 }
 
+func Test_GetJSONArgsFunc(t *testing.T) {
+	var commandArgsDef TestCommandArgsDef
+	jsonCommandFunc, err := GetJSONArgsFunc(CommandFunc, &commandArgsDef)
+	assert.NoError(t, err, "GetJSONArgsFunc")
+
+	passedArgsCollector = nil
+	err = jsonCommandFunc(context.Background(), []byte(`[123, "Hello World!", true]`))
+	assert.NoError(t, err, "JSON array")
+	assert.Equal(t, TestCommandArgsDef{Int0: 123, Str1: "Hello World!", Bool2: true}, *passedArgsCollector)
+
+	passedArgsCollector = nil
+	err = jsonCommandFunc(context.Background(), []byte(`{"int0": 123, "Str1": "Hello World!"}`))
+	assert.NoError(t, err, "JSON object")
+	assert.Equal(t, TestCommandArgsDef{Int0: 123, Str1: "Hello World!"}, *passedArgsCollector)
+
+	err = jsonCommandFunc(context.Background(), []byte(`["x"]`))
+	var argErr ArgError
+	if assert.ErrorAs(t, err, &argErr) {
+		assert.Equal(t, "int0", argErr.Arg)
+	}
+	assert.True(t, IsUsageError(err))
+
+	err = jsonCommandFunc(context.Background(), []byte(`{"int0": "x"}`))
+	assert.True(t, IsUsageError(err), "JSON object with wrong type")
+
+	passedArgsCollector = nil
+	err = jsonCommandFunc(context.Background(), []byte(`[1, "", false, 3]`))
+	assert.NoError(t, err, "extra array elements are ignored")
+	assert.Equal(t, TestCommandArgsDef{Int0: 1}, *passedArgsCollector)
+}
+
+// upperString unmarshals JSON strings in upper case
+type upperString string
+
+func (s *upperString) UnmarshalJSON(data []byte) error {
+	var str string
+	err := json.Unmarshal(data, &str)
+	*s = upperString(strings.ToUpper(str))
+	return err
+}
+
+type jsonTagsArgsDef struct {
+	ArgsDef
+
+	Name    upperString `arg:"name"`
+	Count   int         `arg:"count" json:"n"`
+	Ignored string      `arg:"ignored" json:"-"`
+}
+
+func Test_GetJSONArgsFunc_Unmarshal(t *testing.T) {
+	var (
+		name    upperString
+		count   int
+		ignored string
+	)
+	jsonCommandFunc, err := GetJSONArgsFunc(func(n upperString, c int, i string) {
+		name, count, ignored = n, c, i
+	}, new(jsonTagsArgsDef))
+	assert.NoError(t, err, "GetJSONArgsFunc")
+
+	err = jsonCommandFunc(context.Background(), []byte(`{"name": "abc", "n": 2, "count": 3, "ignored": "x"}`))
+	assert.NoError(t, err, "JSON object")
+	assert.Equal(t, upperString("ABC"), name, "custom UnmarshalJSON")
+	assert.Equal(t, 2, count, "json tag name")
+	assert.Equal(t, "", ignored, "json:\"-\" field")
+
+	err = jsonCommandFunc(context.Background(), []byte(`["abc", 3, "x"]`))
+	assert.NoError(t, err, "JSON array")
+	assert.Equal(t, upperString("ABC"), name, "custom UnmarshalJSON")
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "x", ignored)
+}
+
+func Test_GetMapArgsFunc(t *testing.T) {
+	var commandArgsDef TestCommandArgsDef
+	mapCommandFunc, err := GetMapArgsFunc(CommandFunc, &commandArgsDef)
+	assert.NoError(t, err, "GetMapArgsFunc")
+	passedArgsCollector = nil
+	err = mapCommandFunc(context.Background(), map[string]interface{}{"int0": 123.0, "str1": "Hello World!", "bool2": true})
+	assert.NoError(t, err, "command should return nil")
+	assert.Equal(t, TestCommandArgsDef{Int0: 123, Str1: "Hello World!", Bool2: true}, *passedArgsCollector)
+}
+
 type ResultStruct struct {
 	ResultCode    int
 	ResultMessage string
@@ -118,3 +202,103 @@ func Test_WithResultHandler(t *testing.T) {
 	// Check result struct
 	assert.Equal(t, resultBuf.String(), string(defaultResultStructJSON), "equal result JSON")
 }
+
+type tagsTestArgsDef struct {
+	ArgsDef
+
+	Name  string `arg:"name" required:"true" example:"World"`
+	Greet string `arg:"greet" default:"Hello" env:"ARGS_TEST_GREET"`
+	Count int    `arg:"count" default:"1"`
+	Lang  string `arg:"lang" enum:"en,de"`
+}
+
+func Test_ArgsDef_Tags(t *testing.T) {
+	var argsDef tagsTestArgsDef
+	assert.NoError(t, argsDef.Init(&argsDef))
+	args := argsDef.Args()
+	assert.Equal(t, Arg{Name: "name", Type: args[0].Type, Required: true, Example: "World"}, args[0])
+	assert.Equal(t, Arg{Name: "greet", Type: args[1].Type, Default: "Hello", Env: "ARGS_TEST_GREET"}, args[1])
+	assert.Equal(t, "1", args[2].Default)
+	assert.Equal(t, []string{"en", "de"}, args[3].Enum)
+
+	var passed tagsTestArgsDef
+	f := func(name, greet string, count int, lang string) {
+		passed = tagsTestArgsDef{Name: name, Greet: greet, Count: count, Lang: lang}
+	}
+	stringArgsFunc, err := GetStringArgsFunc(f, &argsDef)
+	assert.NoError(t, err)
+	stringMapArgsFunc, err := GetStringMapArgsFunc(f, &argsDef)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, stringArgsFunc(ctx, "World"))
+	assert.Equal(t, tagsTestArgsDef{Name: "World", Greet: "Hello", Count: 1}, passed, "defaults")
+	assert.NoError(t, stringMapArgsFunc(ctx, map[string]string{"name": "World", "count": "2"}))
+	assert.Equal(t, tagsTestArgsDef{Name: "World", Greet: "Hello", Count: 2}, passed, "defaults")
+
+	t.Setenv("ARGS_TEST_GREET", "Hi")
+	assert.NoError(t, stringArgsFunc(ctx, "World"))
+	assert.Equal(t, "Hi", passed.Greet, "env before default")
+	assert.NoError(t, stringArgsFunc(ctx, "World", "Hello"))
+	assert.Equal(t, "Hello", passed.Greet, "passed arg before env")
+	t.Setenv("ARGS_TEST_GREET", "")
+	assert.NoError(t, stringMapArgsFunc(ctx, map[string]string{"name": "World"}))
+	assert.Equal(t, "", passed.Greet, "empty env var is used")
+
+	err = stringArgsFunc(ctx)
+	assert.EqualError(t, err, "missing required argument <name>")
+	err = stringMapArgsFunc(ctx, map[string]string{"greet": "Hi"})
+	assert.EqualError(t, err, "missing required argument <name>")
+	assert.NoError(t, stringArgsFunc(ctx, "World", "Hi", "1", "de"))
+	assert.Equal(t, "de", passed.Lang)
+	err = stringArgsFunc(ctx, "World", "Hi", "1", "fr")
+	assert.EqualError(t, err, `argument <lang> must be one of en, de, but is "fr"`)
+}
+
+type constraintsTestArgsDef struct {
+	ArgsDef
+
+	Name  string   `arg:"name" minLength:"2" maxLength:"4" pattern:"^[a-z]+$"`
+	Count int      `arg:"count" min:"1" max:"10" default:"1"`
+	Ratio *float64 `arg:"ratio" max:"0.5"`
+}
+
+func Test_ArgsDef_Constraints(t *testing.T) {
+	f := func(name string, count int, ratio *float64) {}
+	stringArgsFunc, err := GetStringArgsFunc(f, new(constraintsTestArgsDef))
+	assert.NoError(t, err)
+	jsonArgsFunc, err := GetJSONArgsFunc(f, new(constraintsTestArgsDef))
+	assert.NoError(t, err)
+	mapArgsFunc, err := GetMapArgsFunc(f, new(constraintsTestArgsDef))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, stringArgsFunc(ctx, "abc", "10", "0.5"))
+	assert.NoError(t, stringArgsFunc(ctx, "ab"), "nil pointer not checked")
+	assert.EqualError(t, stringArgsFunc(ctx, "a"), "argument <name> must have at least 2 characters, but has 1")
+	assert.EqualError(t, stringArgsFunc(ctx, "abcde"), "argument <name> must have at most 4 characters, but has 5")
+	assert.EqualError(t, stringArgsFunc(ctx, "AB"), `argument <name> must match the pattern ^[a-z]+$, but is "AB"`)
+	assert.EqualError(t, stringArgsFunc(ctx, "abc", "0"), "argument <count> must be at least 1, but is 0")
+	assert.EqualError(t, stringArgsFunc(ctx, "abc", "11"), "argument <count> must be at most 10, but is 11")
+	assert.EqualError(t, stringArgsFunc(ctx, "abc", "1", "0.75"), "argument <ratio> must be at most 0.5, but is 0.75")
+
+	assert.NoError(t, jsonArgsFunc(ctx, []byte(`["abc", 2, 0.25]`)))
+	err = jsonArgsFunc(ctx, []byte(`["abc", 20]`))
+	assert.True(t, IsUsageError(err))
+	assert.EqualError(t, err, "argument <count> must be at most 10, but is 20")
+
+	assert.NoError(t, jsonArgsFunc(ctx, []byte(`{"Name": "abc", "ratio": null}`)), "args not passed are not checked")
+	err = jsonArgsFunc(ctx, []byte(`{"name": "abc", "count": 0}`))
+	assert.EqualError(t, err, "argument <count> must be at least 1, but is 0")
+	err = jsonArgsFunc(ctx, []byte(`{"name": "a"}`))
+	assert.EqualError(t, err, "argument <name> must have at least 2 characters, but has 1")
+
+	err = mapArgsFunc(ctx, map[string]interface{}{"name": "abc", "count": 0.0})
+	assert.EqualError(t, err, "argument <count> must be at least 1, but is 0")
+
+	invalid := new(struct {
+		ArgsDef
+		Name string `arg:"name" pattern:"("`
+	})
+	assert.Error(t, invalid.Init(invalid), "invalid pattern")
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"reflect"
 	"strings"
 
@@ -18,6 +19,7 @@ type ArgsDef struct {
 	outerStructType reflect.Type
 	argStructFields []reflection.NamedStructField
 	argInfos        []Arg
+	constraints     []argConstraints
 	initialized     bool
 }
 
@@ -68,29 +70,60 @@ func (def *ArgsDef) Init(outerStructPtr interface{}) error {
 	def.argStructFields = reflection.FlatExportedNamedStructFields(def.outerStructType, ArgNameTag)
 
 	def.argInfos = make([]Arg, len(def.argStructFields))
+	def.constraints = make([]argConstraints, len(def.argStructFields))
 	for i := range def.argInfos {
 		def.argInfos[i].Name = def.argStructFields[i].Name
 		def.argInfos[i].Description = def.ArgTag(i, ArgDescriptionTag)
 		def.argInfos[i].Type = def.argStructFields[i].Field.Type
+		def.argInfos[i].Default = def.ArgTag(i, ArgDefaultTag)
+		def.argInfos[i].Env = def.ArgTag(i, ArgEnvTag)
+		def.argInfos[i].Required = def.ArgTag(i, ArgRequiredTag) == "true"
+		def.argInfos[i].Example = def.ArgTag(i, ArgExampleTag)
+		def.argInfos[i].Secret = def.ArgTag(i, ArgSecretTag) == "true"
+		if enum := def.ArgTag(i, ArgEnumTag); enum != "" {
+			def.argInfos[i].Enum = strings.Split(enum, ",")
+		}
+		constraints, err := def.parseArgConstraints(i)
+		if err != nil {
+			return err
+		}
+		def.constraints[i] = constraints
 	}
 
 	def.initialized = true
 	return nil
 }
 
-func (def *ArgsDef) argValsFromStringArgs(callerArgs []string) ([]reflect.Value, error) {
+func (def *ArgsDef) argValsFromStringArgs(ctx context.Context, callerArgs []string) ([]reflect.Value, error) {
 	// Allocate a new args struct because we need addressable
 	// variables of struct field types to hold arg values.
 	// Instead of new individual variable use fields of args struct.
 	argsStruct := reflect.New(def.outerStructType).Elem()
 	argVals := make([]reflect.Value, def.NumArgs())
 	numStringArgs := len(callerArgs)
+	var prompt *argPrompt
+	canPrompt := promptMissingArgs(ctx)
 	for i := range argVals {
 		argVals[i] = argsStruct.FieldByIndex(def.argStructFields[i].Field.Index)
-		if i >= numStringArgs {
-			continue
+		var stringArg string
+		hasArg := i < numStringArgs
+		if hasArg {
+			stringArg = callerArgs[i]
+		}
+		if canPrompt && !hasArg && def.argInfos[i].Required && !def.hasEnv(i) {
+			if prompt == nil {
+				prompt = newTerminalArgPrompt()
+				canPrompt = prompt != nil
+			}
+			if prompt != nil {
+				err := prompt.promptArg(&def.argInfos[i], argVals[i])
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
 		}
-		err := assignString(argVals[i], callerArgs[i])
+		err := def.assignStringArg(i, argVals[i], stringArg, hasArg)
 		if err != nil {
 			return nil, err
 		}
@@ -98,6 +131,56 @@ func (def *ArgsDef) argValsFromStringArgs(callerArgs []string) ([]reflect.Value,
 	return argVals, nil
 }
 
+// hasEnv returns if the Arg.Env environment variable
+// of the argument with index is set
+func (def *ArgsDef) hasEnv(index int) bool {
+	if def.argInfos[index].Env == "" {
+		return false
+	}
+	_, ok := os.LookupEnv(def.argInfos[index].Env)
+	return ok
+}
+
+// assignStringArg assigns stringArg to the argument argVal with index
+// after checking the Arg.Enum values and checks the constraints
+// from the struct tags ArgMinTag, ArgMaxTag, ArgMinLengthTag,
+// ArgMaxLengthTag, and ArgPatternTag of the assigned value.
+// If the argument was not passed, then the value of the Arg.Env
+// environment variable or Arg.Default is used if available,
+// else an error is returned for Arg.Required arguments.
+func (def *ArgsDef) assignStringArg(index int, argVal reflect.Value, stringArg string, hasArg bool) error {
+	arg := &def.argInfos[index]
+	if !hasArg && arg.Env != "" {
+		stringArg, hasArg = os.LookupEnv(arg.Env)
+	}
+	if !hasArg && arg.Default != "" {
+		stringArg, hasArg = arg.Default, true
+	}
+	if !hasArg {
+		if arg.Required {
+			return ArgError{Arg: arg.Name, Err: fmt.Errorf("missing required argument <%s>", arg.Name)}
+		}
+		return nil
+	}
+	if len(arg.Enum) > 0 && !containsString(arg.Enum, stringArg) {
+		return ArgError{Arg: arg.Name, Err: fmt.Errorf("argument <%s> must be one of %s, but is %q", arg.Name, strings.Join(arg.Enum, ", "), stringArg)}
+	}
+	err := assignString(argVal, stringArg)
+	if err != nil {
+		return ArgError{Arg: arg.Name, Err: err}
+	}
+	return def.checkConstraints(index, argVal)
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
 func (def *ArgsDef) argValsFromStringMapArgs(callerArgs map[string]string) ([]reflect.Value, error) {
 	// Allocate a new args struct because we need addressable
 	// variables of struct field types to hold arg values.
@@ -108,10 +191,7 @@ func (def *ArgsDef) argValsFromStringMapArgs(callerArgs map[string]string) ([]re
 		argVals[i] = argsStruct.FieldByIndex(def.argStructFields[i].Field.Index)
 		argName := def.argStructFields[i].Name
 		stringArg, hasArg := callerArgs[argName]
-		if !hasArg {
-			continue
-		}
-		err := assignString(argVals[i], stringArg)
+		err := def.assignStringArg(i, argVals[i], stringArg, hasArg)
 		if err != nil {
 			return nil, err
 		}
@@ -119,6 +199,26 @@ func (def *ArgsDef) argValsFromStringMapArgs(callerArgs map[string]string) ([]re
 	return argVals, nil
 }
 
+// argsStructFromStringMapArgs returns the address of a copy
+// of the args struct pointed to by outerStructPtr with the values
+// of callerArgs assigned like with argValsFromStringMapArgs.
+// Fields of arguments that are not passed and have no
+// Env or Default value keep the value of the original struct.
+func (def *ArgsDef) argsStructFromStringMapArgs(outerStructPtr interface{}, callerArgs map[string]string) (interface{}, error) {
+	argsStructPtr := reflect.New(def.outerStructType)
+	argsStruct := argsStructPtr.Elem()
+	argsStruct.Set(reflect.ValueOf(outerStructPtr).Elem())
+	for i := range def.argInfos {
+		argVal := argsStruct.FieldByIndex(def.argStructFields[i].Field.Index)
+		stringArg, hasArg := callerArgs[def.argStructFields[i].Name]
+		err := def.assignStringArg(i, argVal, stringArg, hasArg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return argsStructPtr.Interface(), nil
+}
+
 func (def *ArgsDef) argValsFromMapArgs(callerArgs map[string]interface{}) ([]reflect.Value, error) {
 	// Allocate a new args struct because we need addressable
 	// variables of struct field types to hold arg values.
@@ -129,10 +229,25 @@ func (def *ArgsDef) argValsFromMapArgs(callerArgs map[string]interface{}) ([]ref
 		argVals[i] = argsStruct.FieldByIndex(def.argStructFields[i].Field.Index)
 		argName := def.argStructFields[i].Name
 		varArg, hasArg := callerArgs[argName]
-		if !hasArg {
+		if !hasArg || varArg == nil {
+			err := def.assignStringArg(i, argVals[i], "", false)
+			if err != nil {
+				return nil, err
+			}
 			continue
 		}
-		err := assignAny(argVals[i], varArg) // TODO implement assignAny
+		if str, ok := varArg.(string); ok {
+			err := def.assignStringArg(i, argVals[i], str, true)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err := assignAny(argVals[i], varArg)
+		if err != nil {
+			return nil, ArgError{Arg: argName, Err: err}
+		}
+		err = def.checkConstraints(i, argVals[i])
 		if err != nil {
 			return nil, err
 		}
@@ -140,6 +255,14 @@ func (def *ArgsDef) argValsFromMapArgs(callerArgs map[string]interface{}) ([]ref
 	return argVals, nil
 }
 
+// argValsFromJSON returns the argument values from a JSON array
+// with the arguments by position or from a JSON object
+// unmarshalled into the args struct with encoding/json.
+// The constraints of the arguments passed in a JSON object
+// are checked like for arguments passed in a JSON array.
+// Missing array elements and null values are handled like
+// arguments not passed as strings, array elements
+// exceeding the number of arguments are ignored.
 func (def *ArgsDef) argValsFromJSON(argsJSON []byte) ([]reflect.Value, error) {
 	argsJSON = bytes.TrimSpace(argsJSON)
 	if len(argsJSON) < 2 {
@@ -148,7 +271,7 @@ func (def *ArgsDef) argValsFromJSON(argsJSON []byte) ([]reflect.Value, error) {
 
 	// Handle JSON array
 	if argsJSON[0] == '[' {
-		var callerArray []interface{}
+		var callerArray []json.RawMessage
 		err := json.Unmarshal(argsJSON, &callerArray)
 		if err != nil {
 			return nil, err
@@ -157,11 +280,13 @@ func (def *ArgsDef) argValsFromJSON(argsJSON []byte) ([]reflect.Value, error) {
 		argVals := make([]reflect.Value, def.NumArgs())
 		for i := range argVals {
 			argVals[i] = argsStruct.FieldByIndex(def.argStructFields[i].Field.Index)
+			var value json.RawMessage
 			if i < len(callerArray) {
-				err := assignAny(argVals[i], callerArray[i]) // TODO implement assignAny
-				if err != nil {
-					return nil, err
-				}
+				value = callerArray[i]
+			}
+			err := def.assignJSONArg(i, argVals[i], value)
+			if err != nil {
+				return nil, err
 			}
 		}
 		return argVals, nil
@@ -174,24 +299,106 @@ func (def *ArgsDef) argValsFromJSON(argsJSON []byte) ([]reflect.Value, error) {
 		return nil, err
 	}
 
+	var callerObject map[string]json.RawMessage
+	err = json.Unmarshal(argsJSON, &callerObject)
+	if err != nil {
+		return nil, err
+	}
+
 	argsStruct := argsStructPtr.Elem()
 	argVals := make([]reflect.Value, def.NumArgs())
 	for i := range argVals {
 		argVals[i] = argsStruct.FieldByIndex(def.argStructFields[i].Field.Index)
+		if !def.passedInJSONObject(i, callerObject) {
+			continue
+		}
+		err = def.checkConstraints(i, argVals[i])
+		if err != nil {
+			return nil, err
+		}
 	}
 	return argVals, nil
 }
 
+// passedInJSONObject returns if the argument with index has a non null
+// value in callerObject under the key that encoding/json unmarshals
+// into its struct field, matched case insensitive like encoding/json.
+func (def *ArgsDef) passedInJSONObject(index int, callerObject map[string]json.RawMessage) bool {
+	field := def.argStructFields[index].Field
+	key := strings.Split(field.Tag.Get("json"), ",")[0]
+	if key == "-" {
+		return false
+	}
+	if key == "" {
+		key = field.Name
+	}
+	for k, value := range callerObject {
+		if strings.EqualFold(k, key) && string(value) != "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// assignJSONArg assigns the JSON value to the argument argVal with index
+// and checks its constraints like assignStringArg.
+// JSON strings are assigned like string arguments except
+// for types that unmarshal JSON strings themselves,
+// see unmarshalsJSONString.
+func (def *ArgsDef) assignJSONArg(index int, argVal reflect.Value, value json.RawMessage) error {
+	if len(value) == 0 || string(value) == "null" {
+		return def.assignStringArg(index, argVal, "", false)
+	}
+	if value[0] == '"' && !unmarshalsJSONString(argVal.Type()) {
+		var str string
+		err := json.Unmarshal(value, &str)
+		if err != nil {
+			return ArgError{Arg: def.argInfos[index].Name, Err: err}
+		}
+		return def.assignStringArg(index, argVal, str, true)
+	}
+	arg := &def.argInfos[index]
+	if len(arg.Enum) > 0 && !containsString(arg.Enum, string(value)) {
+		return ArgError{Arg: arg.Name, Err: fmt.Errorf("argument <%s> must be one of %s, but is %s", arg.Name, strings.Join(arg.Enum, ", "), value)}
+	}
+	err := json.Unmarshal(value, argVal.Addr().Interface())
+	if err != nil {
+		return ArgError{Arg: arg.Name, Err: fmt.Errorf("can't unmarshal JSON %s as argument <%s> of type %s: %w", value, arg.Name, argVal.Type(), err)}
+	}
+	return def.checkConstraints(index, argVal)
+}
+
+var typeOfJSONUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unmarshalsJSONString returns if encoding/json unmarshals
+// JSON strings as t itself: base64 encoded []byte
+// and types implementing json.Unmarshaler.
+func unmarshalsJSONString(t reflect.Type) bool {
+	return t == reflect.TypeOf([]byte(nil)) || reflect.PtrTo(t).Implements(typeOfJSONUnmarshaler)
+}
+
 func (def *ArgsDef) StringArgsFunc(commandFunc interface{}, resultsHandlers []ResultsHandler) (StringArgsFunc, error) {
-	dispatcher, err := newFuncDispatcher(def, commandFunc)
+	handlersFunc, err := def.stringArgsHandlersFunc(commandFunc)
 	if err != nil {
 		return nil, err
 	}
 
 	f := func(ctx context.Context, callerArgs ...string) error {
-		argVals, err := def.argValsFromStringArgs(callerArgs)
+		return handlersFunc(ctx, callerArgs, resultsHandlers)
+	}
+	return f, nil
+}
+
+func (def *ArgsDef) stringArgsHandlersFunc(commandFunc interface{}) (stringArgsHandlersFunc, error) {
+	dispatcher, err := newFuncDispatcher(def, commandFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	f := func(ctx context.Context, callerArgs []string, resultsHandlers []ResultsHandler) error {
+		argVals, err := def.argValsFromStringArgs(ctx, callerArgs)
 		if err != nil {
-			return err
+			return UsageError{Err: err}
 		}
 		return dispatcher.callWithResultsHandlers(ctx, argVals, resultsHandlers)
 	}
@@ -207,7 +414,7 @@ func (def *ArgsDef) StringMapArgsFunc(commandFunc interface{}, resultsHandlers [
 	f := func(ctx context.Context, callerArgs map[string]string) (err error) {
 		argVals, err := def.argValsFromStringMapArgs(callerArgs)
 		if err != nil {
-			return err
+			return UsageError{Err: err}
 		}
 		return dispatcher.callWithResultsHandlers(ctx, argVals, resultsHandlers)
 	}
@@ -223,7 +430,7 @@ func (def *ArgsDef) MapArgsFunc(commandFunc interface{}, resultsHandlers []Resul
 	f := func(ctx context.Context, callerArgs map[string]interface{}) (err error) {
 		argVals, err := def.argValsFromMapArgs(callerArgs)
 		if err != nil {
-			return err
+			return UsageError{Err: err}
 		}
 		return dispatcher.callWithResultsHandlers(ctx, argVals, resultsHandlers)
 	}
@@ -239,7 +446,7 @@ func (def *ArgsDef) JSONArgsFunc(commandFunc interface{}, resultsHandlers []Resu
 	f := func(ctx context.Context, callerArgs []byte) (err error) {
 		argVals, err := def.argValsFromJSON(callerArgs)
 		if err != nil {
-			return err
+			return UsageError{Err: err}
 		}
 		return dispatcher.callWithResultsHandlers(ctx, argVals, resultsHandlers)
 	}
@@ -253,9 +460,9 @@ func (def *ArgsDef) StringArgsResultValuesFunc(commandFunc interface{}) (StringA
 	}
 
 	f := func(ctx context.Context, args []string) ([]reflect.Value, error) {
-		argVals, err := def.argValsFromStringArgs(args)
+		argVals, err := def.argValsFromStringArgs(ctx, args)
 		if err != nil {
-			return nil, err
+			return nil, UsageError{Err: err}
 		}
 		return dispatcher.callAndReturnResults(ctx, argVals)
 	}
@@ -271,7 +478,7 @@ func (def *ArgsDef) StringMapArgsResultValuesFunc(commandFunc interface{}) (Stri
 	f := func(ctx context.Context, args map[string]string) ([]reflect.Value, error) {
 		argVals, err := def.argValsFromStringMapArgs(args)
 		if err != nil {
-			return nil, err
+			return nil, UsageError{Err: err}
 		}
 		return dispatcher.callAndReturnResults(ctx, argVals)
 	}
@@ -287,7 +494,7 @@ func (def *ArgsDef) MapArgsResultValuesFunc(commandFunc interface{}) (MapArgsRes
 	f := func(ctx context.Context, args map[string]interface{}) ([]reflect.Value, error) {
 		argVals, err := def.argValsFromMapArgs(args)
 		if err != nil {
-			return nil, err
+			return nil, UsageError{Err: err}
 		}
 		return dispatcher.callAndReturnResults(ctx, argVals)
 	}
@@ -303,7 +510,7 @@ func (def *ArgsDef) JSONArgsResultValuesFunc(commandFunc interface{}) (JSONArgsR
 	f := func(ctx context.Context, argsJSON []byte) ([]reflect.Value, error) {
 		argVals, err := def.argValsFromJSON(argsJSON)
 		if err != nil {
-			return nil, err
+			return nil, UsageError{Err: err}
 		}
 		return dispatcher.callAndReturnResults(ctx, argVals)
 	}
@@ -0,0 +1,444 @@
+// Package argsdefcheck implements a go/analysis analyzer that checks
+// command.ArgsDef structs against the command functions they are used with.
+//
+// Mismatches in the number, order, or types of arguments are otherwise
+// only detected at runtime when the command function gets registered.
+// The analyzer lives in its own module to keep golang.org/x/tools
+// out of the dependencies of the command package
+// and can be used with go vet:
+//
+//	go install github.com/ungerik/go-command/argsdefcheck/cmd/argsdefcheck@latest
+//	go vet -vettool=$(which argsdefcheck) ./...
+package argsdefcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const (
+	commandPkgPath    = "github.com/ungerik/go-command"
+	gorillamuxPkgPath = commandPkgPath + "/gorillamux"
+	htmlformPkgPath   = commandPkgPath + "/htmlform"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "argsdefcheck",
+	Doc:      "check that command.ArgsDef structs match the functions they are registered with",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// argNameTag is the struct field tag used for argument names,
+// see command.ArgNameTag
+var argNameTag = "arg"
+
+// resultNameTag is the struct field tag used for result names,
+// see command.ResultNameTag
+var resultNameTag = "result"
+
+func init() {
+	Analyzer.Flags.StringVar(&argNameTag, "argtag", argNameTag, "struct field tag used for argument names")
+	Analyzer.Flags.StringVar(&resultNameTag, "resulttag", resultNameTag, "struct field tag used for result names")
+}
+
+// registration describes the call parameter indices
+// of the command function and the args struct pointer.
+type registration struct {
+	funcIndex int
+	argsIndex int
+}
+
+// registrations maps the full names of functions and methods
+// that register a command function with an args struct.
+var registrations = map[string]registration{
+	"(*" + commandPkgPath + ".StringArgsDispatcher).AddCommand":                 {2, 3},
+	"(*" + commandPkgPath + ".StringArgsDispatcher).MustAddCommand":             {2, 3},
+	"(*" + commandPkgPath + ".StringArgsDispatcher).AddCommandWithResults":      {2, 3},
+	"(*" + commandPkgPath + ".StringArgsDispatcher).MustAddCommandWithResults":  {2, 3},
+	"(*" + commandPkgPath + ".StringArgsDispatcher).ReplaceCommand":             {2, 3},
+	"(*" + commandPkgPath + ".StringArgsDispatcher).MustReplaceCommand":         {2, 3},
+	"(*" + commandPkgPath + ".StringArgsDispatcher).ReplaceCommandWithResults":  {2, 3},
+	"(*" + commandPkgPath + ".StringArgsDispatcher).AddDefaultCommand":          {1, 2},
+	"(*" + commandPkgPath + ".StringArgsDispatcher).MustAddDefaultCommand":      {1, 2},
+	"(*" + commandPkgPath + ".SuperStringArgsDispatcher).AddDefaultCommand":     {1, 2},
+	"(*" + commandPkgPath + ".SuperStringArgsDispatcher).MustAddDefaultCommand": {1, 2},
+	"(*" + commandPkgPath + ".CommandTree).AddCommand":                          {2, 3},
+	"(*" + commandPkgPath + ".CommandTree).MustAddCommand":                      {2, 3},
+	"(*" + commandPkgPath + ".CommandTree).AddCommandWithResults":               {2, 3},
+	"(*" + commandPkgPath + ".CommandTree).AddDefaultCommand":                   {1, 2},
+	"(*" + commandPkgPath + ".CommandTree).MustAddDefaultCommand":               {1, 2},
+
+	gorillamuxPkgPath + ".CommandHandler":                {0, 1},
+	gorillamuxPkgPath + ".CommandHandlerWithQueryParams": {0, 1},
+	gorillamuxPkgPath + ".CommandHandlerRequestBodyArg":  {1, 2},
+
+	"(*" + gorillamuxPkgPath + ".API).Command":                {3, 4},
+	"(*" + gorillamuxPkgPath + ".API).CommandWithQueryParams": {3, 4},
+	"(*" + gorillamuxPkgPath + ".API).CommandRequestBodyArg":  {4, 5},
+	"(*" + gorillamuxPkgPath + ".API).CommandJSONBodyFields":  {3, 4},
+
+	htmlformPkgPath + ".NewHandler":     {0, 1},
+	htmlformPkgPath + ".MustNewHandler": {0, 1},
+
+	commandPkgPath + ".GetStringArgsFuncWithResults":     {0, 1},
+	commandPkgPath + ".MustGetStringArgsFuncWithResults": {0, 1},
+}
+
+// resultsRegistrations maps the full names of functions and methods
+// from registrations that also have a results struct pointer
+// parameter to the index of that parameter.
+var resultsRegistrations = map[string]int{
+	"(*" + commandPkgPath + ".StringArgsDispatcher).AddCommandWithResults":     4,
+	"(*" + commandPkgPath + ".StringArgsDispatcher).MustAddCommandWithResults": 4,
+	"(*" + commandPkgPath + ".StringArgsDispatcher).ReplaceCommandWithResults": 4,
+	"(*" + commandPkgPath + ".CommandTree).AddCommandWithResults":              4,
+
+	commandPkgPath + ".GetStringArgsFuncWithResults":     2,
+	commandPkgPath + ".MustGetStringArgsFuncWithResults": 2,
+}
+
+func init() {
+	for _, kind := range []string{
+		"StringArgs",
+		"StringMapArgs",
+		"MapArgs",
+		"JSONArgs",
+		"StringArgsResultValues",
+		"StringMapArgsResultValues",
+		"MapArgsResultValues",
+		"JSONArgsResultValues",
+	} {
+		registrations[commandPkgPath+".Get"+kind+"Func"] = registration{0, 1}
+		registrations[commandPkgPath+".MustGet"+kind+"Func"] = registration{0, 1}
+	}
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		callee, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok {
+			return
+		}
+		reg, ok := registrations[callee.FullName()]
+		if !ok || reg.funcIndex >= len(call.Args) || reg.argsIndex >= len(call.Args) {
+			return
+		}
+		checkRegistration(pass, call.Args[reg.funcIndex], call.Args[reg.argsIndex])
+		if resultsIndex, ok := resultsRegistrations[callee.FullName()]; ok && resultsIndex < len(call.Args) {
+			checkResults(pass, call.Args[reg.funcIndex], call.Args[resultsIndex])
+		}
+	})
+
+	return nil, nil
+}
+
+// argField is a flattened args or results struct field
+// like reflection.NamedStructField
+type argField struct {
+	field *types.Var
+	name  string
+}
+
+func checkRegistration(pass *analysis.Pass, funcExpr, argsExpr ast.Expr) {
+	argsType := pass.TypesInfo.TypeOf(argsExpr)
+	if argsType == nil {
+		return
+	}
+	ptr, isPtr := argsType.Underlying().(*types.Pointer)
+	if !isPtr {
+		if _, isStruct := argsType.Underlying().(*types.Struct); isStruct {
+			pass.Reportf(argsExpr.Pos(), "args struct %s must be passed as pointer", argsType)
+		}
+		// Other types like the command.Args interface
+		// can't be checked statically
+		return
+	}
+	argsStruct, ok := ptr.Elem().Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	// command.ArgsDef itself like &command.WithoutArgs defines no args
+	if !isNamed(ptr.Elem(), commandPkgPath, "ArgsDef") && !embedsArgsDef(argsStruct) {
+		pass.Reportf(argsExpr.Pos(), "args struct %s does not embed command.ArgsDef", ptr.Elem())
+		return
+	}
+
+	fields := flatFields(argsStruct, argNameTag)
+
+	names := make(map[string]*types.Var, len(fields))
+	for _, f := range fields {
+		if prev, exists := names[f.name]; exists {
+			pass.Reportf(f.field.Pos(), "duplicate argument name %q of field %s, already used by field %s", f.name, f.field.Name(), prev.Name())
+			continue
+		}
+		names[f.name] = f.field
+		if !isSupportedArgType(f.field.Type()) {
+			pass.Reportf(f.field.Pos(), "argument %q has unsupported type %s", f.name, f.field.Type())
+		}
+	}
+
+	funcType := pass.TypesInfo.TypeOf(funcExpr)
+	if funcType == nil {
+		return
+	}
+	sig, ok := funcType.Underlying().(*types.Signature)
+	if !ok {
+		if _, isInterface := funcType.Underlying().(*types.Interface); !isInterface {
+			pass.Reportf(funcExpr.Pos(), "expected a function or method, but got %s", funcType)
+		}
+		return
+	}
+
+	funcArgTypes := functionArgTypesWithoutReplaceables(sig)
+	if len(fields) != len(funcArgTypes) {
+		pass.Reportf(
+			argsExpr.Pos(),
+			"number of fields in command.Args struct %s (%d) does not match number of function arguments (%d)",
+			ptr.Elem(),
+			len(fields),
+			len(funcArgTypes),
+		)
+		return
+	}
+	for i, f := range fields {
+		if !types.Identical(f.field.Type(), funcArgTypes[i]) {
+			pass.Reportf(
+				argsExpr.Pos(),
+				"type of command.Args struct field '%s' is %s, which does not match function argument %d type %s",
+				f.field.Name(),
+				f.field.Type(),
+				i,
+				funcArgTypes[i],
+			)
+		}
+	}
+}
+
+// functionArgTypesWithoutReplaceables mirrors the function
+// of the same name in the command package.
+func functionArgTypesWithoutReplaceables(sig *types.Signature) []types.Type {
+	params := sig.Params()
+	argTypes := make([]types.Type, 0, params.Len())
+	for i := 0; i < params.Len(); i++ {
+		t := params.At(i).Type()
+		if i == 0 && isNamed(t, "context", "Context") {
+			continue
+		}
+		if _, isFunc := t.Underlying().(*types.Signature); isFunc {
+			continue
+		}
+		argTypes = append(argTypes, t)
+	}
+	return argTypes
+}
+
+// flatFields mirrors reflection.FlatExportedNamedStructFields
+func flatFields(s *types.Struct, nameTag string) []argField {
+	var fields []argField
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		if field.Embedded() {
+			t := field.Type()
+			if ptr, ok := t.Underlying().(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if embedded, ok := t.Underlying().(*types.Struct); ok {
+				fields = append(fields, flatFields(embedded, nameTag)...)
+			}
+			continue
+		}
+		if !field.Exported() {
+			continue
+		}
+		name, ok := fieldName(field, s.Tag(i), nameTag)
+		if ok {
+			fields = append(fields, argField{field: field, name: name})
+		}
+	}
+	return fields
+}
+
+func fieldName(field *types.Var, tag, nameTag string) (name string, ok bool) {
+	name, hasTag := reflect.StructTag(tag).Lookup(nameTag)
+	if !hasTag {
+		return field.Name(), true
+	}
+	if pos := strings.IndexRune(name, ','); pos != -1 {
+		name = name[:pos]
+	}
+	if name == "-" {
+		return "", false
+	}
+	return name, true
+}
+
+func checkResults(pass *analysis.Pass, funcExpr, resultsExpr ast.Expr) {
+	resultsType := pass.TypesInfo.TypeOf(resultsExpr)
+	if resultsType == nil {
+		return
+	}
+	ptr, isPtr := resultsType.Underlying().(*types.Pointer)
+	if !isPtr {
+		return
+	}
+	resultsStruct, ok := ptr.Elem().Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	if !isNamed(ptr.Elem(), commandPkgPath, "ResultsDef") && !embedsDef(resultsStruct, "ResultsDef") {
+		pass.Reportf(resultsExpr.Pos(), "results struct %s does not embed command.ResultsDef", ptr.Elem())
+		return
+	}
+
+	fields := flatFields(resultsStruct, resultNameTag)
+
+	names := make(map[string]*types.Var, len(fields))
+	for _, f := range fields {
+		if prev, exists := names[f.name]; exists {
+			pass.Reportf(f.field.Pos(), "duplicate result name %q of field %s, already used by field %s", f.name, f.field.Name(), prev.Name())
+			continue
+		}
+		names[f.name] = f.field
+	}
+
+	funcType := pass.TypesInfo.TypeOf(funcExpr)
+	if funcType == nil {
+		return
+	}
+	sig, ok := funcType.Underlying().(*types.Signature)
+	if !ok {
+		return
+	}
+
+	funcResults := sig.Results()
+	resultTypes := make([]types.Type, 0, funcResults.Len())
+	for i := 0; i < funcResults.Len(); i++ {
+		t := funcResults.At(i).Type()
+		if i == funcResults.Len()-1 && isNamed(t, "", "error") {
+			break
+		}
+		resultTypes = append(resultTypes, t)
+	}
+	if len(fields) != len(resultTypes) {
+		pass.Reportf(
+			resultsExpr.Pos(),
+			"number of fields in command.Results struct %s (%d) does not match number of function results (%d)",
+			ptr.Elem(),
+			len(fields),
+			len(resultTypes),
+		)
+		return
+	}
+	for i, f := range fields {
+		if !types.Identical(f.field.Type(), resultTypes[i]) {
+			pass.Reportf(
+				resultsExpr.Pos(),
+				"type of command.Results struct field '%s' is %s, which does not match function result %d type %s",
+				f.field.Name(),
+				f.field.Type(),
+				i,
+				resultTypes[i],
+			)
+		}
+	}
+}
+
+func embedsArgsDef(s *types.Struct) bool {
+	return embedsDef(s, "ArgsDef")
+}
+
+func embedsDef(s *types.Struct, defName string) bool {
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		if !field.Embedded() {
+			continue
+		}
+		t := field.Type()
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if isNamed(t, commandPkgPath, defName) {
+			return true
+		}
+		if embedded, ok := t.Underlying().(*types.Struct); ok && embedsDef(embedded, defName) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNamed(t types.Type, pkgPath, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil {
+		// Predeclared types like error
+		return pkgPath == "" && obj.Name() == name
+	}
+	return obj.Name() == name && obj.Pkg().Path() == pkgPath
+}
+
+func hasPointerMethod(t types.Type, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, nil, name)
+	_, isFunc := obj.(*types.Func)
+	return isFunc
+}
+
+// isSupportedArgType reports if values of type t can be
+// assigned from strings by the command package.
+func isSupportedArgType(t types.Type) bool {
+	switch {
+	case isNamed(t, "time", "Time"),
+		isNamed(t, "time", "Duration"),
+		isNamed(t, "github.com/domonda/go-types/nullable", "Time"),
+		isNamed(t, "github.com/ungerik/go-fs", "FileReader"),
+		hasPointerMethod(t, "UnmarshalText"),
+		hasPointerMethod(t, "UnmarshalJSON"):
+		return true
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&types.IsUntyped == 0 && u.Kind() != types.UnsafePointer && u.Kind() != types.Invalid
+	case *types.Struct:
+		return true
+	case *types.Pointer:
+		return isSupportedArgType(u.Elem())
+	case *types.Slice:
+		if isEmptyInterface(u.Elem()) {
+			_, isNamedSlice := t.(*types.Named)
+			return !isNamedSlice
+		}
+		return isSupportedArgType(u.Elem())
+	case *types.Array:
+		return isSupportedArgType(u.Elem())
+	case *types.Map:
+		_, isNamedMap := t.(*types.Named)
+		return !isNamedMap && isString(u.Key()) && isEmptyInterface(u.Elem())
+	}
+	return false
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return ok && b.Kind() == types.String
+}
+
+func isEmptyInterface(t types.Type) bool {
+	_, isNamed := t.(*types.Named)
+	i, ok := t.Underlying().(*types.Interface)
+	return ok && !isNamed && i.Empty()
+}
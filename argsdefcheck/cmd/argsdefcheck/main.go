@@ -0,0 +1,17 @@
+// Command argsdefcheck checks command.ArgsDef structs against
+// the functions they are registered with.
+//
+// Usage with go vet:
+//
+//	go vet -vettool=$(which argsdefcheck) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/ungerik/go-command/argsdefcheck"
+)
+
+func main() {
+	singlechecker.Main(argsdefcheck.Analyzer)
+}
@@ -0,0 +1,89 @@
+package a
+
+import (
+	"context"
+
+	command "github.com/ungerik/go-command"
+	"github.com/ungerik/go-command/gorillamux"
+)
+
+type okArgs struct {
+	command.ArgsDef
+
+	Name  string `arg:"name"`
+	Count int    `arg:"count"`
+}
+
+func okFunc(ctx context.Context, name string, count int) error { return nil }
+
+type wrongTypeArgs struct {
+	command.ArgsDef
+
+	Name  string `arg:"name"`
+	Count int64  `arg:"count"`
+}
+
+type duplicateArgs struct {
+	command.ArgsDef
+
+	Name  string `arg:"name"`
+	Other int    `arg:"name"` // want `duplicate argument name "name" of field Other, already used by field Name`
+}
+
+type unsupportedArgs struct {
+	command.ArgsDef
+
+	Ch chan int `arg:"ch"` // want `argument "ch" has unsupported type chan int`
+	F  func()   `arg:"f"`  // want `argument "f" has unsupported type func\(\)`
+}
+
+type okResults struct {
+	command.ResultsDef
+
+	User    string `result:"user"`
+	Created bool   `result:"created"`
+}
+
+func createUser(name string, count int) (user string, created bool, err error) { return "", false, nil }
+
+type noArgsDef struct {
+	Name string
+}
+
+func register(disp *command.StringArgsDispatcher) {
+	disp.AddCommand("ok", "", okFunc, &okArgs{})
+	disp.AddCommand("count", "", func(name string) {}, &okArgs{}) // want `number of fields in command.Args struct a.okArgs \(2\) does not match number of function arguments \(1\)`
+	disp.AddCommand("type", "", okFunc, &wrongTypeArgs{})         // want `type of command.Args struct field 'Count' is int64, which does not match function argument 1 type int`
+	disp.AddCommand("dup", "", func(string, int) {}, &duplicateArgs{})
+	disp.AddCommand("chan", "", func(chan int) {}, &unsupportedArgs{}) // want `number of fields in command.Args struct a.unsupportedArgs \(2\) does not match number of function arguments \(1\)`
+	disp.AddCommand("noargs", "", func() {}, &command.WithoutArgs)
+	disp.AddCommand("noargs", "", func(string) {}, &command.WithoutArgs) // want `number of fields in command.Args struct github.com/ungerik/go-command.ArgsDef \(0\) does not match number of function arguments \(1\)`
+
+	disp.ReplaceCommand("ok", "", okFunc, &okArgs{})
+	disp.ReplaceCommand("type", "", okFunc, &wrongTypeArgs{}) // want `type of command.Args struct field 'Count' is int64, which does not match function argument 1 type int`
+
+	disp.AddCommandWithResults("create", "", createUser, &okArgs{}, &okResults{})
+	disp.AddCommandWithResults("noresults", "", func() {}, &command.WithoutArgs, &command.ResultsDef{})
+	disp.ReplaceCommandWithResults("create", "", createUser, &okArgs{}, &okResults{})
+	disp.ReplaceCommandWithResults("create", "", okFunc, &okArgs{}, &okResults{}) // want `number of fields in command.Results struct a.okResults \(2\) does not match number of function results \(0\)`
+	disp.AddCommandWithResults("create", "", okFunc, &okArgs{}, &okResults{})     // want `number of fields in command.Results struct a.okResults \(2\) does not match number of function results \(0\)`
+
+	command.GetStringArgsFunc(okFunc, &noArgsDef{})                 // want `args struct a.noArgsDef does not embed command.ArgsDef`
+	command.GetStringArgsFunc(okFunc, okArgs{})                     // want `args struct a.okArgs must be passed as pointer`
+	command.GetStringArgsFunc("okFunc", &okArgs{})                  // want `expected a function or method, but got string`
+	gorillamux.CommandHandler(func(int, string) {}, &okArgs{}, nil) // want `type of command.Args struct field 'Name' is string, which does not match function argument 0 type int` `type of command.Args struct field 'Count' is int, which does not match function argument 1 type string`
+}
+
+func registerTree(tree *command.CommandTree) {
+	tree.AddCommand("ok", "", okFunc, &okArgs{})
+	tree.AddCommand("count", "", func(name string) {}, &okArgs{})             // want `number of fields in command.Args struct a.okArgs \(2\) does not match number of function arguments \(1\)`
+	tree.AddCommandWithResults("create", "", okFunc, &okArgs{}, &okResults{}) // want `number of fields in command.Results struct a.okResults \(2\) does not match number of function results \(0\)`
+	tree.AddDefaultCommand("", okFunc, &wrongTypeArgs{})                      // want `type of command.Args struct field 'Count' is int64, which does not match function argument 1 type int`
+}
+
+func registerAPI(api *gorillamux.API) {
+	api.Command("GET", "/ok/{name}/{count}", "", okFunc, &okArgs{}, nil)
+	api.Command("GET", "/type/{name}/{count}", "", okFunc, &wrongTypeArgs{}, nil) // want `type of command.Args struct field 'Count' is int64, which does not match function argument 1 type int`
+	api.CommandRequestBodyArg("PUT", "/ok/{count}", "", "name", okFunc, &okArgs{}, nil)
+	api.CommandRequestBodyArg("PUT", "/count/{count}", "", "name", func(name string) {}, &okArgs{}, nil) // want `number of fields in command.Args struct a.okArgs \(2\) does not match number of function arguments \(1\)`
+}
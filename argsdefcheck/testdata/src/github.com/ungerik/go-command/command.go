@@ -0,0 +1,60 @@
+// Package command is a minimal stub of github.com/ungerik/go-command
+package command
+
+type Args interface {
+	NumArgs() int
+}
+
+type ArgsDef struct{}
+
+var WithoutArgs ArgsDef
+
+func (*ArgsDef) NumArgs() int { return 0 }
+
+type ResultsHandler interface{}
+
+type StringArgsFunc func(args ...string) error
+
+func GetStringArgsFunc(commandFunc interface{}, argsStructPtr interface{}, resultsHandlers ...ResultsHandler) (StringArgsFunc, error) {
+	return nil, nil
+}
+
+type StringArgsDispatcher struct{}
+
+func (*StringArgsDispatcher) AddCommand(command, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
+	return nil
+}
+
+type Results interface {
+	NumResults() int
+}
+
+type ResultsDef struct{}
+
+func (*ResultsDef) NumResults() int { return 0 }
+
+func (*StringArgsDispatcher) AddCommandWithResults(command, description string, commandFunc interface{}, args Args, results Results, resultsHandlers ...ResultsHandler) error {
+	return nil
+}
+
+func (*StringArgsDispatcher) ReplaceCommand(command, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
+	return nil
+}
+
+func (*StringArgsDispatcher) ReplaceCommandWithResults(command, description string, commandFunc interface{}, args Args, results Results, resultsHandlers ...ResultsHandler) error {
+	return nil
+}
+
+type CommandTree struct{}
+
+func (*CommandTree) AddCommand(name, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
+	return nil
+}
+
+func (*CommandTree) AddCommandWithResults(name, description string, commandFunc interface{}, args Args, results Results, resultsHandlers ...ResultsHandler) error {
+	return nil
+}
+
+func (*CommandTree) AddDefaultCommand(description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
+	return nil
+}
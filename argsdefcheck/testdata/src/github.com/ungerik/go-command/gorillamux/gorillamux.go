@@ -0,0 +1,14 @@
+// Package gorillamux is a minimal stub of github.com/ungerik/go-command/gorillamux
+package gorillamux
+
+import command "github.com/ungerik/go-command"
+
+func CommandHandler(commandFunc interface{}, args command.Args, resultsWriter interface{}) {}
+
+type API struct{}
+
+func (*API) Command(method, path, description string, commandFunc interface{}, args command.Args, resultsWriter interface{}) {
+}
+
+func (*API) CommandRequestBodyArg(method, path, description, bodyArg string, commandFunc interface{}, args command.Args, resultsWriter interface{}) {
+}
@@ -1,9 +1,35 @@
 package command
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
 )
 
+// assignAny assigns source to destVal if source is assignable
+// to the type of destVal, parses source if it is a string,
+// or converts source via its JSON representation
+// like the values returned by encoding/json.Unmarshal.
 func assignAny(destVal reflect.Value, source interface{}) (err error) {
-	panic("assignAny NOT IMPLEMENTED") // TODO
+	if source == nil {
+		destVal.Set(reflect.Zero(destVal.Type()))
+		return nil
+	}
+	sourceVal := reflect.ValueOf(source)
+	if sourceVal.Type().AssignableTo(destVal.Type()) {
+		destVal.Set(sourceVal)
+		return nil
+	}
+	if str, ok := source.(string); ok {
+		return assignString(destVal, str)
+	}
+	j, err := json.Marshal(source)
+	if err != nil {
+		return fmt.Errorf("assignAny(%s, %T): %w", destVal.Type(), source, err)
+	}
+	err = json.Unmarshal(j, destVal.Addr().Interface())
+	if err != nil {
+		return fmt.Errorf("assignAny(%s, %T): %w", destVal.Type(), source, err)
+	}
+	return nil
 }
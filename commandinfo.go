@@ -0,0 +1,439 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CommandLister is implemented by StringArgsDispatcher,
+// SuperStringArgsDispatcher, and CommandTree
+type CommandLister interface {
+	Commands() []*CommandInfo
+}
+
+// CommandInfo describes a registered command
+// as returned by the Commands method of dispatchers.
+type CommandInfo struct {
+	// Path are the names of the super commands or CommandTree nodes
+	// and the command. Path is empty for the Default command
+	// of a StringArgsDispatcher or the root of a CommandTree.
+	Path        []string
+	Aliases     []string
+	Description string
+	// Args are the arguments of the command.
+	// Use Args.ArgTag to access the struct tags of an argument.
+	Args Args
+	// Results names the results of the command
+	// if registered with a results definition, else nil
+	Results Results
+	// ResultTypes are the result types of the command function
+	// without the error result
+	ResultTypes []reflect.Type
+	// ReturnsError is true if the last result
+	// of the command function is an error
+	ReturnsError bool
+	// Func is the command function
+	Func interface{}
+	// ResultsHandlers are the ResultsHandler registered for the command
+	ResultsHandlers []ResultsHandler
+	// ArgCompleters are the Completer registered per argument name
+	ArgCompleters map[string]Completer
+	// Middleware wraps the calls of Func made by the dispatcher,
+	// the first Middleware is the outermost one.
+	// Use WithMiddleware to apply it to calls of Func
+	// made outside of the dispatcher.
+	Middleware []Middleware
+
+	// set by the dispatchers for CallWithStringMapArgs and CallWithMapArgs
+	stringMapArgsFunc StringMapArgsResultValuesFunc
+	mapArgsFunc       MapArgsResultValuesFunc
+	loggers           []StringArgsCommandLogger
+	persistent        []Args
+}
+
+// Command returns the space separated Path
+func (info *CommandInfo) Command() string {
+	return strings.Join(info.Path, " ")
+}
+
+// MatchesPath returns if path is the Path of the command
+// or the Path with the last element replaced by an alias.
+func (info *CommandInfo) MatchesPath(path []string) bool {
+	if len(path) != len(info.Path) {
+		return false
+	}
+	if equalPaths(info.Path, path) {
+		return true
+	}
+	if len(path) == 0 || !equalPaths(info.Path[:len(path)-1], path[:len(path)-1]) {
+		return false
+	}
+	return containsString(info.Aliases, path[len(path)-1])
+}
+
+// CallWithStringMapArgs calls Func with args by name
+// like a StringMapArgsResultValuesFunc the same way
+// as the dispatcher of the command:
+// the call is logged with the loggers of the dispatcher,
+// the persistent args of CommandTree nodes are passed
+// from args via the context, see PersistentArgsFromContext,
+// and the call is wrapped with WithCommand and Middleware.
+// Streamed results have to be closed with DiscardResultStreams.
+func (info *CommandInfo) CallWithStringMapArgs(ctx context.Context, args map[string]string) ([]reflect.Value, error) {
+	commandFunc := info.stringMapArgsFunc
+	if commandFunc == nil {
+		var err error
+		commandFunc, err = GetStringMapArgsResultValuesFunc(info.Func, info.Args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ctx, err := info.callContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.loggers) > 0 {
+		logArgs := make([]string, 0, len(args))
+		for _, arg := range info.Args.Args() {
+			if value, ok := args[arg.Name]; ok {
+				logArgs = append(logArgs, "--"+arg.Name+"="+value)
+			}
+		}
+		info.log(logArgs)
+	}
+	return commandFunc(ctx, args)
+}
+
+// CallWithMapArgs calls Func with args by name
+// like a MapArgsResultValuesFunc the same way as the dispatcher
+// of the command, see CallWithStringMapArgs.
+// Values of args that are not strings are logged
+// and passed as persistent args in their JSON representation.
+func (info *CommandInfo) CallWithMapArgs(ctx context.Context, args map[string]interface{}) ([]reflect.Value, error) {
+	commandFunc := info.mapArgsFunc
+	if commandFunc == nil {
+		var err error
+		commandFunc, err = GetMapArgsResultValuesFunc(info.Func, info.Args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var persistentValues map[string]string
+	if len(info.persistent) > 0 {
+		persistentValues = make(map[string]string, len(args))
+		for name, value := range args {
+			if value != nil {
+				persistentValues[name] = mapArgString(value)
+			}
+		}
+	}
+	ctx, err := info.callContext(ctx, persistentValues)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.loggers) > 0 {
+		logArgs := make([]string, 0, len(args))
+		for _, arg := range info.Args.Args() {
+			if value, ok := args[arg.Name]; ok && value != nil {
+				logArgs = append(logArgs, "--"+arg.Name+"="+mapArgString(value))
+			}
+		}
+		info.log(logArgs)
+	}
+	return commandFunc(ctx, args)
+}
+
+// callContext returns ctx with the persistent args
+// from persistentValues, WithCommand, and the Middleware
+func (info *CommandInfo) callContext(ctx context.Context, persistentValues map[string]string) (context.Context, error) {
+	for _, persistent := range info.persistent {
+		var err error
+		ctx, err = withPersistentArgs(ctx, persistent, persistentValues)
+		if err != nil {
+			return nil, UsageError{Err: err}
+		}
+	}
+	ctx = WithCommand(ctx, info.Command(), info.Description)
+	return WithMiddleware(ctx, info.Middleware...), nil
+}
+
+func (info *CommandInfo) log(args []string) {
+	for _, logger := range info.loggers {
+		logger.LogStringArgsCommand(info.Command(), args)
+	}
+}
+
+// mapArgString returns value if it is a string
+// or else its JSON representation
+func mapArgString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	j, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(j)
+}
+
+// LookupCommand returns the command listed by lister with path
+// where the last path element can also be an alias of the command,
+// or nil if there is no such command.
+// The dispatchers of this package look up the command directly
+// instead of searching the result of Commands.
+func LookupCommand(lister CommandLister, path []string) *CommandInfo {
+	for _, name := range path {
+		if name == Default {
+			return nil
+		}
+	}
+	if l, ok := lister.(commandInfoLookup); ok {
+		return l.lookupCommandInfo(path)
+	}
+	return findCommandInfo(lister.Commands(), func(p []string) bool { return equalPaths(p, path) })
+}
+
+// LookupCommandByName returns the command listed by lister
+// with the path elements joined by separator as name
+// where the last path element can also be an alias of the command,
+// or nil if there is no such command.
+// An empty name returns the Default command.
+func LookupCommandByName(lister CommandLister, name, separator string) *CommandInfo {
+	if name == "" {
+		return LookupCommand(lister, nil)
+	}
+	if info := LookupCommand(lister, strings.Split(name, separator)); info != nil {
+		return info
+	}
+	// Command names can contain the separator
+	return findCommandInfo(lister.Commands(), func(p []string) bool { return strings.Join(p, separator) == name })
+}
+
+// commandInfoLookup is implemented by the dispatchers of this package
+type commandInfoLookup interface {
+	lookupCommandInfo(path []string) *CommandInfo
+}
+
+// findCommandInfo returns the first of infos whose Path matches,
+// or the first with the Path with the last element replaced
+// by an alias that matches, or nil.
+func findCommandInfo(infos []*CommandInfo, match func(path []string) bool) *CommandInfo {
+	for _, info := range infos {
+		if match(info.Path) {
+			return info
+		}
+	}
+	for _, info := range infos {
+		if len(info.Path) == 0 {
+			continue
+		}
+		n := len(info.Path) - 1
+		parent := info.Path[:n:n]
+		for _, alias := range info.Aliases {
+			if match(append(parent, alias)) {
+				return info
+			}
+		}
+	}
+	return nil
+}
+
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (cmd *stringArgsCommand) info(path []string) *CommandInfo {
+	info := &CommandInfo{
+		Path:            path,
+		Aliases:         append([]string(nil), cmd.aliases...),
+		Description:     cmd.description,
+		Args:            cmd.args,
+		Results:         cmd.results,
+		Func:            cmd.commandFunc,
+		ResultsHandlers: cmd.resultsHandlers,
+
+		stringMapArgsFunc: cmd.stringMapArgsFunc,
+		mapArgsFunc:       cmd.mapArgsFunc,
+	}
+	funcType := reflect.TypeOf(cmd.commandFunc)
+	numOut := funcType.NumOut()
+	if numOut > 0 && funcType.Out(numOut-1) == typeOfError {
+		info.ReturnsError = true
+		numOut--
+	}
+	for i := 0; i < numOut; i++ {
+		info.ResultTypes = append(info.ResultTypes, funcType.Out(i))
+	}
+	if len(cmd.completers) > 0 {
+		info.ArgCompleters = make(map[string]Completer, len(cmd.completers))
+		for arg, completer := range cmd.completers {
+			info.ArgCompleters[arg] = completer
+		}
+	}
+	return info
+}
+
+// appendPath returns path with name appended
+// if name is not the Default command
+func appendPath(path []string, name string) []string {
+	if name == Default {
+		return path
+	}
+	return append(append([]string(nil), path...), name)
+}
+
+func sortCommandInfos(infos []*CommandInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Command() < infos[j].Command()
+	})
+}
+
+// Commands returns the registered commands sorted by their path
+func (disp *StringArgsDispatcher) Commands() []*CommandInfo {
+	return disp.commandInfos(nil)
+}
+
+// commandInfos returns the commands with path prepended to their paths
+func (disp *StringArgsDispatcher) commandInfos(path []string) []*CommandInfo {
+	reg := disp.commands()
+	infos := make([]*CommandInfo, 0, len(reg.comm))
+	for name, cmd := range reg.comm {
+		infos = append(infos, disp.commandInfo(appendPath(path, name), cmd))
+	}
+	sortCommandInfos(infos)
+	return infos
+}
+
+func (disp *StringArgsDispatcher) commandInfo(path []string, cmd *stringArgsCommand) *CommandInfo {
+	info := cmd.info(path)
+	info.Middleware = append([]Middleware(nil), disp.middleware...)
+	info.loggers = disp.loggers
+	return info
+}
+
+func (disp *StringArgsDispatcher) lookupCommandInfo(path []string) *CommandInfo {
+	return disp.lookupCommandInfoWithPrefix(nil, path)
+}
+
+// lookupCommandInfoWithPrefix returns the command with the name or alias
+// of the single element of path, or the Default command for an empty path,
+// with prefix prepended to its path
+func (disp *StringArgsDispatcher) lookupCommandInfoWithPrefix(prefix, path []string) *CommandInfo {
+	if len(path) > 1 {
+		return nil
+	}
+	name := Default
+	if len(path) == 1 {
+		name = path[0]
+	}
+	cmd, found := disp.commands().lookup(name, false)
+	if !found {
+		return nil
+	}
+	return disp.commandInfo(appendPath(prefix, cmd.command), cmd)
+}
+
+// Commands returns the registered commands of all
+// super commands sorted by their path
+func (disp *SuperStringArgsDispatcher) Commands() []*CommandInfo {
+	var infos []*CommandInfo
+	for superCommand, sub := range disp.superCommands() {
+		for _, info := range sub.commandInfos(appendPath(nil, superCommand)) {
+			infos = append(infos, disp.withMiddleware(info))
+		}
+	}
+	sortCommandInfos(infos)
+	return infos
+}
+
+func (disp *SuperStringArgsDispatcher) lookupCommandInfo(path []string) *CommandInfo {
+	subs := disp.superCommands()
+	if len(path) > 0 {
+		if sub, ok := subs[path[0]]; ok {
+			if info := sub.lookupCommandInfoWithPrefix(path[:1], path[1:]); info != nil {
+				return disp.withMiddleware(info)
+			}
+		}
+	}
+	if sub, ok := subs[Default]; ok {
+		if info := sub.lookupCommandInfoWithPrefix(nil, path); info != nil {
+			return disp.withMiddleware(info)
+		}
+	}
+	return nil
+}
+
+// withMiddleware prepends the middleware of disp to info.Middleware
+func (disp *SuperStringArgsDispatcher) withMiddleware(info *CommandInfo) *CommandInfo {
+	info.Middleware = append(append([]Middleware(nil), disp.middleware...), info.Middleware...)
+	return info
+}
+
+// Commands returns the commands of the node and all its sub nodes
+// sorted by their path starting at the root of the tree
+func (t *CommandTree) Commands() []*CommandInfo {
+	var infos []*CommandInfo
+	t.walk(func(node *CommandTree) {
+		if node.command != nil {
+			infos = append(infos, node.commandInfo())
+		}
+	})
+	sortCommandInfos(infos)
+	return infos
+}
+
+// lookupCommandInfo returns the default command of the node
+// with the names or aliases of path starting at the root
+// if it is the node t or one of its sub nodes
+func (t *CommandTree) lookupCommandInfo(path []string) *CommandInfo {
+	node := t.root()
+	for _, name := range path {
+		child, found := node.children[name]
+		if !found {
+			child, found = node.aliases[name]
+		}
+		if !found {
+			return nil
+		}
+		node = child
+	}
+	if node.command == nil {
+		return nil
+	}
+	for n := node; n != t; n = n.parent {
+		if n.parent == nil {
+			return nil
+		}
+	}
+	return node.commandInfo()
+}
+
+func (t *CommandTree) commandInfo() *CommandInfo {
+	info := t.command.info(t.pathNames())
+	info.Aliases = append([]string(nil), t.aliasNames...)
+	info.Middleware = t.pathMiddleware()
+	info.loggers = t.root().loggers
+	info.persistent = t.pathPersistentArgs()
+	return info
+}
+
+// pathNames returns the names of the nodes
+// from the root to t without the root
+func (t *CommandTree) pathNames() []string {
+	if t.parent == nil {
+		return nil
+	}
+	return appendPath(t.parent.pathNames(), t.name)
+}
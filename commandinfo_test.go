@@ -0,0 +1,148 @@
+package command
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Commands(t *testing.T) {
+	disp := NewSuperStringArgsDispatcher()
+	disp.MustAddDefaultCommand("Default", func() {}, new(struct{ ArgsDef }))
+	sub := disp.MustAddSuperCommand("node")
+	sub.MustAddCommand("remove", "Removes a node", func(ctx context.Context, name string) (int, error) { return 0, nil }, new(treeTestNameArgs), Println)
+	sub.MustAddAlias("remove", "rm")
+	sub.MustSetArgCompleter("remove", "name", CompleterFunc(func(ctx context.Context, prefix string) ([]string, error) { return nil, nil }))
+
+	infos := disp.Commands()
+	if !assert.Len(t, infos, 2) {
+		return
+	}
+	assert.Empty(t, infos[0].Path)
+	assert.Equal(t, "Default", infos[0].Description)
+
+	info := infos[1]
+	assert.Equal(t, []string{"node", "remove"}, info.Path)
+	assert.Equal(t, "node remove", info.Command())
+	assert.Equal(t, []string{"rm"}, info.Aliases)
+	assert.Equal(t, "Removes a node", info.Description)
+	assert.Equal(t, "Node name", info.Args.ArgTag(0, ArgDescriptionTag))
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(0)}, info.ResultTypes)
+	assert.True(t, info.ReturnsError)
+	assert.Len(t, info.ResultsHandlers, 1)
+	assert.Contains(t, info.ArgCompleters, "name")
+
+	subInfos := sub.Commands()
+	if assert.Len(t, subInfos, 1) {
+		assert.Equal(t, []string{"remove"}, subInfos[0].Path)
+	}
+
+	tree, err := CommandTreeFromSuperDispatcher(disp)
+	assert.NoError(t, err)
+	treeInfos := tree.Commands()
+	if assert.Len(t, treeInfos, 2) {
+		assert.Empty(t, treeInfos[0].Path)
+		assert.Equal(t, info.Path, treeInfos[1].Path)
+		assert.Equal(t, info.Aliases, treeInfos[1].Aliases)
+	}
+}
+
+// commandList is a CommandLister without direct lookup
+type commandList []*CommandInfo
+
+func (l commandList) Commands() []*CommandInfo { return l }
+
+func Test_LookupCommand(t *testing.T) {
+	disp := NewSuperStringArgsDispatcher()
+	disp.MustAddDefaultCommand("Default", func() {}, new(struct{ ArgsDef }))
+	sub := disp.MustAddSuperCommand("node")
+	sub.MustAddCommand("remove", "", func(name string) {}, new(treeTestNameArgs))
+	sub.MustAddAlias("remove", "rm")
+	sub.MustAddCommand("list.all", "", func() {}, new(struct{ ArgsDef }))
+	disp.MustAddSuperCommand("cluster").MustAddDefaultCommand("", func() {}, new(struct{ ArgsDef }))
+	tree, err := CommandTreeFromSuperDispatcher(disp)
+	assert.NoError(t, err)
+
+	for _, lister := range []CommandLister{disp, tree, commandList(disp.Commands())} {
+		name := reflect.TypeOf(lister).String()
+		lookup := func(path ...string) []string {
+			info := LookupCommand(lister, path)
+			if info == nil {
+				return []string{"<nil>"}
+			}
+			return info.Path
+		}
+		assert.Empty(t, lookup(), name)
+		assert.Equal(t, []string{"node", "remove"}, lookup("node", "remove"), name)
+		assert.Equal(t, []string{"node", "remove"}, lookup("node", "rm"), name)
+		assert.Equal(t, []string{"cluster"}, lookup("cluster"), name)
+		assert.Equal(t, []string{"<nil>"}, lookup("node"), name)
+		assert.Equal(t, []string{"<nil>"}, lookup("node", "rm", "x"), name)
+		assert.Equal(t, []string{"<nil>"}, lookup("node", ""), name)
+
+		info := LookupCommandByName(lister, "node.list.all", ".")
+		if assert.NotNil(t, info, name) {
+			assert.Equal(t, []string{"node", "list.all"}, info.Path, "separator in command name")
+		}
+		info = LookupCommandByName(lister, "node/rm", "/")
+		if assert.NotNil(t, info, name) {
+			assert.Equal(t, []string{"node", "remove"}, info.Path)
+		}
+		assert.Nil(t, LookupCommandByName(lister, "node.rm.", "."), name)
+	}
+
+	info := &CommandInfo{Path: []string{"node", "remove"}, Aliases: []string{"rm"}}
+	assert.True(t, info.MatchesPath([]string{"node", "remove"}))
+	assert.True(t, info.MatchesPath([]string{"node", "rm"}))
+	assert.False(t, info.MatchesPath([]string{"rm"}))
+	assert.False(t, info.MatchesPath([]string{"cluster", "rm"}))
+}
+
+func Test_CommandInfo_Call(t *testing.T) {
+	var (
+		calls  []string
+		logged []string
+	)
+	tree := newTestCommandTree(&calls)
+	tree.loggers = []StringArgsCommandLogger{StringArgsCommandLoggerFunc(func(command string, args []string) {
+		logged = append(logged, command+" "+strings.Join(args, " "))
+	})}
+	tree.Use(func(next Invoker) Invoker {
+		return func(ctx context.Context, inv *Invocation) ([]reflect.Value, error) {
+			calls = append(calls, "middleware "+inv.Command)
+			return next(ctx, inv)
+		}
+	})
+	ctx := context.Background()
+
+	info := LookupCommand(tree, []string{"cluster", "node", "drain"})
+	if !assert.NotNil(t, info) {
+		return
+	}
+	_, err := info.CallWithStringMapArgs(ctx, map[string]string{"name": "n1"})
+	assert.NoError(t, err)
+	_, err = info.CallWithStringMapArgs(ctx, map[string]string{"name": "n2", "cluster": "prod"})
+	assert.NoError(t, err)
+	_, err = info.CallWithMapArgs(ctx, map[string]interface{}{"name": "n3", "cluster": "test"})
+	assert.NoError(t, err)
+	_, err = info.CallWithMapArgs(ctx, map[string]interface{}{"name": "n4", "cluster": nil})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"middleware cluster node drain", "drain local n1",
+		"middleware cluster node drain", "drain prod n2",
+		"middleware cluster node drain", "drain test n3",
+		"middleware cluster node drain", "drain local n4",
+	}, calls, "persistent args from args or default")
+	assert.Equal(t, []string{
+		"cluster node drain --name=n1",
+		"cluster node drain --name=n2",
+		"cluster node drain --name=n3",
+		"cluster node drain --name=n4",
+	}, logged)
+
+	_, err = (&CommandInfo{Func: func() {}, Args: new(struct{ ArgsDef })}).CallWithMapArgs(ctx, nil)
+	assert.NoError(t, err, "CommandInfo not from a dispatcher")
+}
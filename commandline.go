@@ -0,0 +1,193 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommandLineError is returned for command lines that can't be split
+type CommandLineError struct {
+	Line string
+	// Column is the 1 based column of the rune
+	// in Line where the error occurred
+	Column int
+	Msg    string
+}
+
+func (e *CommandLineError) Error() string {
+	return fmt.Sprintf("%s at column %d of command line: %s", e.Msg, e.Column, e.Line)
+}
+
+// SplitCommandLine splits a command line into words
+// using POSIX shell quoting rules:
+//
+//   - words are separated by unquoted spaces, tabs, and newlines
+//   - characters between single quotes are taken literally
+//   - characters between double quotes are taken literally
+//     except for backslash escapes of \" \\ \$ \` and newlines
+//   - an unquoted backslash escapes the following character
+//   - a backslash followed by a newline continues the line
+//
+// Empty single or double quoted strings are returned as empty words.
+// Errors for unbalanced quotes are returned as *CommandLineError.
+func SplitCommandLine(line string) ([]string, error) {
+	return splitCommandLine(line, nil)
+}
+
+// SplitCommandLineLookup splits a command line like SplitCommandLine
+// and replaces unquoted or double quoted variable references
+// like ${NAME} or $NAME with the value returned by lookup.
+// A *CommandLineError is returned for variables not found by lookup.
+func SplitCommandLineLookup(line string, lookup func(name string) (value string, found bool)) ([]string, error) {
+	return splitCommandLine(line, func(name string) (string, error) {
+		value, found := lookup(name)
+		if !found {
+			return "", fmt.Errorf("undefined variable $%s", name)
+		}
+		return value, nil
+	})
+}
+
+// splitCommandLine splits line like SplitCommandLine.
+// If expand is not nil, then it is called with the name of
+// unquoted or double quoted variable references like $1 or ${name}
+// to return the value that replaces the reference.
+// Errors from expand are returned as *CommandLineError.
+func splitCommandLine(line string, expand func(name string) (string, error)) (words []string, err error) {
+	var (
+		word    strings.Builder
+		inWord  bool
+		runes   = []rune(line)
+		numRune = len(runes)
+	)
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	errorAt := func(i int, msg string) error {
+		return &CommandLineError{Line: line, Column: i + 1, Msg: msg}
+	}
+	expandVar := func(i int) (next int, err error) {
+		// runes[i] == '$'
+		name, next := scanVarName(runes, i+1)
+		if next == -1 {
+			return 0, errorAt(i, "unterminated ${")
+		}
+		if name == "" {
+			word.WriteRune('$')
+			return i + 1, nil
+		}
+		value, err := expand(name)
+		if err != nil {
+			return 0, errorAt(i, err.Error())
+		}
+		word.WriteString(value)
+		return next, nil
+	}
+
+	for i := 0; i < numRune; {
+		switch r := runes[i]; {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			endWord()
+			i++
+
+		case r == '\\':
+			if i+1 >= numRune {
+				return nil, errorAt(i, "unterminated backslash escape")
+			}
+			if runes[i+1] != '\n' {
+				inWord = true
+				word.WriteRune(runes[i+1])
+			}
+			i += 2
+
+		case r == '\'':
+			inWord = true
+			end := indexRune(runes, i+1, '\'')
+			if end == -1 {
+				return nil, errorAt(i, "unterminated single quote")
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			i = end + 1
+
+		case r == '"':
+			inWord = true
+			start := i
+			i++
+			for {
+				if i >= numRune {
+					return nil, errorAt(start, "unterminated double quote")
+				}
+				c := runes[i]
+				if c == '"' {
+					i++
+					break
+				}
+				switch {
+				case c == '\\' && i+1 < numRune && runes[i+1] == '\n':
+					i += 2
+				case c == '\\' && i+1 < numRune && strings.ContainsRune("\"\\$`", runes[i+1]):
+					word.WriteRune(runes[i+1])
+					i += 2
+				case c == '$' && expand != nil:
+					i, err = expandVar(i)
+					if err != nil {
+						return nil, err
+					}
+				default:
+					word.WriteRune(c)
+					i++
+				}
+			}
+
+		case r == '$' && expand != nil:
+			inWord = true
+			i, err = expandVar(i)
+			if err != nil {
+				return nil, err
+			}
+
+		default:
+			inWord = true
+			word.WriteRune(r)
+			i++
+		}
+	}
+	endWord()
+	return words, nil
+}
+
+// scanVarName returns the variable name starting at runes[start]
+// written as name or {name} and the index after the name.
+// Names consist of ASCII letters, digits, and underscores.
+// If {name} is not terminated, then next is -1.
+func scanVarName(runes []rune, start int) (name string, next int) {
+	if start < len(runes) && runes[start] == '{' {
+		end := indexRune(runes, start+1, '}')
+		if end == -1 {
+			return "", -1
+		}
+		return string(runes[start+1 : end]), end + 1
+	}
+	next = start
+	for next < len(runes) && isVarNameRune(runes[next]) {
+		next++
+	}
+	return string(runes[start:next]), next
+}
+
+func isVarNameRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
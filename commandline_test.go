@@ -0,0 +1,79 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "", want: nil},
+		{line: "  cmd  arg1\targ2 ", want: []string{"cmd", "arg1", "arg2"}},
+		{line: `cmd 'single quoted' "double quoted"`, want: []string{"cmd", "single quoted", "double quoted"}},
+		{line: `cmd 'it''s' "say \"hi\"" back\ slash`, want: []string{"cmd", "its", `say "hi"`, "back slash"}},
+		{line: `cmd '' "" 'a\b' "$1"`, want: []string{"cmd", "", "", `a\b`, "$1"}},
+		{line: `cmd pre"mid"'post'`, want: []string{"cmd", "premidpost"}},
+	}
+	for _, tt := range tests {
+		got, err := SplitCommandLine(tt.line)
+		assert.NoError(t, err, tt.line)
+		assert.Equal(t, tt.want, got, tt.line)
+	}
+
+	errorColumns := map[string]int{
+		`cmd 'open`:        5,
+		`cmd "open`:        5,
+		`cmd "a" 'b' "c`:   13,
+		`cmd trailing\`:    13,
+		`cmd "${PATH"`:     6,
+		"cmd 'überall":     5,
+		`cmd \'a 'b`:       9,
+		"cmd $UNDEFINED x": 5,
+	}
+	for line, column := range errorColumns {
+		_, err := SplitCommandLineLookup(line, func(name string) (string, bool) { return "", name == "PATH" })
+		var lineErr *CommandLineError
+		if assert.ErrorAs(t, err, &lineErr, line) {
+			assert.Equal(t, column, lineErr.Column, line)
+		}
+	}
+}
+
+func Test_DispatchCommandLine(t *testing.T) {
+	var (
+		args   TestCommandArgsDef
+		called *TestCommandArgsDef
+	)
+	lookup := func(name string) (string, bool) {
+		return map[string]string{"NUM": "42", "APP": "my app"}[name], name == "NUM" || name == "APP"
+	}
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("deploy", "", func(i int, s string, b bool) {
+		called = &TestCommandArgsDef{Int0: i, Str1: s, Bool2: b}
+	}, &args)
+
+	_, err := disp.DispatchCommandLine(context.Background(), `deploy 7 'my app' true`)
+	assert.NoError(t, err)
+	assert.Equal(t, &TestCommandArgsDef{Int0: 7, Str1: "my app", Bool2: true}, called)
+
+	_, err = disp.DispatchCommandLineLookup(context.Background(), `deploy $NUM "${APP}s" \
+	true`, lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, &TestCommandArgsDef{Int0: 42, Str1: "my apps", Bool2: true}, called)
+
+	_, err = disp.DispatchCommandLine(context.Background(), `deploy 7 "my app`)
+	assert.True(t, IsUsageError(err))
+
+	super := NewSuperStringArgsDispatcher()
+	super.MustAddSuperCommand("app").MustAddCommand("deploy", "", func(i int, s string, b bool) {
+		called = &TestCommandArgsDef{Int0: i, Str1: s, Bool2: b}
+	}, &args)
+	_, _, err = super.DispatchCommandLine(context.Background(), `app deploy 1 "x y" false`)
+	assert.NoError(t, err)
+	assert.Equal(t, &TestCommandArgsDef{Int0: 1, Str1: "x y"}, called)
+}
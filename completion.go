@@ -0,0 +1,235 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/ungerik/go-fs"
+)
+
+// CompleteCommand is the hidden command called by the completion
+// scripts with the words of the command line to complete
+// if completion is enabled for a dispatcher.
+// The last word is the one to complete and may be empty.
+// The candidates are written line by line.
+const CompleteCommand = "__complete"
+
+// Completer returns completion candidates for an argument value
+type Completer interface {
+	Complete(ctx context.Context, prefix string) ([]string, error)
+}
+
+// CompleterFunc implements Completer
+type CompleterFunc func(ctx context.Context, prefix string) ([]string, error)
+
+func (f CompleterFunc) Complete(ctx context.Context, prefix string) ([]string, error) {
+	return f(ctx, prefix)
+}
+
+// CompletionShells are the shells supported by WriteCompletionScript
+var CompletionShells = []string{"bash", "fish", "zsh"}
+
+var completionScripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`# bash completion for {{.App}}
+_{{.Func}}_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($({{.App}} {{.Complete}} "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+        compopt -o nospace
+    fi
+}
+complete -F _{{.Func}}_complete {{.App}}
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`#compdef {{.App}}
+# zsh completion for {{.App}}
+_{{.Func}}() {
+    local -a candidates
+    candidates=("${(@f)$({{.App}} {{.Complete}} "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    compadd -Q -- "${candidates[@]}"
+}
+compdef _{{.Func}} {{.App}}
+`)),
+	"fish": template.Must(template.New("fish").Parse(`# fish completion for {{.App}}
+function __{{.Func}}_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    {{.App}} {{.Complete}} $tokens[2..-1] "$current" 2>/dev/null
+end
+complete -c {{.App}} -f -a '(__{{.Func}}_complete)'
+`)),
+}
+
+// WriteCompletionScript writes the completion script for shell
+// to writer. Supported shells are listed in CompletionShells.
+// The scripts call appName with the CompleteCommand to get the
+// candidates from the registered commands and their arguments,
+// so completion has to be enabled for the dispatcher of appName.
+func WriteCompletionScript(writer io.Writer, shell, appName string) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported completion shell %q, supported shells: %s", shell, strings.Join(CompletionShells, ", "))
+	}
+	funcName := strings.Map(
+		func(r rune) rune {
+			if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		},
+		appName,
+	)
+	return script.Execute(writer, map[string]string{
+		"App":      appName,
+		"Func":     funcName,
+		"Complete": CompleteCommand,
+	})
+}
+
+// completion handles the CompleteCommand if enabled for a dispatcher
+type completion struct {
+	enabled bool
+	writer  io.Writer
+}
+
+func (c *completion) enable(writer io.Writer) {
+	if writer == nil {
+		writer = os.Stdout
+	}
+	c.enabled = true
+	c.writer = writer
+}
+
+func (c *completion) write(candidates []string) error {
+	for _, candidate := range candidates {
+		_, err := fmt.Fprintln(c.writer, candidate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var typeOfFileReader = reflect.TypeOf((*fs.FileReader)(nil)).Elem()
+
+// completeArg returns the candidates for the argument
+// with index of cmd starting with prefix.
+// A Completer registered for the argument is used if available,
+// else the Arg.Enum values, true and false for bool arguments,
+// or file paths for arguments implementing fs.FileReader.
+func (cmd *stringArgsCommand) completeArg(ctx context.Context, index int, prefix string) ([]string, error) {
+	args := cmd.args.Args()
+	if index >= len(args) {
+		return nil, nil
+	}
+	arg := args[index]
+	if completer, ok := cmd.completers[arg.Name]; ok {
+		return completer.Complete(ctx, prefix)
+	}
+	switch {
+	case len(arg.Enum) > 0:
+		return filterPrefix(arg.Enum, prefix), nil
+	case arg.Type.Kind() == reflect.Bool:
+		return filterPrefix([]string{"true", "false"}, prefix), nil
+	case arg.Type.Implements(typeOfFileReader):
+		return completeFilePath(prefix), nil
+	}
+	return nil, nil
+}
+
+// completeFlag returns the candidates for the flags
+// enabled for a dispatcher starting with prefix
+func completeFlag(flags *dispatchFlags, help *helpFlag, prefix string) []string {
+	var candidates []string
+	if flags.output {
+		candidates = append(candidates, "--"+OutputFlag)
+	}
+	if flags.query {
+		candidates = append(candidates, "--"+QueryFlag)
+	}
+	if help.enabled {
+		candidates = append(candidates, "--help")
+	}
+	return filterPrefix(candidates, prefix)
+}
+
+func completeFilePath(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		candidate := dir + name
+		if entry.IsDir() {
+			candidate += "/"
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+func filterPrefix(list []string, prefix string) []string {
+	var filtered []string
+	for _, s := range list {
+		if strings.HasPrefix(s, prefix) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// splitCompletionWords returns the words preceding the word to complete
+// without enabled flags and the word to complete.
+// If the word to complete is a flag or a flag value,
+// then done is true and the candidates are returned.
+// Words following a "--" terminator are not completed as flags.
+func splitCompletionWords(flags *dispatchFlags, help *helpFlag, words []string) (preceding []string, current string, candidates []string, done bool) {
+	if len(words) == 0 {
+		return nil, "", nil, false
+	}
+	current = words[len(words)-1]
+	preceding = words[:len(words)-1]
+	terminated := false
+	for _, word := range preceding {
+		if word == argsTerminator {
+			terminated = true
+			break
+		}
+	}
+	if len(preceding) > 0 && !terminated {
+		switch preceding[len(preceding)-1] {
+		case "--" + OutputFlag:
+			if flags.output {
+				return nil, current, filterPrefix(OutputFormatNames(), current), true
+			}
+		case "--" + QueryFlag:
+			if flags.query {
+				return nil, current, nil, true
+			}
+		}
+	}
+	if strings.HasPrefix(current, "-") && !terminated {
+		return nil, current, completeFlag(flags, help, current), true
+	}
+	_, preceding, err := flags.parse(preceding)
+	if err != nil {
+		return nil, current, nil, true
+	}
+	return preceding, current, nil, false
+}
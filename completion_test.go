@@ -0,0 +1,74 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StringArgsDispatcher_Completion(t *testing.T) {
+	var (
+		args helpTestArgsDef
+		buf  bytes.Buffer
+	)
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("greet", "", func(name, greet string) {}, &args)
+	disp.MustAddCommand("green", "", func(name, greet string) {}, &args)
+	disp.MustSetArgCompleter("greet", "name", CompleterFunc(func(ctx context.Context, prefix string) ([]string, error) {
+		return filterPrefix([]string{"Alice", "Bob"}, prefix), nil
+	}))
+	disp.EnableOutputFlag(nil)
+	disp.EnableHelp("app", nil)
+	disp.EnableCompletion(&buf)
+
+	complete := func(words ...string) string {
+		buf.Reset()
+		_, err := disp.DispatchCombinedCommandAndArgs(context.Background(), append([]string{CompleteCommand}, words...))
+		assert.NoError(t, err)
+		return buf.String()
+	}
+	assert.Equal(t, "green\ngreet\n", complete("gre"))
+	assert.Equal(t, "green\ngreet\nhelp\n", complete(""))
+	assert.Equal(t, "Alice\n", complete("greet", "A"))
+	assert.Equal(t, "Hello\nHi\n", complete("greet", "Alice", "H"))
+	assert.Equal(t, "Hello\nHi\n", complete("greet", "--output", "csv", "Alice", "H"))
+	assert.Equal(t, "", complete("greet", "Alice", "Hi", ""))
+	assert.Equal(t, "--output\n", complete("greet", "--o"))
+	assert.Equal(t, "json\n", complete("greet", "--output", "j"))
+	assert.Equal(t, "", complete("greet", "--", "--o"), "no flags after --")
+	assert.Equal(t, "Hello\nHi\n", complete("greet", "--", "--output", ""), "no flag values after --")
+	assert.Equal(t, "Hello\nHi\n", complete("greet", "--", "-1", "H"))
+	assert.Equal(t, "green\n", complete("help", "green"))
+
+	for _, shell := range CompletionShells {
+		var script strings.Builder
+		err := WriteCompletionScript(&script, shell, "my-app")
+		assert.NoError(t, err)
+		assert.Contains(t, script.String(), "my-app "+CompleteCommand)
+	}
+	assert.Error(t, WriteCompletionScript(&buf, "cmd.exe", "app"))
+}
+
+func Test_SuperStringArgsDispatcher_Completion(t *testing.T) {
+	var (
+		args helpTestArgsDef
+		buf  bytes.Buffer
+	)
+	super := NewSuperStringArgsDispatcher()
+	super.MustAddSuperCommand("say").MustAddCommand("greet", "", func(name, greet string) {}, &args)
+	super.MustAddSuperCommand("sing").MustAddCommand("song", "", func(name, greet string) {}, &args)
+	super.EnableCompletion(&buf)
+
+	complete := func(words ...string) string {
+		buf.Reset()
+		_, _, err := super.DispatchCombinedCommandAndArgs(context.Background(), append([]string{CompleteCommand}, words...))
+		assert.NoError(t, err)
+		return buf.String()
+	}
+	assert.Equal(t, "say\nsing\n", complete("s"))
+	assert.Equal(t, "greet\n", complete("say", ""))
+	assert.Equal(t, "Hi\n", complete("say", "greet", "Alice", "Hi"))
+}
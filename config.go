@@ -20,6 +20,69 @@ var (
 	ArgNameTag        = "arg"
 	ArgDescriptionTag = "desc"
 
+	// ArgDefaultTag is the struct field tag for Arg.Default
+	ArgDefaultTag = "default"
+	// ArgEnvTag is the struct field tag for Arg.Env
+	ArgEnvTag = "env"
+	// ArgRequiredTag is the struct field tag for Arg.Required,
+	// set to "true" for required arguments
+	ArgRequiredTag = "required"
+	// ArgEnumTag is the struct field tag for Arg.Enum
+	// with comma separated values
+	ArgEnumTag = "enum"
+	// ArgExampleTag is the struct field tag for Arg.Example
+	ArgExampleTag = "example"
+	// ArgSecretTag is the struct field tag for Arg.Secret,
+	// set to "true" for arguments like passwords
+	ArgSecretTag = "secret"
+	// ArgMinTag is the struct field tag for the minimum
+	// of a number argument checked when it is passed
+	// and used in its JSON schema
+	ArgMinTag = "min"
+	// ArgMaxTag is the struct field tag for the maximum
+	// of a number argument checked when it is passed
+	// and used in its JSON schema
+	ArgMaxTag = "max"
+	// ArgMinLengthTag is the struct field tag for the minimum
+	// length of a string argument checked when it is passed
+	// and used in its JSON schema
+	ArgMinLengthTag = "minLength"
+	// ArgMaxLengthTag is the struct field tag for the maximum
+	// length of a string argument checked when it is passed
+	// and used in its JSON schema
+	ArgMaxLengthTag = "maxLength"
+	// ArgPatternTag is the struct field tag for the regular
+	// expression pattern of a string argument checked when it is
+	// passed and used in its JSON schema
+	ArgPatternTag = "pattern"
+
+	// PromptMissingArgs enables prompting for missing required
+	// arguments of commands called with string arguments
+	// like from a command line if os.Stdin is a terminal.
+	// Run and Runner always enable prompting for the commands they run.
+	PromptMissingArgs = false
+
+	ResultNameTag        = "result"
+	ResultDescriptionTag = "desc"
+
+	// ResultColumnTag is the struct field tag used as column name
+	// by result handlers like PrintTable and PrintCSV.
+	ResultColumnTag = "col"
+
+	// OutputFlag is the name of the flag that selects one of the
+	// OutputFormats per command invocation if enabled for a dispatcher.
+	// Example: --output=json
+	OutputFlag = "output"
+
+	// QueryFlag is the name of the flag that applies a Query
+	// to the results per command invocation if enabled for a dispatcher.
+	// Example: --query='items[?status==failed].name'
+	QueryFlag = "query"
+
+	// ResultTimeFormat is used to format time results
+	// by result handlers like PrintTable and PrintCSV.
+	ResultTimeFormat = time.RFC3339
+
 	// TimeFormats used in that order to try parse time strings.
 	// If a time format has not time zone part,
 	// then the date is returned in the local time zone.
@@ -0,0 +1,160 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// argsTerminator ends the flags of a command line,
+// all following words are passed as arguments
+const argsTerminator = "--"
+
+// dispatchFlags parses the OutputFlag and QueryFlag
+// from command arguments if enabled for a dispatcher.
+type dispatchFlags struct {
+	output bool
+	writer io.Writer
+	query  bool
+}
+
+// invocationFlags are the flag values of a command invocation
+type invocationFlags struct {
+	outputFormat string
+	query        *Query
+}
+
+func (flags *dispatchFlags) enableOutput(writer io.Writer) {
+	if writer == nil {
+		writer = os.Stdout
+	}
+	flags.output = true
+	flags.writer = writer
+}
+
+func (flags *dispatchFlags) enableQuery() {
+	flags.query = true
+}
+
+// parse returns the values of the enabled flags
+// and args without the flags.
+func (flags *dispatchFlags) parse(args []string) (inv invocationFlags, remaining []string, err error) {
+	remaining = args
+	if flags.output {
+		format, found, rest, err := extractFlag(remaining, OutputFlag)
+		if err != nil {
+			return inv, nil, err
+		}
+		if found {
+			if _, ok := OutputFormats[format]; !ok {
+				return inv, nil, UsageError{Err: fmt.Errorf("unknown output format %q, supported formats: %s", format, strings.Join(OutputFormatNames(), ", "))}
+			}
+			inv.outputFormat = format
+			remaining = rest
+		}
+	}
+	if flags.query {
+		expr, found, rest, err := extractFlag(remaining, QueryFlag)
+		if err != nil {
+			return inv, nil, err
+		}
+		if found {
+			inv.query, err = ParseQuery(expr)
+			if err != nil {
+				return inv, nil, UsageError{Err: err}
+			}
+			remaining = rest
+		}
+	}
+	return inv, remaining, nil
+}
+
+// resultsHandlers returns the ResultsHandler for the selected output format
+// or registered if no format was selected,
+// wrapped with WithQuery if a query was passed.
+func (flags *dispatchFlags) resultsHandlers(inv invocationFlags, registered []ResultsHandler) []ResultsHandler {
+	handlers := registered
+	if inv.outputFormat != "" {
+		handlers = []ResultsHandler{OutputFormats[inv.outputFormat](flags.writer)}
+	}
+	if inv.query != nil {
+		handlers = []ResultsHandler{WithQuery(inv.query, handlers...)}
+	}
+	return handlers
+}
+
+// extractFlag returns the value of the flag passed as
+// "--name value" or "--name=value" before a "--" terminator
+// and args without the flag.
+// A flag passed more than once is returned as UsageError.
+func extractFlag(args []string, name string) (value string, found bool, remaining []string, err error) {
+	flag := "--" + name
+	remaining = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == argsTerminator:
+			return value, found, append(remaining, args[i:]...), nil
+
+		case arg == flag || strings.HasPrefix(arg, flag+"="):
+			if found {
+				return "", false, nil, UsageError{Err: fmt.Errorf("flag %s passed more than once", flag)}
+			}
+			found = true
+			if arg != flag {
+				value = strings.TrimPrefix(arg, flag+"=")
+				continue
+			}
+			if i+1 >= len(args) {
+				return "", false, nil, UsageError{Err: fmt.Errorf("missing value for flag %s", flag)}
+			}
+			i++
+			value = args[i]
+
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	return value, found, remaining, nil
+}
+
+// extractSwitch returns the value of the boolean flag passed as
+// "--name" or "--name=value" before a "--" terminator
+// and args without the flag.
+// The value is "true" if the flag is passed as "--name".
+// A flag passed more than once is returned as UsageError.
+func extractSwitch(args []string, name string) (value string, found bool, remaining []string, err error) {
+	flag := "--" + name
+	remaining = make([]string, 0, len(args))
+	for i, arg := range args {
+		switch {
+		case arg == argsTerminator:
+			return value, found, append(remaining, args[i:]...), nil
+
+		case arg == flag || strings.HasPrefix(arg, flag+"="):
+			if found {
+				return "", false, nil, UsageError{Err: fmt.Errorf("flag %s passed more than once", flag)}
+			}
+			found = true
+			value = "true"
+			if arg != flag {
+				value = strings.TrimPrefix(arg, flag+"=")
+			}
+
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	return value, found, remaining, nil
+}
+
+// withoutArgsTerminator returns args without the first "--" terminator
+func withoutArgsTerminator(args []string) []string {
+	for i, arg := range args {
+		if arg == argsTerminator {
+			return append(args[:i:i], args[i+1:]...)
+		}
+	}
+	return args
+}
@@ -60,6 +60,7 @@ type funcDispatcher struct {
 	firstArgIsContext bool
 	insertArgs        []insertArg
 	errorIndex        int
+	hasStreamResults  bool
 }
 
 func newFuncDispatcher(argsDef *ArgsDef, commandFunc interface{}) (disp *funcDispatcher, err error) {
@@ -79,6 +80,12 @@ func newFuncDispatcher(argsDef *ArgsDef, commandFunc interface{}) (disp *funcDis
 		disp.errorIndex = -1
 	}
 
+	for i := 0; i < numResults; i++ {
+		if IsResultStreamType(disp.funcType.Out(i)) {
+			disp.hasStreamResults = true
+		}
+	}
+
 	// disp.argReplacements = nil // TODO
 
 	var funcArgTypes []reflect.Type
@@ -103,27 +110,20 @@ func newFuncDispatcher(argsDef *ArgsDef, commandFunc interface{}) (disp *funcDis
 }
 
 func (disp *funcDispatcher) callWithResultsHandlers(ctx context.Context, argVals []reflect.Value, resultsHandlers []ResultsHandler) error {
-	if disp.firstArgIsContext {
-		argVals = append([]reflect.Value{reflect.ValueOf(ctx)}, argVals...)
-	}
-	for _, insert := range disp.insertArgs {
-		argVals = append(argVals[:insert.index], append([]reflect.Value{insert.value}, argVals[insert.index:]...)...)
-	}
+	argVals, resultVals, resultErr := disp.call(ctx, argVals)
+	defer DiscardResultStreams(resultVals)
 
-	var resultVals []reflect.Value
-	if disp.funcType.IsVariadic() {
-		resultVals = disp.funcVal.CallSlice(argVals)
-	} else {
-		resultVals = disp.funcVal.Call(argVals)
+	if record, ok := ctx.Value(resultsRecorderKey{}).(func([]reflect.Value)); ok && resultErr == nil {
+		record(resultVals)
 	}
 
-	var resultErr error
-	if disp.errorIndex != -1 {
-		resultErr, _ = resultVals[disp.errorIndex].Interface().(error)
-		resultVals = resultVals[:disp.errorIndex]
+	argVals = disp.funcArgVals(ctx, argVals)
+	replay, err := replayableResults(resultVals, len(resultsHandlers))
+	if err != nil {
+		return err
 	}
 	for _, resultsHandler := range resultsHandlers {
-		err := resultsHandler.HandleResults(disp.argsDef, argVals, resultVals, resultErr)
+		err := resultsHandler.HandleResults(disp.argsDef, argVals, replay(), resultErr)
 		if err != nil && err != resultErr {
 			return err
 		}
@@ -132,25 +132,87 @@ func (disp *funcDispatcher) callWithResultsHandlers(ctx context.Context, argVals
 	return resultErr
 }
 
+// callAndReturnResults calls the function and returns its results
+// without the error result. Channel and iterator function results
+// are returned as *ResultStream, not as the values returned
+// by the function, and the caller has to pass the results
+// to DiscardResultStreams when they are no longer needed.
 func (disp *funcDispatcher) callAndReturnResults(ctx context.Context, argVals []reflect.Value) ([]reflect.Value, error) {
-	if disp.firstArgIsContext {
-		argVals = append([]reflect.Value{reflect.ValueOf(ctx)}, argVals...)
+	_, resultVals, err := disp.call(ctx, argVals)
+	return resultVals, err
+}
+
+// call calls the function with argVals wrapped by the
+// Middleware from ctx and returns the results without the error result.
+// callArgVals are the argument values the function was called with
+// after Middleware replaced Invocation.ArgVals.
+func (disp *funcDispatcher) call(ctx context.Context, argVals []reflect.Value) (callArgVals, resultVals []reflect.Value, err error) {
+	middleware, _ := ctx.Value(middlewareKey{}).([]Middleware)
+	if len(middleware) == 0 {
+		resultVals, err = disp.invoke(ctx, argVals)
+		return argVals, resultVals, err
 	}
-	for _, insert := range disp.insertArgs {
-		argVals = append(argVals[:insert.index], append([]reflect.Value{insert.value}, argVals[insert.index:]...)...)
+	callArgVals = argVals
+	invoke := chainMiddleware(
+		func(ctx context.Context, inv *Invocation) ([]reflect.Value, error) {
+			callArgVals = inv.ArgVals
+			// Don't pass the middleware on to calls made by the function
+			ctx = context.WithValue(ctx, middlewareKey{}, []Middleware(nil))
+			return disp.invoke(ctx, inv.ArgVals)
+		},
+		middleware,
+	)
+	inv := &Invocation{Args: disp.argsDef, ArgVals: argVals}
+	if info, ok := ctx.Value(commandKey{}).(commandInfo); ok {
+		inv.Command = info.command
+		inv.Description = info.description
+	}
+	resultVals, err = invoke(ctx, inv)
+	return callArgVals, resultVals, err
+}
+
+// invoke calls the function with argVals
+// and returns the results without the error result.
+//
+// Functions with stream results are called with a context
+// that is canceled by DiscardResultStreams, or right after
+// the call if it panicked. All other functions
+// are called with ctx unchanged.
+func (disp *funcDispatcher) invoke(ctx context.Context, argVals []reflect.Value) (resultVals []reflect.Value, resultErr error) {
+	if disp.hasStreamResults {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer func() {
+			if resultVals == nil {
+				cancel()
+				return
+			}
+			wrapResultStreams(ctx, cancel, resultVals)
+		}()
 	}
+	argVals = disp.funcArgVals(ctx, argVals)
 
-	var resultVals []reflect.Value
 	if disp.funcType.IsVariadic() {
 		resultVals = disp.funcVal.CallSlice(argVals)
 	} else {
 		resultVals = disp.funcVal.Call(argVals)
 	}
 
-	var resultErr error
 	if disp.errorIndex != -1 {
 		resultErr, _ = resultVals[disp.errorIndex].Interface().(error)
 		resultVals = resultVals[:disp.errorIndex]
 	}
 	return resultVals, resultErr
 }
+
+// funcArgVals returns argVals with the context
+// and inserted arguments of the function.
+func (disp *funcDispatcher) funcArgVals(ctx context.Context, argVals []reflect.Value) []reflect.Value {
+	if disp.firstArgIsContext {
+		argVals = append([]reflect.Value{reflect.ValueOf(ctx)}, argVals...)
+	}
+	for _, insert := range disp.insertArgs {
+		argVals = append(argVals[:insert.index:insert.index], append([]reflect.Value{insert.value}, argVals[insert.index:]...)...)
+	}
+	return argVals
+}
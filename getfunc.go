@@ -8,16 +8,43 @@ import (
 type StringArgsFunc func(ctx context.Context, args ...string) error
 type StringMapArgsFunc func(ctx context.Context, args map[string]string) error
 type MapArgsFunc func(ctx context.Context, args map[string]interface{}) error
+
+// JSONArgsFunc calls a command function with the arguments
+// from a JSON array by position or from a JSON object.
+// Array elements are assigned like string arguments:
+// Arg.Default, Arg.Env, and Arg.Required are applied
+// for missing and null elements, Arg.Enum values are checked,
+// and JSON strings are parsed like command line arguments.
+// A JSON object is unmarshalled into the args struct with encoding/json
+// without applying Arg.Default, Arg.Env, Arg.Required, or Arg.Enum.
+// The constraints from the struct tags ArgMinTag, ArgMaxTag,
+// ArgMinLengthTag, ArgMaxLengthTag, and ArgPatternTag
+// are checked for passed arguments in both cases.
 type JSONArgsFunc func(ctx context.Context, args []byte) error
 
+// The ResultValuesFunc types call a command function and return
+// its results without the error result.
+// Channel and iterator function results are returned as *ResultStream
+// instead of the values returned by the command function.
+// Callers have to pass the results to DiscardResultStreams
+// when they are no longer needed to cancel the context
+// of the command function call.
+// JSONArgsResultValuesFunc assigns the arguments like JSONArgsFunc.
 type StringArgsResultValuesFunc func(ctx context.Context, args []string) ([]reflect.Value, error)
 type StringMapArgsResultValuesFunc func(ctx context.Context, args map[string]string) ([]reflect.Value, error)
 type MapArgsResultValuesFunc func(ctx context.Context, args map[string]interface{}) ([]reflect.Value, error)
 type JSONArgsResultValuesFunc func(ctx context.Context, args []byte) ([]reflect.Value, error)
 
+// stringArgsHandlersFunc calls a command function with string arguments
+// and passes the results to the resultsHandlers of the call.
+type stringArgsHandlersFunc func(ctx context.Context, args []string, resultsHandlers []ResultsHandler) error
+
 type argsImpl interface {
 	Init(outerStructPtr interface{}) error
 
+	stringArgsHandlersFunc(commandFunc interface{}) (stringArgsHandlersFunc, error)
+	argsStructFromStringMapArgs(outerStructPtr interface{}, callerArgs map[string]string) (interface{}, error)
+
 	StringArgsFunc(commandFunc interface{}, resultsHandlers []ResultsHandler) (StringArgsFunc, error)
 	StringMapArgsFunc(commandFunc interface{}, resultsHandlers []ResultsHandler) (StringMapArgsFunc, error)
 	MapArgsFunc(commandFunc interface{}, resultsHandlers []ResultsHandler) (MapArgsFunc, error)
@@ -42,6 +69,15 @@ func GetStringArgsFunc(commandFunc interface{}, argsStructPtr interface{}, resul
 	return argsImpl.StringArgsFunc(commandFunc, resultsHandlers)
 }
 
+func getStringArgsHandlersFunc(commandFunc interface{}, argsStructPtr interface{}) (stringArgsHandlersFunc, error) {
+	argsImpl := argsStructPtr.(argsImpl)
+	err := argsImpl.Init(argsStructPtr)
+	if err != nil {
+		return nil, err
+	}
+	return argsImpl.stringArgsHandlersFunc(commandFunc)
+}
+
 func MustGetStringArgsFunc(commandFunc interface{}, argsStructPtr interface{}, resultsHandlers ...ResultsHandler) StringArgsFunc {
 	f, err := GetStringArgsFunc(commandFunc, argsStructPtr, resultsHandlers...)
 	if err != nil {
@@ -196,3 +232,27 @@ func MustGetJSONArgsResultValuesFunc(commandFunc interface{}, argsStructPtr inte
 	}
 	return f
 }
+
+// GetStringArgsFuncWithResults works like GetStringArgsFunc
+// but also checks the results struct embedding ResultsDef against
+// the results of commandFunc and passes the result values
+// as NamedResultValues to resultsHandlers.
+func GetStringArgsFuncWithResults(commandFunc interface{}, argsStructPtr, resultsStructPtr interface{}, resultsHandlers ...ResultsHandler) (StringArgsFunc, error) {
+	results, err := GetResults(resultsStructPtr)
+	if err != nil {
+		return nil, err
+	}
+	err = checkResultsDef(results, commandFunc)
+	if err != nil {
+		return nil, err
+	}
+	return GetStringArgsFunc(commandFunc, argsStructPtr, WithNamedResults(results, resultsHandlers...))
+}
+
+func MustGetStringArgsFuncWithResults(commandFunc interface{}, argsStructPtr, resultsStructPtr interface{}, resultsHandlers ...ResultsHandler) StringArgsFunc {
+	f, err := GetStringArgsFuncWithResults(commandFunc, argsStructPtr, resultsStructPtr, resultsHandlers...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
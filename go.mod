@@ -8,13 +8,15 @@ require (
 	github.com/fatih/color v1.13.0
 	github.com/gorilla/mux v1.8.0
 	github.com/h2non/filetype v1.1.3
-	github.com/mattn/go-isatty v0.0.16 // indirect
+	github.com/mattn/go-isatty v0.0.16
 	github.com/muesli/termenv v0.13.0 // indirect
 	github.com/stretchr/testify v1.8.0
 	github.com/ungerik/go-fs v0.0.0-20220919212925-72a039a29894
 	github.com/ungerik/go-httpx v0.0.0-20220112162338-087d2c80ef46
 	github.com/ungerik/go-reflection v0.0.0-20220113085621-6c5fc1f2694a
 	golang.org/x/sys v0.0.0-20220926163933-8cfa568d3c25 // indirect
+	golang.org/x/term v0.0.0-20220722155259-a9ba230a4035
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -30,5 +32,4 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.2 // indirect
 	golang.org/x/exp v0.0.0-20220921164117-439092de6870 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
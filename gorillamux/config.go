@@ -1,7 +1,21 @@
 package gorillamux
 
+import "github.com/ungerik/go-command"
+
 var (
 	CatchPanics       = true
 	PrettyPrint       = true
 	PrettyPrintIndent = "  "
+
+	// ResultsQueryParam is the name of the URL query parameter
+	// with a command.Query expression that will be applied to the
+	// command results before they are passed to the ResultsWriter.
+	// Example: ?query=items[?status==failed].name
+	// An empty string disables result queries.
+	ResultsQueryParam = ""
+
+	// Middleware wraps the command function calls of all handlers.
+	// The name or else the path template of the matched route
+	// is passed as command.Invocation.Command.
+	Middleware []command.Middleware
 )
@@ -0,0 +1,254 @@
+package gorillamux
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ungerik/go-command"
+	"github.com/ungerik/go-httpx/httperr"
+)
+
+// ResultFormats are the ResultsWriter by media type
+// negotiated with the Accept header of requests
+// to commands mounted with MountDispatcher.
+// RespondJSON is used if no media type is acceptable.
+var ResultFormats = map[string]ResultsWriter{
+	"application/json":     RespondJSON,
+	"application/x-ndjson": RespondNDJSON,
+	"application/xml":      RespondXML,
+	"text/xml":             RespondXML,
+	"text/plain":           RespondPlaintext,
+}
+
+// MountedCommand describes a command in the index
+// of commands mounted with MountDispatcher
+type MountedCommand struct {
+	Command     string       `json:"command"`
+	Path        string       `json:"path"`
+	Aliases     []string     `json:"aliases,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Args        []MountedArg `json:"args"`
+	Results     []MountedArg `json:"results,omitempty"`
+}
+
+// MountedArg describes an argument or a named result
+// of a command mounted with MountDispatcher
+type MountedArg struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Example     string   `json:"example,omitempty"`
+	Secret      bool     `json:"secret,omitempty"`
+}
+
+// MountDispatcher registers handlers at router that call
+// the commands of disp with POST requests to
+// prefix/<super command>/<command> and list the
+// commands as JSON array of MountedCommand with
+// a GET request to prefix/
+//
+// Arguments are passed as JSON object by name,
+// as JSON array by position, as form values,
+// or as URL query parameters.
+// Values of the request body overwrite query parameters.
+// The results are written in one of the ResultFormats
+// negotiated with the Accept header of the request.
+//
+// Commands are looked up per request with command.LookupCommandByName
+// so that commands added to disp later are also available,
+// and called with command.CommandInfo.CallWithStringMapArgs.
+func MountDispatcher(router *mux.Router, prefix string, disp command.CommandLister, errHandlers ...httperr.Handler) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	router.Handle(prefix+"/", dispatcherIndexHandler(prefix, disp, errHandlers)).Methods(http.MethodGet)
+	router.PathPrefix(prefix + "/").Handler(dispatcherCommandHandler(prefix, disp, errHandlers)).Methods(http.MethodPost)
+}
+
+func dispatcherIndexHandler(prefix string, disp command.CommandLister, errHandlers []httperr.Handler) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		infos := disp.Commands()
+		index := make([]MountedCommand, len(infos))
+		for i, info := range infos {
+			index[i] = MountedCommand{
+				Command:     info.Command(),
+				Path:        prefix + "/" + strings.Join(info.Path, "/"),
+				Aliases:     info.Aliases,
+				Description: info.Description,
+				Args:        make([]MountedArg, 0, info.Args.NumArgs()),
+			}
+			for _, arg := range info.Args.Args() {
+				index[i].Args = append(index[i].Args, MountedArg{
+					Name:        arg.Name,
+					Type:        arg.Type.String(),
+					Description: arg.Description,
+					Default:     arg.Default,
+					Required:    arg.Required,
+					Enum:        arg.Enum,
+					Example:     arg.Example,
+					Secret:      arg.Secret,
+				})
+			}
+			if info.Results != nil {
+				for _, result := range info.Results.Results() {
+					index[i].Results = append(index[i].Results, MountedArg{
+						Name:        result.Name,
+						Type:        result.Type.String(),
+						Description: result.Description,
+					})
+				}
+			}
+		}
+		b, err := encodeJSON(index)
+		if err != nil {
+			handleErr(err, writer, request, errHandlers)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		writer.Write(b)
+	}
+}
+
+func dispatcherCommandHandler(prefix string, disp command.CommandLister, errHandlers []httperr.Handler) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if CatchPanics {
+			defer func() {
+				handleErr(httperr.AsError(recover()), writer, request, errHandlers)
+			}()
+		}
+
+		path := strings.Trim(strings.TrimPrefix(request.URL.Path, prefix), "/")
+		info := command.LookupCommandByName(disp, path, "/")
+		if info == nil {
+			err := command.CommandNotFoundError{Command: strings.ReplaceAll(path, "/", " ")}
+			handleErr(httperr.Errorf(http.StatusNotFound, "%s", err), writer, request, errHandlers)
+			return
+		}
+
+		vars, err := requestArgs(request, info.Args)
+		if err != nil {
+			handleErr(httperr.Errorf(http.StatusBadRequest, "%s", err), writer, request, errHandlers)
+			return
+		}
+
+		query, err := resultsQuery(request)
+		if err != nil {
+			handleErr(err, writer, request, errHandlers)
+			return
+		}
+
+		ctx := command.WithMiddleware(request.Context(), Middleware...)
+		resultVals, err := info.CallWithStringMapArgs(ctx, vars)
+		if command.IsUsageError(err) {
+			err = httperr.Errorf(http.StatusBadRequest, "%s", err)
+		}
+
+		resultsWriter := negotiateResultsWriter(request.Header.Get("Accept"))
+		if info.Results != nil {
+			resultsWriter = WithNamedResults(info.Results, resultsWriter)
+		}
+		err = writeResults(resultsWriter, query, info.Args, vars, resultVals, err, writer, request)
+		command.DiscardResultStreams(resultVals)
+		handleErr(err, writer, request, errHandlers)
+	}
+}
+
+// requestArgs returns the arguments passed with the request
+// as URL query parameters and as JSON object, JSON array,
+// or form values in the request body.
+func requestArgs(request *http.Request, args command.Args) (map[string]string, error) {
+	vars := make(map[string]string)
+	for name, values := range request.URL.Query() {
+		if name != ResultsQueryParam && len(values) > 0 && len(values[0]) > 0 {
+			vars[name] = strings.Join(values, ";")
+		}
+	}
+
+	contentType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	switch contentType {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		err := request.ParseMultipartForm(32 << 20)
+		if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return nil, err
+		}
+		for name, values := range request.PostForm {
+			if len(values) > 0 && len(values[0]) > 0 {
+				vars[name] = strings.Join(values, ";")
+			}
+		}
+		return vars, nil
+	}
+
+	defer request.Body.Close()
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSpace(body)
+	switch {
+	case len(body) == 0:
+		return vars, nil
+
+	case body[0] == '{':
+		err = jsonBodyFieldsAsVars(body, nil, vars)
+		if err != nil {
+			return nil, err
+		}
+		return vars, nil
+
+	case body[0] == '[':
+		var values []json.RawMessage
+		err = json.Unmarshal(body, &values)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) > args.NumArgs() {
+			return nil, fmt.Errorf("%d arguments passed but command has only %d", len(values), args.NumArgs())
+		}
+		for i, value := range values {
+			err = setJSONVar(vars, args.Args()[i].Name, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return vars, nil
+	}
+	return nil, fmt.Errorf("request body is not a JSON object or array")
+}
+
+// negotiateResultsWriter returns the ResultsWriter from ResultFormats
+// for the media type with the highest quality in the Accept header
+// or RespondJSON if none of the media types is supported.
+func negotiateResultsWriter(accept string) ResultsWriter {
+	var (
+		best  ResultsWriter = RespondJSON
+		bestQ float64
+	)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qParam, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(qParam, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if writer, ok := ResultFormats[mediaType]; ok && q > bestQ {
+			best, bestQ = writer, q
+		}
+	}
+	return best
+}
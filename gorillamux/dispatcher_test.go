@@ -0,0 +1,136 @@
+package gorillamux
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ungerik/go-command"
+)
+
+type greetingArgs struct {
+	command.ArgsDef
+
+	Greeting string `arg:"greeting" default:"Hello"`
+}
+
+type nameArgs struct {
+	command.ArgsDef
+
+	Name string `arg:"name" desc:"Name to greet" required:"true"`
+}
+
+func newTestRouter() *mux.Router {
+	tree := command.NewCommandTree()
+	tree.MustSetPersistentArgs(new(greetingArgs))
+	tree.MustAddCommand("hello", "Greets name", func(ctx context.Context, name string) string {
+		var args greetingArgs
+		if !command.PersistentArgsFromContext(ctx, &args) {
+			return "no persistent args"
+		}
+		return args.Greeting + " " + name
+	}, new(nameArgs))
+	math := tree.MustAddNode("math", "Calculates")
+	math.MustAddCommand("add", "Adds a and b", add, new(addArgs))
+	math.MustAddAlias("add", "plus")
+	math.MustAddCommand("swap", "Swaps a and b", func(a, b int) (int, int) { return b, a }, new(addArgs))
+
+	router := mux.NewRouter()
+	MountDispatcher(router, "/api", tree)
+	return router
+}
+
+func serve(router *mux.Router, request *http.Request) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	return response
+}
+
+func post(router *mux.Router, path, contentType, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	return serve(router, request)
+}
+
+func TestMountDispatcher(t *testing.T) {
+	router := newTestRouter()
+
+	response := post(router, "/api/math/add", "application/json", `{"a": 1, "b": "2"}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "3", response.Body.String(), "JSON object")
+	response = post(router, "/api/math/add", "application/json", `[2, 3]`)
+	assert.Equal(t, "5", response.Body.String(), "JSON array")
+	response = post(router, "/api/math/add", "application/x-www-form-urlencoded", `a=3&b=4`)
+	assert.Equal(t, "7", response.Body.String(), "form values")
+	response = post(router, "/api/math/add?a=4&b=5", "", ``)
+	assert.Equal(t, "9", response.Body.String(), "query params")
+	response = post(router, "/api/math/add?a=4&b=5", "application/json", `{"b": 1}`)
+	assert.Equal(t, "5", response.Body.String(), "body overwrites query params")
+	response = post(router, "/api/math/plus", "application/json", `{"a": 1, "b": 1}`)
+	assert.Equal(t, "2", response.Body.String(), "alias")
+
+	accept := func(accept string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/api/math/swap", strings.NewReader(`[1, 2]`))
+		request.Header.Set("Accept", accept)
+		return serve(router, request)
+	}
+	response = accept("text/plain;q=0.5, application/x-ndjson")
+	assert.Equal(t, "2\n1\n", response.Body.String(), "NDJSON")
+	response = accept("text/html, application/xml")
+	assert.Contains(t, response.Header().Get("Content-Type"), "application/xml")
+	response = accept("text/html")
+	assert.Equal(t, "21", response.Body.String(), "JSON if no format is acceptable")
+	request := httptest.NewRequest(http.MethodPost, "/api/hello", strings.NewReader(`{"name": "World"}`))
+	request.Header.Set("Accept", "application/json;q=x, text/plain;q=0.1")
+	response = serve(router, request)
+	assert.Equal(t, "Hello World", response.Body.String(), "plaintext with persistent arg default")
+	response = post(router, "/api/hello", "application/json", `{"name": "World", "greeting": "Hi"}`)
+	assert.Equal(t, `"Hi World"`, response.Body.String(), "persistent arg")
+
+	response = post(router, "/api/math/sub", "application/json", `{}`)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, "command 'math sub' not found\n", response.Body.String())
+	response = post(router, "/api/math/add", "application/json", `{"a": "x"}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	response = post(router, "/api/math/add", "application/json", `[1, 2, 3]`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, "3 arguments passed but command has only 2\n", response.Body.String())
+	response = post(router, "/api/hello", "application/json", `{}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code, "missing required arg")
+	response = post(router, "/api/math/add", "text/plain", `1 2`)
+	assert.Equal(t, http.StatusBadRequest, response.Code, "invalid body")
+
+	response = serve(router, httptest.NewRequest(http.MethodGet, "/api/", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+	var index []MountedCommand
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &index))
+	assert.Equal(t, []MountedCommand{
+		{
+			Command:     "hello",
+			Path:        "/api/hello",
+			Description: "Greets name",
+			Args:        []MountedArg{{Name: "name", Type: "string", Description: "Name to greet", Required: true}},
+		},
+		{
+			Command:     "math add",
+			Path:        "/api/math/add",
+			Aliases:     []string{"plus"},
+			Description: "Adds a and b",
+			Args:        []MountedArg{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}},
+		},
+		{
+			Command:     "math swap",
+			Path:        "/api/math/swap",
+			Description: "Swaps a and b",
+			Args:        []MountedArg{{Name: "a", Type: "int"}, {Name: "b", Type: "int"}},
+		},
+	}, index)
+}
@@ -1,10 +1,12 @@
 package gorillamux
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -25,11 +27,16 @@ func CommandHandler(commandFunc interface{}, args command.Args, resultsWriter Re
 
 		vars := mux.Vars(request)
 
-		resultVals, err := cmdFunc(request.Context(), vars)
-
-		if resultsWriter != nil {
-			err = resultsWriter.WriteResults(args, vars, resultVals, err, writer, request)
+		query, err := resultsQuery(request)
+		if err != nil {
+			handleErr(err, writer, request, errHandlers)
+			return
 		}
+
+		resultVals, err := cmdFunc(commandContext(request), vars)
+
+		err = writeResults(resultsWriter, query, args, vars, resultVals, err, writer, request)
+		command.DiscardResultStreams(resultVals)
 		handleErr(err, writer, request, errHandlers)
 	}
 }
@@ -54,11 +61,16 @@ func CommandHandlerWithQueryParams(commandFunc interface{}, args command.Args, r
 			}
 		}
 
-		resultVals, err := cmdFunc(request.Context(), vars)
-
-		if resultsWriter != nil {
-			err = resultsWriter.WriteResults(args, vars, resultVals, err, writer, request)
+		query, err := resultsQuery(request)
+		if err != nil {
+			handleErr(err, writer, request, errHandlers)
+			return
 		}
+
+		resultVals, err := cmdFunc(commandContext(request), vars)
+
+		err = writeResults(resultsWriter, query, args, vars, resultVals, err, writer, request)
+		command.DiscardResultStreams(resultVals)
 		handleErr(err, writer, request, errHandlers)
 	}
 }
@@ -107,15 +119,68 @@ func CommandHandlerRequestBodyArg(bodyConverter RequestBodyArgConverter, command
 		}
 		vars[name] = value
 
-		resultVals, err := cmdFunc(request.Context(), vars)
-
-		if resultsWriter != nil {
-			err = resultsWriter.WriteResults(args, vars, resultVals, err, writer, request)
+		query, err := resultsQuery(request)
+		if err != nil {
+			handleErr(err, writer, request, errHandlers)
+			return
 		}
+
+		resultVals, err := cmdFunc(commandContext(request), vars)
+
+		err = writeResults(resultsWriter, query, args, vars, resultVals, err, writer, request)
+		command.DiscardResultStreams(resultVals)
 		handleErr(err, writer, request, errHandlers)
 	}
 }
 
+// commandContext returns the context of the request
+// with the Middleware and the name or path template
+// of the matched route as command name.
+func commandContext(request *http.Request) context.Context {
+	ctx := request.Context()
+	if route := mux.CurrentRoute(request); route != nil {
+		name := route.GetName()
+		if name == "" {
+			name, _ = route.GetPathTemplate()
+		}
+		ctx = command.WithCommand(ctx, name, "")
+	}
+	return command.WithMiddleware(ctx, Middleware...)
+}
+
+// resultsQuery returns the parsed command.Query from the
+// ResultsQueryParam of the request or nil if not set.
+func resultsQuery(request *http.Request) (*command.Query, error) {
+	if ResultsQueryParam == "" {
+		return nil, nil
+	}
+	expr := request.URL.Query().Get(ResultsQueryParam)
+	if expr == "" {
+		return nil, nil
+	}
+	query, err := command.ParseQuery(expr)
+	if err != nil {
+		return nil, httperr.Errorf(http.StatusBadRequest, "%s", err)
+	}
+	return query, nil
+}
+
+// writeResults applies query to resultVals if not nil
+// and writes them with resultsWriter if not nil.
+func writeResults(resultsWriter ResultsWriter, query *command.Query, args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+	if resultsWriter == nil {
+		return resultErr
+	}
+	if query != nil && resultErr == nil {
+		var err error
+		resultVals, err = query.ApplyToResults(resultVals)
+		if err != nil {
+			return err
+		}
+	}
+	return resultsWriter.WriteResults(args, vars, resultVals, resultErr, writer, request)
+}
+
 func handleErr(err error, writer http.ResponseWriter, request *http.Request, errHandlers []httperr.Handler) {
 	if err == nil {
 		return
@@ -183,27 +248,37 @@ func jsonBodyFieldsAsVars(body []byte, mapping map[string]string, vars map[strin
 	}
 
 	for name, value := range fields {
-		if len(value) == 0 {
-			// should never happen with well formed JSON
-			return fmt.Errorf("JSON body field %q is empty", name)
-		}
-		valueStr := string(value)
-		switch {
-		case valueStr == "null":
-			// JSON nulls are left alone
-
-		case valueStr[0] == '"':
-			// Unescape JSON string
-			err = json.Unmarshal(value, &valueStr)
-			if err != nil {
-				return fmt.Errorf("can't unmarshal JSON body field %q as string because of: %w", name, err)
-			}
-			vars[name] = valueStr
+		err = setJSONVar(vars, name, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setJSONVar sets vars[name] to the JSON value
+// with strings unescaped and nulls left alone
+func setJSONVar(vars map[string]string, name string, value json.RawMessage) error {
+	if len(value) == 0 {
+		// should never happen with well formed JSON
+		return fmt.Errorf("JSON body field %q is empty", name)
+	}
+	valueStr := string(value)
+	switch {
+	case valueStr == "null":
+		// JSON nulls are left alone
 
-		default:
-			// All other JSON types are mapped directly to string
-			vars[name] = valueStr
+	case valueStr[0] == '"':
+		// Unescape JSON string
+		err := json.Unmarshal(value, &valueStr)
+		if err != nil {
+			return fmt.Errorf("can't unmarshal JSON body field %q as string because of: %w", name, err)
 		}
+		vars[name] = valueStr
+
+	default:
+		// All other JSON types are mapped directly to string
+		vars[name] = valueStr
 	}
 	return nil
 }
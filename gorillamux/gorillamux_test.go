@@ -0,0 +1,49 @@
+package gorillamux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ungerik/go-command"
+)
+
+type addArgs struct {
+	command.ArgsDef
+
+	A int `arg:"a"`
+	B int `arg:"b"`
+}
+
+func add(ctx context.Context, a, b int) int { return a + b }
+
+func TestMiddleware(t *testing.T) {
+	var trace []string
+	defer func(middleware []command.Middleware) { Middleware = middleware }(Middleware)
+	Middleware = []command.Middleware{func(next command.Invoker) command.Invoker {
+		return func(ctx context.Context, inv *command.Invocation) ([]reflect.Value, error) {
+			trace = append(trace, inv.Command)
+			inv.ArgVals[1] = reflect.ValueOf(100)
+			return next(ctx, inv)
+		}
+	}}
+
+	router := mux.NewRouter()
+	router.Handle("/add/{a}/{b}", CommandHandler(add, new(addArgs), RespondJSON)).Name("add")
+	router.Handle("/sum/{a}/{b}", CommandHandler(add, new(addArgs), RespondJSON))
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/add/1/2", nil))
+	assert.Equal(t, "101", response.Body.String())
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/sum/2/2", nil))
+	assert.Equal(t, "102", response.Body.String())
+
+	assert.Equal(t, []string{"add", "/sum/{a}/{b}"}, trace)
+}
@@ -0,0 +1,426 @@
+package gorillamux
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ungerik/go-command"
+	"github.com/ungerik/go-httpx/httperr"
+)
+
+// OpenAPIVersion is the version of the OpenAPI documents returned by API.OpenAPI
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIDocument is an OpenAPI document marshalled with encoding/json
+type OpenAPIDocument struct {
+	OpenAPI string                     `json:"openapi"`
+	Info    OpenAPIInfo                `json:"info"`
+	Paths   map[string]OpenAPIPathItem `json:"paths"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIPathItem holds the operations of a path
+// by lower case HTTP method
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name        string          `json:"name"`
+	In          string          `json:"in"`
+	Description string          `json:"description,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Schema      *command.Schema `json:"schema,omitempty"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *command.Schema `json:"schema,omitempty"`
+}
+
+// API registers command handlers at Router
+// and generates an OpenAPI document for them.
+//
+// Path parameters are documented for the arguments
+// named like the variables of the route path template.
+type API struct {
+	Router      *mux.Router
+	Title       string
+	Version     string
+	Description string
+
+	mutex      sync.Mutex
+	operations []*apiOperation
+}
+
+type apiArgsSource int
+
+const (
+	argsFromPath apiArgsSource = iota
+	argsFromQueryParams
+	argsFromRequestBody
+	argsFromJSONBodyFields
+)
+
+type apiOperation struct {
+	route         *mux.Route
+	method        string
+	description   string
+	commandFunc   interface{}
+	args          command.Args
+	resultsWriter ResultsWriter
+	argsSource    apiArgsSource
+	bodyArg       string
+}
+
+// NewAPI returns an API registering its handlers at router
+func NewAPI(router *mux.Router, title, version string) *API {
+	return &API{Router: router, Title: title, Version: version}
+}
+
+// Command registers a CommandHandler for method and path
+// and returns its route.
+func (api *API) Command(method, path, description string, commandFunc interface{}, args command.Args, resultsWriter ResultsWriter, errHandlers ...httperr.Handler) *mux.Route {
+	handler := CommandHandler(commandFunc, args, resultsWriter, errHandlers...)
+	op := &apiOperation{
+		method:        method,
+		description:   description,
+		commandFunc:   commandFunc,
+		args:          args,
+		resultsWriter: resultsWriter,
+		argsSource:    argsFromPath,
+	}
+	return api.handleAt(op, path, handler)
+}
+
+// CommandWithQueryParams registers a CommandHandlerWithQueryParams
+// for method and path and returns its route.
+// The arguments that are not path variables are documented as query parameters.
+func (api *API) CommandWithQueryParams(method, path, description string, commandFunc interface{}, args command.Args, resultsWriter ResultsWriter, errHandlers ...httperr.Handler) *mux.Route {
+	handler := CommandHandlerWithQueryParams(commandFunc, args, resultsWriter, errHandlers...)
+	op := &apiOperation{
+		method:        method,
+		description:   description,
+		commandFunc:   commandFunc,
+		args:          args,
+		resultsWriter: resultsWriter,
+		argsSource:    argsFromQueryParams,
+	}
+	return api.handleAt(op, path, handler)
+}
+
+// CommandRequestBodyArg registers a CommandHandlerRequestBodyArg
+// using RequestBodyAsArg(bodyArg) for method and path
+// and returns its route.
+func (api *API) CommandRequestBodyArg(method, path, description, bodyArg string, commandFunc interface{}, args command.Args, resultsWriter ResultsWriter, errHandlers ...httperr.Handler) *mux.Route {
+	handler := CommandHandlerRequestBodyArg(RequestBodyAsArg(bodyArg), commandFunc, args, resultsWriter, errHandlers...)
+	op := &apiOperation{
+		method:        method,
+		description:   description,
+		commandFunc:   commandFunc,
+		args:          args,
+		resultsWriter: resultsWriter,
+		argsSource:    argsFromRequestBody,
+		bodyArg:       bodyArg,
+	}
+	return api.handleAt(op, path, handler)
+}
+
+// CommandJSONBodyFields registers a CommandHandler wrapped
+// with JSONBodyFieldsAsVars for method and path and returns its route.
+// The arguments that are not path variables are documented
+// as properties of a JSON object request body.
+func (api *API) CommandJSONBodyFields(method, path, description string, commandFunc interface{}, args command.Args, resultsWriter ResultsWriter, errHandlers ...httperr.Handler) *mux.Route {
+	handler := JSONBodyFieldsAsVars(CommandHandler(commandFunc, args, resultsWriter, errHandlers...))
+	op := &apiOperation{
+		method:        method,
+		description:   description,
+		commandFunc:   commandFunc,
+		args:          args,
+		resultsWriter: resultsWriter,
+		argsSource:    argsFromJSONBodyFields,
+	}
+	return api.handleAt(op, path, handler)
+}
+
+func (api *API) handleAt(op *apiOperation, path string, handler http.Handler) *mux.Route {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	op.route = api.Router.Handle(path, handler).Methods(op.method)
+	api.operations = append(api.operations, op)
+	return op.route
+}
+
+// ServeOpenAPI registers the API as GET handler
+// for the OpenAPI document at path and returns its route.
+func (api *API) ServeOpenAPI(path string) *mux.Route {
+	return api.Router.Handle(path, api).Methods(http.MethodGet)
+}
+
+// ServeHTTP implements http.Handler by responding
+// with the OpenAPI document as JSON.
+func (api *API) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	b, err := encodeJSON(api.OpenAPI())
+	if err != nil {
+		httperr.Handle(err, writer, request)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.Write(b)
+}
+
+// OpenAPI returns the OpenAPI document
+// for the registered command handlers.
+func (api *API) OpenAPI() *OpenAPIDocument {
+	api.mutex.Lock()
+	defer api.mutex.Unlock()
+
+	doc := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info: OpenAPIInfo{
+			Title:       api.Title,
+			Version:     api.Version,
+			Description: api.Description,
+		},
+		Paths: make(map[string]OpenAPIPathItem),
+	}
+	for _, op := range api.operations {
+		template, err := op.route.GetPathTemplate()
+		if err != nil {
+			continue
+		}
+		path, pathVars := parsePathTemplate(template)
+		item := doc.Paths[path]
+		if item == nil {
+			item = make(OpenAPIPathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(op.method)] = op.operation(pathVars)
+	}
+	return doc
+}
+
+func (op *apiOperation) operation(pathVars map[string]string) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		OperationID: op.route.GetName(),
+		Summary:     op.description,
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+
+	var bodySchema *command.Schema
+	for i, arg := range op.args.Args() {
+		schema := stringArgSchema(op.args, i)
+		required := arg.Required && arg.Default == "" && arg.Env == ""
+		if pattern, isPathVar := pathVars[arg.Name]; isPathVar {
+			if pattern != "" && schema.Pattern == "" {
+				schema.Pattern = "^" + pattern + "$"
+			}
+			operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+				Name:        arg.Name,
+				In:          "path",
+				Description: arg.Description,
+				Required:    true,
+				Schema:      schema,
+			})
+			continue
+		}
+		switch op.argsSource {
+		case argsFromQueryParams:
+			operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+				Name:        arg.Name,
+				In:          "query",
+				Description: arg.Description,
+				Required:    required,
+				Schema:      schema,
+			})
+
+		case argsFromRequestBody:
+			if arg.Name != op.bodyArg {
+				continue
+			}
+			contentType := "application/json"
+			switch {
+			case arg.Type.Kind() == reflect.String:
+				contentType = "text/plain"
+			case arg.Type.Kind() == reflect.Slice && arg.Type.Elem().Kind() == reflect.Uint8:
+				contentType = "application/octet-stream"
+				schema = &command.Schema{Type: "string", Format: "binary", Description: arg.Description}
+			default:
+				schema = command.ArgJSONSchema(op.args, i)
+			}
+			operation.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]*OpenAPIMediaType{contentType: {Schema: schema}},
+			}
+
+		case argsFromJSONBodyFields:
+			if bodySchema == nil {
+				bodySchema = &command.Schema{Type: "object", Properties: make(map[string]*command.Schema)}
+				operation.RequestBody = &OpenAPIRequestBody{
+					Required: true,
+					Content:  map[string]*OpenAPIMediaType{"application/json": {Schema: bodySchema}},
+				}
+			}
+			bodySchema.Properties[arg.Name] = schema
+			if required {
+				bodySchema.Required = append(bodySchema.Required, arg.Name)
+			}
+		}
+	}
+
+	// Document path variables that are not arguments
+	// because OpenAPI requires all path parameters
+	var undocumented []string
+	for name := range pathVars {
+		if !argNameExists(op.args, name) {
+			undocumented = append(undocumented, name)
+		}
+	}
+	sort.Strings(undocumented)
+	for _, name := range undocumented {
+		schema := &command.Schema{Type: "string"}
+		if pattern := pathVars[name]; pattern != "" {
+			schema.Pattern = "^" + pattern + "$"
+		}
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+	}
+
+	contentType, schema := responseContent(op.resultsWriter, commandResultTypes(op.commandFunc), nil)
+	response := &OpenAPIResponse{Description: "OK"}
+	if contentType != "" {
+		response.Content = map[string]*OpenAPIMediaType{contentType: {Schema: schema}}
+	}
+	operation.Responses["200"] = response
+	operation.Responses["default"] = &OpenAPIResponse{Description: "Error"}
+	return operation
+}
+
+func argNameExists(args command.Args, name string) bool {
+	for _, arg := range args.Args() {
+		if arg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// stringArgSchema returns the JSON schema of an argument
+// that is passed as string and parsed by the command package.
+func stringArgSchema(args command.Args, index int) *command.Schema {
+	schema := command.ArgJSONSchema(args, index)
+	if args.Args()[index].Type == reflect.TypeOf(time.Duration(0)) {
+		// Durations are parsed with time.ParseDuration
+		schema.Type = "string"
+		schema.Comment = ""
+		schema.Default = args.Args()[index].Default
+	}
+	return schema
+}
+
+// parsePathTemplate returns the template of a mux route
+// as OpenAPI path and its variables with their regular expression.
+func parsePathTemplate(template string) (path string, vars map[string]string) {
+	vars = make(map[string]string)
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start == -1 {
+			b.WriteString(template)
+			break
+		}
+		// Find the matching closing brace
+		// because regular expressions can contain braces
+		depth, end := 0, -1
+		for i := start; i < len(template) && end == -1; i++ {
+			switch template[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end == -1 {
+			b.WriteString(template)
+			break
+		}
+		name, pattern, _ := strings.Cut(template[start+1:end], ":")
+		vars[name] = pattern
+		b.WriteString(template[:start])
+		b.WriteString("{" + name + "}")
+		template = template[end+1:]
+	}
+	return b.String(), vars
+}
+
+// commandResultTypes returns the result types
+// of commandFunc without the error result
+func commandResultTypes(commandFunc interface{}) []reflect.Type {
+	funcType := reflect.TypeOf(commandFunc)
+	resultTypes := make([]reflect.Type, 0, funcType.NumOut())
+	for i := 0; i < funcType.NumOut(); i++ {
+		if t := funcType.Out(i); t != reflect.TypeOf((*error)(nil)).Elem() {
+			resultTypes = append(resultTypes, t)
+		}
+	}
+	return resultTypes
+}
+
+// resultsSchema returns the JSON schema of the results
+// to be embedded in an OpenAPI document
+func resultsSchema(resultTypes []reflect.Type, results command.Results) *command.Schema {
+	schema := command.ResultsJSONSchema(resultTypes, results)
+	schema.Schema = ""
+	return schema
+}
+
+func binaryResponseContent(contentType string) func([]reflect.Type, command.Results) (string, *command.Schema) {
+	return func([]reflect.Type, command.Results) (string, *command.Schema) {
+		return contentType, &command.Schema{Type: "string", Format: "binary"}
+	}
+}
+
+// responseContent returns the content type and schema of the response
+// written by resultsWriter for the result types and named results
+// if resultsWriter implements ResponseDescriber like the
+// ResultsWriter of this package.
+func responseContent(resultsWriter ResultsWriter, resultTypes []reflect.Type, results command.Results) (contentType string, schema *command.Schema) {
+	if describer, ok := resultsWriter.(ResponseDescriber); ok {
+		return describer.ResponseContent(resultTypes, results)
+	}
+	return "*/*", &command.Schema{}
+}
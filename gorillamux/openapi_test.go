@@ -0,0 +1,184 @@
+package gorillamux
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ungerik/go-command"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// assertGolden compares actual with the file testdata/name
+// or writes it to the file with the -update flag
+func assertGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func Test_parsePathTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		vars     map[string]string
+	}{
+		{template: "/users", path: "/users", vars: map[string]string{}},
+		{template: "/users/{id}", path: "/users/{id}", vars: map[string]string{"id": ""}},
+		{template: "/users/{id:[0-9]+}/posts/{slug}", path: "/users/{id}/posts/{slug}", vars: map[string]string{"id": "[0-9]+", "slug": ""}},
+		{template: "/years/{year:[0-9]{4}}/{day:[0-9]{1,2}}.json", path: "/years/{year}/{day}.json", vars: map[string]string{"year": "[0-9]{4}", "day": "[0-9]{1,2}"}},
+		{template: "/broken/{id", path: "/broken/{id", vars: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			path, vars := parsePathTemplate(tt.template)
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.vars, vars)
+		})
+	}
+}
+
+type quotientResults struct {
+	command.ResultsDef
+
+	Quotient  int `result:"quotient"`
+	Remainder int `result:"remainder"`
+}
+
+func Test_responseContent(t *testing.T) {
+	intTypes := []reflect.Type{reflect.TypeOf(0), reflect.TypeOf(0)}
+	tests := []struct {
+		name          string
+		resultsWriter ResultsWriter
+		resultTypes   []reflect.Type
+		contentType   string
+		schema        *command.Schema
+	}{
+		{name: "JSON", resultsWriter: RespondJSON, resultTypes: intTypes[:1], contentType: "application/json", schema: &command.Schema{Type: "integer"}},
+		{name: "NDJSON", resultsWriter: RespondNDJSON, resultTypes: intTypes[:1], contentType: "application/x-ndjson", schema: &command.Schema{Type: "integer"}},
+		{name: "NDJSON stream", resultsWriter: RespondNDJSON, resultTypes: []reflect.Type{reflect.TypeOf((*command.ResultStream)(nil))}, contentType: "application/x-ndjson", schema: &command.Schema{}},
+		{name: "XML", resultsWriter: RespondXML, resultTypes: intTypes[:1], contentType: "application/xml", schema: &command.Schema{Type: "integer"}},
+		{name: "Plaintext", resultsWriter: RespondPlaintext, resultTypes: intTypes[:1], contentType: "text/plain", schema: &command.Schema{Type: "string"}},
+		{name: "HTML", resultsWriter: RespondHTML, contentType: "text/html", schema: &command.Schema{Type: "string"}},
+		{name: "DetectContentType", resultsWriter: RespondDetectContentType, contentType: "application/octet-stream", schema: &command.Schema{Type: "string", Format: "binary"}},
+		{name: "Nothing", resultsWriter: RespondNothing, resultTypes: intTypes[:1], contentType: "", schema: nil},
+		{name: "Binary", resultsWriter: RespondBinary("image/png"), contentType: "image/png", schema: &command.Schema{Type: "string", Format: "binary"}},
+		{name: "ContentType", resultsWriter: RespondContentType("application/pdf"), contentType: "application/pdf", schema: &command.Schema{Type: "string", Format: "binary"}},
+		{
+			name:          "JSONField",
+			resultsWriter: RespondJSONField("sum"),
+			resultTypes:   intTypes[:1],
+			contentType:   "application/json",
+			schema:        &command.Schema{Type: "object", Properties: map[string]*command.Schema{"sum": {Type: "integer"}}},
+		},
+		{
+			name:          "NamedResults",
+			resultsWriter: WithNamedResults(new(quotientResults), RespondJSON),
+			resultTypes:   intTypes,
+			contentType:   "application/json",
+			schema: &command.Schema{
+				Type:       "object",
+				Properties: map[string]*command.Schema{"quotient": {Type: "integer"}, "remainder": {Type: "integer"}},
+				Required:   []string{"quotient", "remainder"},
+			},
+		},
+		{
+			name: "undescribed",
+			resultsWriter: ResultsWriterFunc(func(command.Args, map[string]string, []reflect.Value, error, http.ResponseWriter, *http.Request) error {
+				return nil
+			}),
+			contentType: "*/*",
+			schema:      &command.Schema{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, schema := responseContent(tt.resultsWriter, tt.resultTypes, nil)
+			assert.Equal(t, tt.contentType, contentType)
+			assert.Equal(t, tt.schema, schema)
+		})
+	}
+}
+
+type userArgs struct {
+	command.ArgsDef
+
+	ID     int           `arg:"id" desc:"User ID"`
+	Name   string        `arg:"name" desc:"Name of the user" required:"true"`
+	Limit  int           `arg:"limit" default:"10"`
+	Period time.Duration `arg:"period" default:"1h"`
+}
+
+type profileArgs struct {
+	command.ArgsDef
+
+	ID      int                    `arg:"id"`
+	Profile struct{ Email string } `arg:"profile" desc:"Profile of the user"`
+}
+
+type avatarArgs struct {
+	command.ArgsDef
+
+	ID    int    `arg:"id"`
+	Image []byte `arg:"image"`
+}
+
+func newTestAPI() *API {
+	api := NewAPI(mux.NewRouter(), "Users", "1.0.0")
+	api.Description = "Manages users"
+	api.Command(http.MethodGet, "/users/{id:[0-9]{1,6}}/name/{name}", "Returns the user",
+		func(ctx context.Context, id int, name string, limit int, period time.Duration) string { return name },
+		new(userArgs), RespondPlaintext,
+	).Name("getUser")
+	api.CommandWithQueryParams(http.MethodGet, "/users/{id}/posts/{page}", "Lists the posts of a user",
+		func(ctx context.Context, id int, name string, limit int, period time.Duration) ([]string, error) {
+			return nil, nil
+		},
+		new(userArgs), RespondJSON,
+	)
+	api.CommandRequestBodyArg(http.MethodPut, "/users/{id}/profile", "Sets the profile", "profile",
+		func(ctx context.Context, id int, profile struct{ Email string }) error { return nil },
+		new(profileArgs), RespondNothing,
+	)
+	api.CommandRequestBodyArg(http.MethodPut, "/users/{id}/avatar", "Sets the avatar", "image",
+		func(ctx context.Context, id int, image []byte) ([]byte, error) { return image, nil },
+		new(avatarArgs), RespondDetectContentType,
+	)
+	api.CommandJSONBodyFields(http.MethodPost, "/users/{id}", "Updates the user",
+		func(ctx context.Context, id int, name string, limit int, period time.Duration) (int, int) {
+			return id, limit
+		},
+		new(userArgs), WithNamedResults(new(quotientResults), RespondJSON),
+	)
+	api.ServeOpenAPI("/openapi.json")
+	return api
+}
+
+func TestAPI_ServeOpenAPI(t *testing.T) {
+	api := newTestAPI()
+
+	response := httptest.NewRecorder()
+	api.Router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json; charset=utf-8", response.Header().Get("Content-Type"))
+	assertGolden(t, "openapi.json", response.Body.Bytes())
+}
@@ -25,6 +25,50 @@ func (f ResultsWriterFunc) WriteResults(args command.Args, vars map[string]strin
 	return f(args, vars, resultVals, resultErr, writer, request)
 }
 
+// ResponseDescriber can be implemented by a ResultsWriter
+// to describe its response in OpenAPI documents.
+type ResponseDescriber interface {
+	// ResponseContent returns the content type and schema of the
+	// response body for the result types of a command function
+	// without the error result and the named results if not nil.
+	// An empty contentType means that no body is written.
+	ResponseContent(resultTypes []reflect.Type, results command.Results) (contentType string, schema *command.Schema)
+}
+
+// describedResultsWriter implements ResponseDescriber
+// for the ResultsWriter of this package.
+type describedResultsWriter struct {
+	ResultsWriterFunc
+	responseContent func(resultTypes []reflect.Type, results command.Results) (string, *command.Schema)
+}
+
+func (w describedResultsWriter) ResponseContent(resultTypes []reflect.Type, results command.Results) (contentType string, schema *command.Schema) {
+	return w.responseContent(resultTypes, results)
+}
+
+// WithNamedResults returns a ResultsWriter that passes the result values
+// as a single command.NamedResultValues value to resultsWriter,
+// so that for example RespondJSON writes a JSON object
+// with the result names as keys.
+// A results struct embedding command.ResultsDef is initialized
+// with command.GetResults, the function panics if that fails.
+func WithNamedResults(results command.Results, resultsWriter ResultsWriter) ResultsWriter {
+	results = command.MustGetResults(results)
+	writeResults := func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+		if len(resultVals) == results.NumResults() {
+			named := command.NamedResultValues{Results: results, Values: resultVals}
+			resultVals = []reflect.Value{reflect.ValueOf(named)}
+		}
+		return resultsWriter.WriteResults(args, vars, resultVals, resultErr, writer, request)
+	}
+	return describedResultsWriter{
+		ResultsWriterFunc: writeResults,
+		responseContent: func(resultTypes []reflect.Type, _ command.Results) (string, *command.Schema) {
+			return responseContent(resultsWriter, resultTypes, results)
+		},
+	}
+}
+
 func encodeJSON(response interface{}) ([]byte, error) {
 	if PrettyPrint {
 		return json.MarshalIndent(response, "", PrettyPrintIndent)
@@ -32,7 +76,14 @@ func encodeJSON(response interface{}) ([]byte, error) {
 	return json.Marshal(response)
 }
 
-var RespondJSON ResultsWriterFunc = func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+var RespondJSON ResultsWriter = describedResultsWriter{
+	ResultsWriterFunc: respondJSON,
+	responseContent: func(resultTypes []reflect.Type, results command.Results) (string, *command.Schema) {
+		return "application/json", resultsSchema(resultTypes, results)
+	},
+}
+
+func respondJSON(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
 	if resultErr != nil {
 		return resultErr
 	}
@@ -49,9 +100,58 @@ var RespondJSON ResultsWriterFunc = func(args command.Args, vars map[string]stri
 	return err
 }
 
+// RespondNDJSON responds with newline delimited JSON
+// using one line per result value.
+// The elements of command.ResultStream results are written
+// and flushed line by line as soon as they are received,
+// so long lists are streamed as chunked response.
+var RespondNDJSON ResultsWriter = describedResultsWriter{
+	ResultsWriterFunc: respondNDJSON,
+	responseContent: func(resultTypes []reflect.Type, results command.Results) (string, *command.Schema) {
+		if len(resultTypes) == 1 && resultTypes[0] == reflect.TypeOf((*command.ResultStream)(nil)) {
+			// The element type of a stream is unknown
+			return "application/x-ndjson", &command.Schema{}
+		}
+		return "application/x-ndjson", resultsSchema(resultTypes, results)
+	},
+}
+
+func respondNDJSON(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+	if resultErr != nil {
+		return resultErr
+	}
+	writer.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	flusher, _ := writer.(http.Flusher)
+	encoder := json.NewEncoder(writer)
+	encode := func(val reflect.Value) error {
+		err := encoder.Encode(val.Interface())
+		if err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	for _, resultVal := range resultVals {
+		if stream, ok := resultVal.Interface().(*command.ResultStream); ok {
+			err := stream.Range(encode)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err := encode(resultVal)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // RespondBinary responds with contentType using the binary data from results of type []byte, string, or io.Reader.
-func RespondBinary(contentType string) ResultsWriterFunc {
-	return func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) (err error) {
+func RespondBinary(contentType string) ResultsWriter {
+	writeResults := func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) (err error) {
 		if resultErr != nil {
 			return resultErr
 		}
@@ -75,10 +175,14 @@ func RespondBinary(contentType string) ResultsWriterFunc {
 		_, err = writer.Write(buf.Bytes())
 		return err
 	}
+	return describedResultsWriter{
+		ResultsWriterFunc: writeResults,
+		responseContent:   binaryResponseContent(contentType),
+	}
 }
 
-func RespondJSONField(fieldName string) ResultsWriterFunc {
-	return func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) (err error) {
+func RespondJSONField(fieldName string) ResultsWriter {
+	writeResults := func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) (err error) {
 		if resultErr != nil {
 			return resultErr
 		}
@@ -95,6 +199,16 @@ func RespondJSONField(fieldName string) ResultsWriterFunc {
 		_, err = writer.Write(buf)
 		return err
 	}
+	return describedResultsWriter{
+		ResultsWriterFunc: writeResults,
+		responseContent: func(resultTypes []reflect.Type, results command.Results) (string, *command.Schema) {
+			schema := &command.Schema{Type: "object", Properties: make(map[string]*command.Schema)}
+			if len(resultTypes) > 0 {
+				schema.Properties[fieldName] = resultsSchema(resultTypes[:1], nil)
+			}
+			return "application/json", schema
+		},
+	}
 }
 
 func encodeXML(response interface{}) ([]byte, error) {
@@ -104,7 +218,14 @@ func encodeXML(response interface{}) ([]byte, error) {
 	return xml.Marshal(response)
 }
 
-var RespondXML ResultsWriterFunc = func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+var RespondXML ResultsWriter = describedResultsWriter{
+	ResultsWriterFunc: respondXML,
+	responseContent: func(resultTypes []reflect.Type, results command.Results) (string, *command.Schema) {
+		return "application/xml", resultsSchema(resultTypes, results)
+	},
+}
+
+func respondXML(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
 	if resultErr != nil {
 		return resultErr
 	}
@@ -121,7 +242,14 @@ var RespondXML ResultsWriterFunc = func(args command.Args, vars map[string]strin
 	return err
 }
 
-var RespondPlaintext ResultsWriterFunc = func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+var RespondPlaintext ResultsWriter = describedResultsWriter{
+	ResultsWriterFunc: respondPlaintext,
+	responseContent: func([]reflect.Type, command.Results) (string, *command.Schema) {
+		return "text/plain", &command.Schema{Type: "string"}
+	},
+}
+
+func respondPlaintext(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
 	if resultErr != nil {
 		return resultErr
 	}
@@ -139,7 +267,14 @@ var RespondPlaintext ResultsWriterFunc = func(args command.Args, vars map[string
 	return err
 }
 
-var RespondHTML ResultsWriterFunc = func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+var RespondHTML ResultsWriter = describedResultsWriter{
+	ResultsWriterFunc: respondHTML,
+	responseContent: func([]reflect.Type, command.Results) (string, *command.Schema) {
+		return "text/html", &command.Schema{Type: "string"}
+	},
+}
+
+func respondHTML(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
 	if resultErr != nil {
 		return resultErr
 	}
@@ -157,7 +292,12 @@ var RespondHTML ResultsWriterFunc = func(args command.Args, vars map[string]stri
 	return err
 }
 
-var RespondDetectContentType ResultsWriterFunc = func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+var RespondDetectContentType ResultsWriter = describedResultsWriter{
+	ResultsWriterFunc: respondDetectContentType,
+	responseContent:   binaryResponseContent("application/octet-stream"),
+}
+
+func respondDetectContentType(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
 	if resultErr != nil {
 		return resultErr
 	}
@@ -175,7 +315,7 @@ var RespondDetectContentType ResultsWriterFunc = func(args command.Args, vars ma
 }
 
 func RespondContentType(contentType string) ResultsWriter {
-	return ResultsWriterFunc(func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+	writeResults := func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
 		if resultErr != nil {
 			return resultErr
 		}
@@ -190,10 +330,21 @@ func RespondContentType(contentType string) ResultsWriter {
 		writer.Header().Add("Content-Type", contentType)
 		_, err := writer.Write(data)
 		return err
-	})
+	}
+	return describedResultsWriter{
+		ResultsWriterFunc: writeResults,
+		responseContent:   binaryResponseContent(contentType),
+	}
+}
+
+var RespondNothing ResultsWriter = describedResultsWriter{
+	ResultsWriterFunc: respondNothing,
+	responseContent: func([]reflect.Type, command.Results) (string, *command.Schema) {
+		return "", nil
+	},
 }
 
-var RespondNothing ResultsWriterFunc = func(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
+func respondNothing(args command.Args, vars map[string]string, resultVals []reflect.Value, resultErr error, writer http.ResponseWriter, request *http.Request) error {
 	return resultErr
 }
 
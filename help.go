@@ -0,0 +1,205 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// HelpCommand is the command name that prints
+// help pages if help is enabled for a dispatcher.
+// Example: help <command>
+const HelpCommand = "help"
+
+// HelpTemplateFuncs are the functions available
+// in CommandsHelpTemplate and CommandHelpTemplate.
+var HelpTemplateFuncs = template.FuncMap{
+	"usage":       func(s string) string { return CommandUsageColor.Sprint(s) },
+	"description": func(s string) string { return CommandDescriptionColor.Sprint(s) },
+	"join":        strings.Join,
+}
+
+var (
+	// CommandsHelpTemplate renders the usage of multiple commands
+	// with CommandsHelp as data.
+	CommandsHelpTemplate = template.Must(template.New("commands").Funcs(HelpTemplateFuncs).Parse(
+		`{{range .Commands}}  {{usage .Usage}}
+{{with .Description}}      {{description .}}
+{{end}}{{with .Aliases}}      {{description (printf "Aliases: %s" (join . ", "))}}
+{{end}}{{if .HasArgDescriptions}}{{range .Args}}          {{description (printf "<%s:%s> %s" .Name .Type .Description)}}
+{{end}}{{end}}{{with .Results}}      {{description "Results:"}}
+{{range .}}          {{description (printf "<%s:%s> %s" .Name .Type .Description)}}
+{{end}}{{end}}
+{{end}}`))
+
+	// CommandHelpTemplate renders the detailed help page
+	// of a single command with CommandHelp as data.
+	CommandHelpTemplate = template.Must(template.New("command").Funcs(HelpTemplateFuncs).Parse(
+		`Usage:
+  {{usage .Usage}}
+{{with .Aliases}}
+Aliases:
+  {{join . ", "}}
+{{end}}{{with .Description}}
+{{.}}
+{{end}}{{with .Args}}
+Arguments:
+{{range .}}  <{{.Name}}:{{.Type}}>{{with .Description}}  {{.}}{{end}}
+{{if .Required}}      required
+{{end}}{{with .Default}}      default: {{.}}
+{{end}}{{with .Env}}      env: {{.}}
+{{end}}{{with .Enum}}      one of: {{join . ", "}}
+{{end}}{{with .Example}}      example: {{.}}
+{{end}}{{end}}{{end}}{{with .PersistentArgs}}
+Persistent arguments:
+{{range .}}  --{{.Name}}=<{{.Type}}>{{with .Description}}  {{.}}{{end}}
+{{end}}{{end}}{{with .Results}}
+Results:
+{{range .}}  <{{.Name}}:{{.Type}}>{{with .Description}}  {{.}}{{end}}
+{{end}}{{end}}`))
+)
+
+// CommandHelp is the data for rendering the help of a command
+type CommandHelp struct {
+	AppName string
+	// Command including the super command if there is one
+	Command     string
+	Usage       string
+	Aliases     []string
+	Description string
+	Args        []Arg
+	// PersistentArgs are passed as --name=value flags
+	// and inherited from the nodes of a CommandTree
+	PersistentArgs []Arg
+	Results        []Result
+}
+
+// HasArgDescriptions returns if any argument has a description
+func (h *CommandHelp) HasArgDescriptions() bool {
+	for _, arg := range h.Args {
+		if arg.Description != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandsHelp is the data for rendering the usage of multiple commands
+type CommandsHelp struct {
+	AppName  string
+	Commands []*CommandHelp
+}
+
+func (cmd *stringArgsCommand) help(appName, command string) *CommandHelp {
+	var usage []string
+	for _, part := range []string{appName, command, cmd.args.String()} {
+		if part != "" {
+			usage = append(usage, part)
+		}
+	}
+	h := &CommandHelp{
+		AppName:     appName,
+		Command:     command,
+		Usage:       strings.Join(usage, " "),
+		Aliases:     cmd.aliases,
+		Description: cmd.description,
+		Args:        cmd.args.Args(),
+	}
+	if cmd.results != nil {
+		h.Results = cmd.results.Results()
+	}
+	return h
+}
+
+// helpFlag handles the HelpCommand and the -h and --help
+// flags if enabled for a dispatcher.
+type helpFlag struct {
+	enabled bool
+	appName string
+	writer  io.Writer
+}
+
+func (flag *helpFlag) enable(appName string, writer io.Writer) {
+	if appName == "" && len(os.Args) > 0 {
+		appName = filepath.Base(os.Args[0])
+	}
+	if writer == nil {
+		writer = os.Stdout
+	}
+	flag.enabled = true
+	flag.appName = appName
+	flag.writer = writer
+}
+
+// isHelpArg returns if arg is -h or --help
+func isHelpArg(arg string) bool {
+	return arg == "-h" || arg == "--help"
+}
+
+// hasHelpArg returns if args contain -h or --help.
+// If the command declares arguments, then only args
+// before a "--" terminator are checked so that
+// -h and --help can be passed as argument values.
+func hasHelpArg(args []string, declaresArgs bool) bool {
+	for _, arg := range args {
+		if arg == argsTerminator && declaresArgs {
+			return false
+		}
+		if isHelpArg(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// declaresArgs returns if the command has any arguments
+func (cmd *stringArgsCommand) declaresArgs() bool {
+	return cmd.args != nil && cmd.args.NumArgs() > 0
+}
+
+func writeCommandsHelp(writer io.Writer, help *CommandsHelp) error {
+	return helpTemplateFor(CommandsHelpTemplate, writer).Execute(writer, help)
+}
+
+func writeCommandHelp(writer io.Writer, help *CommandHelp) error {
+	return helpTemplateFor(CommandHelpTemplate, writer).Execute(writer, help)
+}
+
+// helpTemplateFor returns tmpl if writer is color.Output or a terminal,
+// else a clone of tmpl with the usage and description functions
+// not colorizing their output.
+func helpTemplateFor(tmpl *template.Template, writer io.Writer) *template.Template {
+	if isColorWriter(writer) {
+		return tmpl
+	}
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return tmpl
+	}
+	plain := func(s string) string { return s }
+	return clone.Funcs(template.FuncMap{"usage": plain, "description": plain})
+}
+
+// isColorWriter returns if writer is color.Output
+// or a file that is a terminal
+func isColorWriter(writer io.Writer) bool {
+	if writer == color.Output {
+		return true
+	}
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	fd := file.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+func helpCommandNotFound(command string) error {
+	return UsageError{Err: fmt.Errorf("no help for command '%s': %w", command, ErrNotFound)}
+}
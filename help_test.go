@@ -0,0 +1,108 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+type helpTestArgsDef struct {
+	ArgsDef
+
+	Name  string `arg:"name" desc:"Name to greet" required:"true" example:"World"`
+	Greet string `arg:"greet" default:"Hello" env:"HELP_TEST_GREET" enum:"Hello,Hi"`
+}
+
+func Test_StringArgsDispatcher_Help(t *testing.T) {
+	var (
+		args helpTestArgsDef
+		buf  bytes.Buffer
+	)
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("greet", "Greets somebody", func(name, greet string) string { return greet + " " + name }, &args, PrintlnTo(&buf))
+	disp.EnableHelp("app", &buf)
+	setArgPrompt(t, nil)
+
+	err := disp.Dispatch(context.Background(), "greet", "World")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World\n", buf.String())
+
+	t.Setenv("HELP_TEST_GREET", "Hi")
+	buf.Reset()
+	err = disp.Dispatch(context.Background(), "greet", "World")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi World\n", buf.String())
+
+	err = disp.Dispatch(context.Background(), "greet")
+	assert.True(t, IsUsageError(err), "missing required arg")
+	err = disp.Dispatch(context.Background(), "greet", "World", "Hey")
+	assert.True(t, IsUsageError(err), "value not in enum")
+
+	expected := `Usage:
+  app greet <name:string> <greet:string>
+
+Greets somebody
+
+Arguments:
+  <name:string>  Name to greet
+      required
+      example: World
+  <greet:string>
+      default: Hello
+      env: HELP_TEST_GREET
+      one of: Hello, Hi
+`
+	buf.Reset()
+	_, err = disp.DispatchCombinedCommandAndArgs(context.Background(), []string{"help", "greet"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	_, err = disp.DispatchCombinedCommandAndArgs(context.Background(), []string{"greet", "--help"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	_, err = disp.DispatchCombinedCommandAndArgs(context.Background(), []string{"greet", "--", "--help", "Hi"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi --help\n", buf.String(), "-h and --help after -- are arguments")
+
+	buf.Reset()
+	_, err = disp.DispatchCombinedCommandAndArgs(context.Background(), []string{"help"})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "  app greet <name:string> <greet:string>\n      Greets somebody\n")
+
+	_, err = disp.DispatchCombinedCommandAndArgs(context.Background(), []string{"help", "unknown"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	super := NewSuperStringArgsDispatcher()
+	super.MustAddSuperCommand("say").MustAddCommand("greet", "Greets somebody", func(name, greet string) {}, &args)
+	super.EnableHelp("app", &buf)
+	buf.Reset()
+	_, _, err = super.DispatchCombinedCommandAndArgs(context.Background(), []string{"help", "say", "greet"})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "  app say greet <name:string> <greet:string>\n")
+
+	buf.Reset()
+	_, _, err = super.DispatchCombinedCommandAndArgs(context.Background(), []string{"say", "-h"})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "  app say greet <name:string> <greet:string>\n      Greets somebody\n")
+}
+
+func Test_writeCommandsHelp_NoColor(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	help := &CommandsHelp{Commands: []*CommandHelp{{Usage: "app greet", Description: "Greets somebody"}}}
+	var buf bytes.Buffer
+	err := writeCommandsHelp(&buf, help)
+	assert.NoError(t, err)
+	assert.Equal(t, "  app greet\n      Greets somebody\n\n", buf.String(), "no ANSI escapes for non terminal writers")
+
+	assert.True(t, isColorWriter(color.Output))
+	assert.Contains(t, HelpTemplateFuncs["usage"].(func(string) string)("app greet"), "\x1b[", "HelpTemplateFuncs still colorize")
+}
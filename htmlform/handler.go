@@ -45,6 +45,7 @@ type Handler struct {
 	}
 	template       *template.Template
 	successHandler http.Handler
+	middleware     []command.Middleware
 }
 
 func NewHandler(commandFunc interface{}, args command.Args, title string, successHandler http.Handler) (handler *Handler, err error) {
@@ -102,6 +103,12 @@ func (handler *Handler) SetSubmitButtonText(text string) {
 	handler.form.SubmitButtonText = text
 }
 
+// Use adds middleware that wraps the calls of the command function
+// with the form title as command.Invocation.Command.
+func (handler *Handler) Use(middleware ...command.Middleware) {
+	handler.middleware = append(handler.middleware, middleware...)
+}
+
 func (handler *Handler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -195,7 +202,9 @@ func (handler *Handler) post(response http.ResponseWriter, request *http.Request
 		argsMap[key] = string(file)
 	}
 
-	err = handler.cmdFunc(request.Context(), argsMap)
+	ctx := command.WithCommand(request.Context(), handler.form.Title, "")
+	ctx = command.WithMiddleware(ctx, handler.middleware...)
+	err = handler.cmdFunc(ctx, argsMap)
 	if err != nil {
 		httperr.Handle(err, response, request)
 		return
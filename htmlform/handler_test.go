@@ -0,0 +1,49 @@
+package htmlform
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ungerik/go-command"
+)
+
+type greetArgs struct {
+	command.ArgsDef
+
+	Name string `arg:"name"`
+}
+
+func TestHandler_Use(t *testing.T) {
+	var (
+		greeted string
+		trace   []string
+	)
+	handler := MustNewHandler(func(ctx context.Context, name string) { greeted = name }, new(greetArgs), "Greet", http.NotFoundHandler())
+	handler.Use(func(next command.Invoker) command.Invoker {
+		return func(ctx context.Context, inv *command.Invocation) ([]reflect.Value, error) {
+			trace = append(trace, inv.Command+" "+inv.ArgVals[0].String())
+			inv.ArgVals = []reflect.Value{reflect.ValueOf("Middleware")}
+			return next(ctx, inv)
+		}
+	})
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	assert.NoError(t, form.WriteField("name", "World"))
+	assert.NoError(t, form.Close())
+	request := httptest.NewRequest(http.MethodPost, "/", &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusNotFound, response.Code, "success handler called")
+	assert.Equal(t, []string{"Greet World"}, trace)
+	assert.Equal(t, "Middleware", greeted)
+}
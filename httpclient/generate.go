@@ -0,0 +1,274 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ungerik/go-command"
+	"github.com/ungerik/go-command/internal/names"
+)
+
+// GenerateClient writes the Go source of a package named packageName
+// with a Client type that calls the commands of disp mounted
+// with gorillamux.MountDispatcher using a RemoteDispatcher.
+//
+// Client has one method per command named after the command path
+// with the arguments of the command as typed parameters and
+// the results of the command as typed results.
+// Types that can't be referenced from another package,
+// like unexported types, are written as their underlying type
+// and interfaces as interface{}.
+func GenerateClient(writer io.Writer, packageName string, disp command.CommandLister) error {
+	g := &generator{imports: map[string]string{
+		"context": "context",
+		"github.com/ungerik/go-command/httpclient": "httpclient",
+	}}
+	var methods bytes.Buffer
+	usedNames := map[string]bool{"Remote": true}
+	for _, info := range disp.Commands() {
+		name := names.Exported(info.Path)
+		for i := 2; usedNames[name]; i++ {
+			name = names.Exported(info.Path) + strconv.Itoa(i)
+		}
+		usedNames[name] = true
+		g.writeMethod(&methods, name, info)
+	}
+
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "// Code generated by httpclient.GenerateClient; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&source, "package %s\n\n", packageName)
+	source.WriteString("import (\n")
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	// Standard library imports first
+	sort.SliceStable(paths, func(i, j int) bool {
+		return isStdLib(paths[i]) && !isStdLib(paths[j])
+	})
+	for i, path := range paths {
+		if i > 0 && isStdLib(paths[i-1]) && !isStdLib(path) {
+			source.WriteString("\n")
+		}
+		if name := g.imports[path]; name != lastPathElement(path) {
+			fmt.Fprintf(&source, "\t%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&source, "\t%q\n", path)
+		}
+	}
+	source.WriteString(")\n\n")
+	source.WriteString("// Client calls the remote commands\n")
+	source.WriteString("type Client struct {\n\tRemote *httpclient.RemoteDispatcher\n}\n\n")
+	source.WriteString("// NewClient returns a Client for the commands mounted at baseURL\n")
+	source.WriteString("func NewClient(baseURL string) *Client {\n\treturn &Client{Remote: httpclient.NewRemoteDispatcher(baseURL)}\n}\n\n")
+	source.Write(methods.Bytes())
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return fmt.Errorf("can't format generated client because of: %w", err)
+	}
+	_, err = writer.Write(formatted)
+	return err
+}
+
+type generator struct {
+	// imports maps import paths to package names
+	imports map[string]string
+}
+
+func (g *generator) writeMethod(w *bytes.Buffer, name string, info *command.CommandInfo) {
+	cmd := info.Command()
+	if cmd == "" {
+		fmt.Fprintf(w, "// %s calls the default command", name)
+	} else {
+		fmt.Fprintf(w, "// %s calls the command %q", name, cmd)
+	}
+	if info.Description != "" {
+		fmt.Fprintf(w, ".\n//\n// %s", strings.ReplaceAll(strings.TrimSpace(info.Description), "\n", "\n// "))
+	}
+	w.WriteString("\n")
+
+	usedParams := map[string]bool{"c": true, "ctx": true, "err": true, "results": true}
+	for i := range info.ResultTypes {
+		usedParams["r"+strconv.Itoa(i)] = true
+	}
+	params := []string{"ctx context.Context"}
+	argsMap := make([]string, 0, info.Args.NumArgs())
+	for i, arg := range info.Args.Args() {
+		param := names.Unexported(arg.Name)
+		if !token.IsIdentifier(param) {
+			param = "arg" + strconv.Itoa(i)
+		}
+		for usedParams[param] || token.IsKeyword(param) {
+			param += "Arg"
+		}
+		usedParams[param] = true
+		params = append(params, param+" "+g.typeExpr(arg.Type))
+		argsMap = append(argsMap, fmt.Sprintf("%q: %s", arg.Name, param))
+	}
+
+	var resultTypes []string
+	for _, t := range info.ResultTypes {
+		resultTypes = append(resultTypes, g.typeExpr(t))
+	}
+	fmt.Fprintf(w, "func (c *Client) %s(%s) ", name, strings.Join(params, ", "))
+	switch len(resultTypes) {
+	case 0:
+		w.WriteString("error {\n")
+	default:
+		fmt.Fprintf(w, "(%s, error) {\n", strings.Join(resultTypes, ", "))
+	}
+
+	args := "nil"
+	if len(argsMap) > 0 {
+		args = "map[string]interface{}{\n" + strings.Join(argsMap, ",\n") + ",\n}"
+	}
+	switch {
+	case len(resultTypes) == 0:
+		fmt.Fprintf(w, "return c.Remote.Call(ctx, %q, %s)\n", cmd, args)
+
+	case info.Results != nil && info.Results.NumResults() == len(resultTypes):
+		w.WriteString("var results struct {\n")
+		fields := make([]string, len(resultTypes))
+		for i, result := range info.Results.Results() {
+			fields[i] = "results.R" + strconv.Itoa(i)
+			fmt.Fprintf(w, "R%d %s `json:%q`\n", i, resultTypes[i], result.Name)
+		}
+		w.WriteString("}\n")
+		fmt.Fprintf(w, "err := c.Remote.Call(ctx, %q, %s, &results)\n", cmd, args)
+		fmt.Fprintf(w, "return %s, err\n", strings.Join(fields, ", "))
+
+	default:
+		vars := make([]string, len(resultTypes))
+		ptrs := make([]string, len(resultTypes))
+		for i, t := range resultTypes {
+			vars[i] = "r" + strconv.Itoa(i)
+			ptrs[i] = "&" + vars[i]
+			fmt.Fprintf(w, "var %s %s\n", vars[i], t)
+		}
+		fmt.Fprintf(w, "err := c.Remote.Call(ctx, %q, %s, %s)\n", cmd, args, strings.Join(ptrs, ", "))
+		fmt.Fprintf(w, "return %s, err\n", strings.Join(vars, ", "))
+	}
+	w.WriteString("}\n\n")
+}
+
+// typeExpr returns the Go expression for t
+// and adds the import of its package
+func (g *generator) typeExpr(t reflect.Type) string {
+	return g.typeExprVisiting(t, make(map[reflect.Type]bool))
+}
+
+func (g *generator) typeExprVisiting(t reflect.Type, visiting map[reflect.Type]bool) string {
+	if t.Name() != "" && t.Kind() != reflect.Interface {
+		if t.PkgPath() == "" {
+			// Predeclared type
+			return t.Name()
+		}
+		if isImportable(t) {
+			return g.importName(t) + "." + t.Name()
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return t.Kind().String()
+
+	case reflect.Ptr:
+		return "*" + g.typeExprVisiting(t.Elem(), visiting)
+
+	case reflect.Slice:
+		return "[]" + g.typeExprVisiting(t.Elem(), visiting)
+
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), g.typeExprVisiting(t.Elem(), visiting))
+
+	case reflect.Map:
+		return "map[" + g.typeExprVisiting(t.Key(), visiting) + "]" + g.typeExprVisiting(t.Elem(), visiting)
+
+	case reflect.Struct:
+		if visiting[t] {
+			return "interface{}"
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		var b strings.Builder
+		b.WriteString("struct {\n")
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				// Unexported fields are not marshalled as JSON
+				continue
+			}
+			if !field.Anonymous || field.Type.Name() == "" || !isImportable(field.Type) {
+				b.WriteString(field.Name + " ")
+			}
+			b.WriteString(g.typeExprVisiting(field.Type, visiting))
+			if field.Tag != "" && !strings.Contains(string(field.Tag), "`") {
+				b.WriteString(" `" + string(field.Tag) + "`")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+		return b.String()
+	}
+	// Interfaces, channels, functions, and unsafe pointers
+	return "interface{}"
+}
+
+// importName returns the name of the imported package of t,
+// adding an alias if the name is already used by another import
+func (g *generator) importName(t reflect.Type) string {
+	path := t.PkgPath()
+	if name, ok := g.imports[path]; ok {
+		return name
+	}
+	base := strings.SplitN(t.String(), ".", 2)[0]
+	name := base
+	for i := 2; g.isImportName(name); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.imports[path] = name
+	return name
+}
+
+func (g *generator) isImportName(name string) bool {
+	for _, n := range g.imports {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isImportable returns true if the named type t
+// can be referenced from another package
+func isImportable(t reflect.Type) bool {
+	path := t.PkgPath()
+	return token.IsExported(t.Name()) &&
+		!strings.Contains(t.Name(), "[") &&
+		path != "main" &&
+		!strings.HasPrefix(path, "internal/") &&
+		!strings.Contains(path, "/internal/") &&
+		!strings.HasSuffix(path, "/internal")
+}
+
+// isStdLib returns true for import paths
+// without a domain name in the first element
+func isStdLib(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+func lastPathElement(path string) string {
+	return path[strings.LastIndexByte(path, '/')+1:]
+}
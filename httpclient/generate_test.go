@@ -0,0 +1,26 @@
+package httpclient
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateClient(t *testing.T) {
+	var source bytes.Buffer
+	err := GenerateClient(&source, "mathclient", newTestDispatcher())
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, expected := range []string{
+		"// Code generated by httpclient.GenerateClient; DO NOT EDIT.\n\npackage mathclient\n",
+		"import (\n\t\"context\"\n\t\"time\"\n\n\t\"github.com/ungerik/go-command/httpclient\"\n)\n",
+		"// MathAdd calls the command \"math add\".\n//\n// Adds a and b\nfunc (c *Client) MathAdd(ctx context.Context, a int, b int) (int, error) {\n",
+		"func (c *Client) MathDiv(ctx context.Context, a int, b int) (int, int, error) {\n\tvar results struct {\n\t\tR0 int `json:\"quotient\"`\n\t\tR1 int `json:\"remainder\"`\n\t}\n",
+		"\terr := c.Remote.Call(ctx, \"math swap\", map[string]interface{}{\n\t\t\"a\": a,\n\t\t\"b\": b,\n\t}, &r0, &r1)\n\treturn r0, r1, err\n",
+		"func (c *Client) TimeWait(ctx context.Context, duration time.Duration, tags []string) (*struct {\n\tWaited time.Duration\n\tTags   []string\n}, error) {\n",
+	} {
+		assert.Contains(t, source.String(), expected)
+	}
+}
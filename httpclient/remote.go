@@ -0,0 +1,322 @@
+// Package httpclient calls commands mounted with
+// gorillamux.MountDispatcher over HTTP and generates
+// typed Go clients for them.
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ungerik/go-command"
+	"github.com/ungerik/go-command/gorillamux"
+)
+
+// StatusError is returned for HTTP responses
+// with a status code of 400 or above
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("HTTP status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return e.Message
+}
+
+// RemoteDispatcher dispatches commands with string arguments
+// like command.StringArgsDispatcher, but calls them over HTTP
+// at a URL where they were mounted with gorillamux.MountDispatcher.
+//
+// Errors with the HTTP status 404 Not Found for commands
+// that are not listed by Commands are returned
+// as command.CommandNotFoundError and errors with the status
+// 400 Bad Request as command.UsageError wrapping a StatusError.
+// All other error statuses are returned as StatusError.
+//
+// The index of the remote commands is fetched once
+// and cached for dispatching and for checking 404 errors.
+type RemoteDispatcher struct {
+	// BaseURL is the URL of the prefix the commands were mounted at
+	BaseURL string
+	// Client is used for the requests, http.DefaultClient if nil
+	Client *http.Client
+	// Header is added to all requests
+	Header http.Header
+	// Output receives the plaintext results of dispatched commands,
+	// os.Stdout if nil
+	Output io.Writer
+
+	commandsMtx sync.Mutex
+	commands    []gorillamux.MountedCommand
+}
+
+// NewRemoteDispatcher returns a RemoteDispatcher for
+// the commands mounted at baseURL
+func NewRemoteDispatcher(baseURL string) *RemoteDispatcher {
+	return &RemoteDispatcher{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Dispatch calls the remote command with the string args
+// and writes its results as plaintext to disp.Output.
+// The path elements of commands of super commands
+// are separated by spaces.
+func (disp *RemoteDispatcher) Dispatch(ctx context.Context, command string, args ...string) error {
+	if args == nil {
+		args = []string{}
+	}
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	result, err := disp.post(ctx, command, body, "text/plain")
+	if err != nil {
+		return err
+	}
+	output := disp.Output
+	if output == nil {
+		output = os.Stdout
+	}
+	if len(result) > 0 && result[len(result)-1] != '\n' {
+		result = append(result, '\n')
+	}
+	_, err = output.Write(result)
+	return err
+}
+
+func (disp *RemoteDispatcher) MustDispatch(ctx context.Context, command string, args ...string) {
+	err := disp.Dispatch(ctx, command, args...)
+	if err != nil {
+		panic(fmt.Errorf("Command '%s': %w", command, err))
+	}
+}
+
+// DispatchCombinedCommandAndArgs dispatches the longest command path
+// from the beginning of commandAndArgs that is listed by Commands
+// with the rest of commandAndArgs as arguments.
+func (disp *RemoteDispatcher) DispatchCombinedCommandAndArgs(ctx context.Context, commandAndArgs []string) (command string, err error) {
+	commands, err := disp.cachedCommands(ctx)
+	if err != nil {
+		return "", err
+	}
+	n := commandPathLength(commands, commandAndArgs)
+	if n < 0 {
+		if len(commandAndArgs) == 0 {
+			return "", disp.Dispatch(ctx, "")
+		}
+		return commandAndArgs[0], disp.Dispatch(ctx, commandAndArgs[0], commandAndArgs[1:]...)
+	}
+	command = strings.Join(commandAndArgs[:n], " ")
+	return command, disp.Dispatch(ctx, command, commandAndArgs[n:]...)
+}
+
+// DispatchCommandLineArgs dispatches the command and
+// its arguments from commandAndArgs like DispatchCombinedCommandAndArgs.
+func (disp *RemoteDispatcher) DispatchCommandLineArgs(ctx context.Context, commandAndArgs []string) error {
+	_, err := disp.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+	return err
+}
+
+// DispatchCommandLine splits line into the command and its arguments
+// with command.SplitCommandLine and dispatches them like DispatchCombinedCommandAndArgs.
+func (disp *RemoteDispatcher) DispatchCommandLine(ctx context.Context, line string) (cmd string, err error) {
+	commandAndArgs, err := command.SplitCommandLine(line)
+	if err != nil {
+		return "", command.UsageError{Err: err}
+	}
+	return disp.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+}
+
+// Commands fetches the index of the remote commands
+// and updates the cached index with it
+func (disp *RemoteDispatcher) Commands(ctx context.Context) ([]gorillamux.MountedCommand, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, disp.BaseURL+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := disp.do(request, "")
+	if err != nil {
+		return nil, err
+	}
+	var commands []gorillamux.MountedCommand
+	err = json.Unmarshal(body, &commands)
+	if err != nil {
+		return nil, fmt.Errorf("can't unmarshal commands index because of: %w", err)
+	}
+	disp.commandsMtx.Lock()
+	disp.commands = commands
+	disp.commandsMtx.Unlock()
+	return commands, nil
+}
+
+// cachedCommands returns the cached index of the remote
+// commands or fetches it with Commands if not cached yet
+func (disp *RemoteDispatcher) cachedCommands(ctx context.Context) ([]gorillamux.MountedCommand, error) {
+	disp.commandsMtx.Lock()
+	commands := disp.commands
+	disp.commandsMtx.Unlock()
+	if commands != nil {
+		return commands, nil
+	}
+	return disp.Commands(ctx)
+}
+
+// PrintCommandsTo prints the remote commands with their arguments
+// and descriptions to writer
+func (disp *RemoteDispatcher) PrintCommandsTo(writer io.Writer, appName string) {
+	commands, err := disp.Commands(context.Background())
+	if err != nil {
+		fmt.Fprintf(writer, "Can't list commands: %s\n", err)
+		return
+	}
+	fmt.Fprintf(writer, "Commands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(writer, "  %s", appName)
+		if cmd.Command != "" {
+			fmt.Fprintf(writer, " %s", cmd.Command)
+		}
+		for _, arg := range cmd.Args {
+			fmt.Fprintf(writer, " <%s:%s>", arg.Name, arg.Type)
+		}
+		fmt.Fprintln(writer)
+		if cmd.Description != "" {
+			fmt.Fprintf(writer, "      %s\n", cmd.Description)
+		}
+	}
+}
+
+// Call calls the remote command with the arguments by name
+// and unmarshals the JSON results into the pointers results.
+// time.Duration arguments are passed in the format of time.Duration.String
+// and slices of strings in the command line format [a,b].
+// Multiple results are requested as newline delimited JSON
+// and a single command.NamedResultValues result as JSON object.
+func (disp *RemoteDispatcher) Call(ctx context.Context, command string, args map[string]interface{}, results ...interface{}) error {
+	jsonArgs := make(map[string]interface{}, len(args))
+	for name, arg := range args {
+		jsonArgs[name] = argValue(arg)
+	}
+	body, err := json.Marshal(jsonArgs)
+	if err != nil {
+		return err
+	}
+	accept := "application/json"
+	if len(results) > 1 {
+		accept = "application/x-ndjson"
+	}
+	result, err := disp.post(ctx, command, body, accept)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	for i, r := range results {
+		err = decoder.Decode(r)
+		if err != nil {
+			return fmt.Errorf("can't unmarshal result %d of command '%s' because of: %w", i, command, err)
+		}
+	}
+	return nil
+}
+
+func (disp *RemoteDispatcher) post(ctx context.Context, cmd string, body []byte, accept string) ([]byte, error) {
+	path := strings.Fields(cmd)
+	escaped := make([]string, len(path))
+	for i, elem := range path {
+		escaped[i] = url.PathEscape(elem)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, disp.BaseURL+"/"+strings.Join(escaped, "/"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	result, err := disp.do(request, accept)
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusNotFound:
+			commands, indexErr := disp.cachedCommands(ctx)
+			if indexErr == nil && commandPathLength(commands, path) != len(path) {
+				return nil, command.CommandNotFoundError{Command: cmd}
+			}
+		case http.StatusBadRequest:
+			return nil, command.UsageError{Err: statusErr}
+		}
+	}
+	return result, err
+}
+
+func (disp *RemoteDispatcher) do(request *http.Request, accept string) ([]byte, error) {
+	for key, values := range disp.Header {
+		request.Header[key] = append(request.Header[key], values...)
+	}
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	client := disp.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 400 {
+		return nil, StatusError{StatusCode: response.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return body, nil
+}
+
+// argValue returns arg in a form that gorillamux.MountDispatcher
+// parses as the type of arg
+func argValue(arg interface{}) interface{} {
+	switch x := arg.(type) {
+	case time.Duration:
+		return x.String()
+	case *time.Duration:
+		if x != nil {
+			return x.String()
+		}
+	}
+	v := reflect.ValueOf(arg)
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String {
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = v.Index(i).String()
+		}
+		return "[" + strings.Join(elems, ",") + "]"
+	}
+	return arg
+}
+
+// commandPathLength returns the number of elements at the beginning
+// of commandAndArgs that form the longest path of a command
+// or alias in commands, or -1 if there is no such command.
+func commandPathLength(commands []gorillamux.MountedCommand, commandAndArgs []string) int {
+	longest := -1
+	for _, cmd := range commands {
+		info := command.CommandInfo{Path: strings.Fields(cmd.Command), Aliases: cmd.Aliases}
+		n := len(info.Path)
+		if n > longest && n <= len(commandAndArgs) && info.MatchesPath(commandAndArgs[:n]) {
+			longest = n
+		}
+	}
+	return longest
+}
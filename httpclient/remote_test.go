@@ -0,0 +1,178 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/ungerik/go-httpx/httperr"
+
+	"github.com/ungerik/go-command"
+	"github.com/ungerik/go-command/gorillamux"
+)
+
+type addArgs struct {
+	command.ArgsDef
+
+	A int `arg:"a" desc:"First number" required:"true"`
+	B int `arg:"b" default:"10"`
+}
+
+type divResults struct {
+	command.ResultsDef
+
+	Quotient  int `result:"quotient"`
+	Remainder int `result:"remainder"`
+}
+
+type waitArgs struct {
+	command.ArgsDef
+
+	Duration time.Duration `arg:"duration"`
+	Tags     []string      `arg:"tags"`
+}
+
+type waitResult struct {
+	Waited time.Duration
+	Tags   []string
+}
+
+func newTestDispatcher() *command.SuperStringArgsDispatcher {
+	disp := command.NewSuperStringArgsDispatcher()
+	math := disp.MustAddSuperCommand("math")
+	math.MustAddCommand("add", "Adds a and b", func(ctx context.Context, a, b int) int { return a + b }, new(addArgs))
+	math.MustAddAlias("add", "plus")
+	math.MustAddCommandWithResults("div", "Divides a by b", func(ctx context.Context, a, b int) (int, int, error) {
+		if b == 0 {
+			return 0, 0, errors.New("division by zero")
+		}
+		return a / b, a % b, nil
+	}, new(addArgs), new(divResults))
+	math.MustAddCommand("swap", "Swaps a and b", func(ctx context.Context, a, b int) (int, int) { return b, a }, new(addArgs))
+	disp.MustAddSuperCommand("time").MustAddCommand("wait", "Pretends to wait", func(ctx context.Context, d time.Duration, tags []string) *waitResult {
+		return &waitResult{Waited: d, Tags: tags}
+	}, new(waitArgs))
+	return disp
+}
+
+func newTestServer() *httptest.Server {
+	router := mux.NewRouter()
+	gorillamux.MountDispatcher(router, "/api", newTestDispatcher())
+	return httptest.NewServer(router)
+}
+
+func TestRemoteDispatcher_Dispatch(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	var output bytes.Buffer
+	disp := NewRemoteDispatcher(server.URL + "/api/")
+	disp.Output = &output
+	ctx := context.Background()
+
+	assert.NoError(t, disp.Dispatch(ctx, "math add", "1", "2"))
+	assert.NoError(t, disp.Dispatch(ctx, "math plus", "1"))
+	assert.Equal(t, "3\n11\n", output.String())
+
+	err := disp.Dispatch(ctx, "math sub", "1")
+	assert.True(t, errors.Is(err, command.ErrNotFound), "command not found")
+	err = disp.Dispatch(ctx, "math add", "x")
+	assert.True(t, command.IsUsageError(err), "invalid argument")
+	err = disp.Dispatch(ctx, "math div", "1", "0")
+	var statusErr StatusError
+	if assert.True(t, errors.As(err, &statusErr), "command error") {
+		assert.Equal(t, 500, statusErr.StatusCode)
+	}
+
+	output.Reset()
+	assert.NoError(t, disp.DispatchCommandLineArgs(ctx, []string{"math", "plus", "2", "3"}))
+	cmd, err := disp.DispatchCommandLine(ctx, "time wait 1s")
+	assert.NoError(t, err)
+	assert.Equal(t, "time wait", cmd)
+	assert.Contains(t, output.String(), "5\n")
+
+	output.Reset()
+	disp.PrintCommandsTo(&output, "app")
+	assert.Contains(t, output.String(), "app math add <a:int> <b:int>\n      Adds a and b\n")
+}
+
+func TestRemoteDispatcher_Call(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	disp := NewRemoteDispatcher(server.URL + "/api")
+	ctx := context.Background()
+
+	var sum int
+	assert.NoError(t, disp.Call(ctx, "math add", map[string]interface{}{"a": 1, "b": 2}, &sum))
+	assert.Equal(t, 3, sum)
+
+	var quotient, remainder int
+	assert.NoError(t, disp.Call(ctx, "math swap", map[string]interface{}{"a": 1, "b": 2}, &quotient, &remainder))
+	assert.Equal(t, []int{2, 1}, []int{quotient, remainder})
+
+	var named struct {
+		Quotient  int `json:"quotient"`
+		Remainder int `json:"remainder"`
+	}
+	assert.NoError(t, disp.Call(ctx, "math div", map[string]interface{}{"a": 7, "b": 2}, &named))
+	assert.Equal(t, 3, named.Quotient)
+	assert.Equal(t, 1, named.Remainder)
+
+	var waited waitResult
+	assert.NoError(t, disp.Call(ctx, "time wait", map[string]interface{}{"duration": time.Second, "tags": []string{"x", "y"}}, &waited))
+	assert.Equal(t, waitResult{Waited: time.Second, Tags: []string{"x", "y"}}, waited)
+
+	err := disp.Call(ctx, "math add", nil, &sum)
+	assert.True(t, command.IsUsageError(err), "missing required argument")
+}
+
+func TestRemoteDispatcher_NotFound(t *testing.T) {
+	disp := command.NewSuperStringArgsDispatcher()
+	users := disp.MustAddSuperCommand("users")
+	users.MustAddCommand("get", "Gets a user", func(ctx context.Context, name string) (string, error) {
+		if name != "alice" {
+			return "", httperr.NotFound
+		}
+		return name, nil
+	}, new(struct {
+		command.ArgsDef
+		Name string `arg:"name"`
+	}))
+	users.MustAddCommand("100%", "Uses all users", func() string { return "ok" }, &command.WithoutArgs)
+	indexRequests := 0
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodGet && request.URL.Path == "/api/" {
+				indexRequests++
+			}
+			next.ServeHTTP(writer, request)
+		})
+	})
+	gorillamux.MountDispatcher(router, "/api", disp)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	var output bytes.Buffer
+	remote := NewRemoteDispatcher(server.URL + "/api")
+	remote.Output = &output
+	ctx := context.Background()
+
+	_, err := remote.DispatchCombinedCommandAndArgs(ctx, []string{"users", "get", "alice"})
+	assert.NoError(t, err)
+	_, err = remote.DispatchCombinedCommandAndArgs(ctx, []string{"users", "100%"})
+	assert.NoError(t, err, "escaped path element")
+	assert.Equal(t, "alice\nok\n", output.String())
+
+	_, err = remote.DispatchCombinedCommandAndArgs(ctx, []string{"users", "get", "bob"})
+	var statusErr StatusError
+	assert.True(t, errors.As(err, &statusErr), "404 of a listed command")
+	assert.False(t, errors.Is(err, command.ErrNotFound), "404 of a listed command")
+	err = remote.Dispatch(ctx, "users list")
+	assert.True(t, errors.Is(err, command.ErrNotFound), "command not listed")
+	assert.Equal(t, 1, indexRequests, "cached commands index")
+}
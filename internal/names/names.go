@@ -0,0 +1,45 @@
+// Package names converts command paths and argument names
+// to identifiers for generated source code.
+package names
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Exported returns the path elements joined in PascalCase
+// or "Default" for an empty path.
+// Names starting with a digit are prefixed with "Command".
+func Exported(path []string) string {
+	var b strings.Builder
+	for _, elem := range path {
+		for _, word := range SplitWords(elem) {
+			runes := []rune(word)
+			b.WriteRune(unicode.ToUpper(runes[0]))
+			b.WriteString(string(runes[1:]))
+		}
+	}
+	name := b.String()
+	switch {
+	case name == "":
+		return "Default"
+	case unicode.IsDigit([]rune(name)[0]):
+		return "Command" + name
+	}
+	return name
+}
+
+// Unexported returns name in camelCase
+func Unexported(name string) string {
+	runes := []rune(Exported([]string{name}))
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// SplitWords splits s at all characters
+// that are not letters or digits
+func SplitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,20 @@
+package names
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExported(t *testing.T) {
+	assert.Equal(t, "Default", Exported(nil))
+	assert.Equal(t, "MathAddAll", Exported([]string{"math", "add-all"}))
+	assert.Equal(t, "UserGetByID", Exported([]string{"user", "get-by_ID"}))
+	assert.Equal(t, "Command2fa", Exported([]string{"2fa"}))
+}
+
+func TestUnexported(t *testing.T) {
+	assert.Equal(t, "maxCount", Unexported("max_count"))
+	assert.Equal(t, "mathAdd", Unexported("MathAdd"))
+	assert.Equal(t, "default", Unexported(""))
+}
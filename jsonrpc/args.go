@@ -0,0 +1,89 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ungerik/go-command"
+)
+
+// ArgsMap returns the JSON array or object params
+// as arguments by name for command.CommandInfo.CallWithMapArgs.
+// Array elements are named after the arguments of args by position,
+// missing or null params are passed as empty map.
+// JSON strings are passed as strings like command line arguments
+// and all other values as json.RawMessage.
+// Invalid params are returned as Error with CodeInvalidParams,
+// with an ArgValidationError as Data for every object param
+// that is not an argument of args.
+func ArgsMap(args command.Args, params json.RawMessage) (map[string]interface{}, error) {
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || string(params) == "null" {
+		return map[string]interface{}{}, nil
+	}
+	switch params[0] {
+	case '[':
+		var values []json.RawMessage
+		err := json.Unmarshal(params, &values)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		if len(values) > args.NumArgs() {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("%d arguments passed, but command has only %d", len(values), args.NumArgs())}
+		}
+		argsMap := make(map[string]interface{}, len(values))
+		for i, value := range values {
+			argsMap[args.Args()[i].Name] = argValue(value)
+		}
+		return argsMap, nil
+
+	case '{':
+		var values map[string]json.RawMessage
+		err := json.Unmarshal(params, &values)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		var unknown []ArgValidationError
+		argsMap := make(map[string]interface{}, len(values))
+		for name, value := range values {
+			if !hasArg(args, name) {
+				unknown = append(unknown, ArgValidationError{Arg: name, Message: fmt.Sprintf("unknown argument <%s>", name)})
+				continue
+			}
+			argsMap[name] = argValue(value)
+		}
+		if len(unknown) > 0 {
+			sort.Slice(unknown, func(i, j int) bool { return unknown[i].Arg < unknown[j].Arg })
+			return nil, &Error{Code: CodeInvalidParams, Message: "unknown arguments passed", Data: unknown}
+		}
+		return argsMap, nil
+	}
+	return nil, &Error{Code: CodeInvalidParams, Message: "params must be an array or object"}
+}
+
+// argValue returns JSON strings unquoted,
+// nil for null, and all other values unchanged
+func argValue(value json.RawMessage) interface{} {
+	switch {
+	case string(value) == "null":
+		return nil
+	case len(value) > 0 && value[0] == '"':
+		var str string
+		if json.Unmarshal(value, &str) == nil {
+			return str
+		}
+	}
+	return value
+}
+
+// hasArg returns if args has an argument with name
+func hasArg(args command.Args, name string) bool {
+	for _, arg := range args.Args() {
+		if arg.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/ungerik/go-command"
+	"github.com/ungerik/go-httpx/httperr"
+)
+
+// MaxLineSize is the maximum size of a request line read by Server.Serve
+var MaxLineSize = 16 * 1024 * 1024
+
+// Server calls the commands of a command.CommandLister
+// for JSON-RPC requests.
+//
+// The method names are the paths of the commands joined
+// by MethodSeparator where the last path element can
+// also be an alias of the command.
+// The Default command of a dispatcher has no method name
+// and can't be called.
+//
+// The params of a request are passed as JSON array
+// or object via ArgsMap to command.CommandInfo.CallWithMapArgs
+// and the results are returned as JSON
+// like described by command.ResultsJSONSchema.
+type Server struct {
+	// MethodSeparator joins the path elements of commands to method names
+	MethodSeparator string
+
+	disp       command.CommandLister
+	middleware []command.Middleware
+}
+
+// NewServer returns a Server for the commands of disp
+// using "." as MethodSeparator
+func NewServer(disp command.CommandLister) *Server {
+	return &Server{MethodSeparator: ".", disp: disp}
+}
+
+// Use adds middleware that wraps the calls
+// of all commands called by the server
+// around the middleware of the dispatcher.
+func (s *Server) Use(middleware ...command.Middleware) {
+	s.middleware = append(s.middleware, middleware...)
+}
+
+// Handle returns the response to a JSON-RPC request or batch
+// message or nil if the message only contains notifications.
+func (s *Server) Handle(ctx context.Context, message []byte) []byte {
+	message = bytes.TrimSpace(message)
+	if len(message) > 0 && message[0] == '[' {
+		var batch []json.RawMessage
+		err := json.Unmarshal(message, &batch)
+		if err != nil {
+			return marshalResponse(errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()}))
+		}
+		if len(batch) == 0 {
+			return marshalResponse(errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"}))
+		}
+		var responses []*Response
+		for _, request := range batch {
+			if response := s.handleRequest(ctx, request); response != nil {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return marshalResponse(responses)
+	}
+
+	response := s.handleRequest(ctx, message)
+	if response == nil {
+		return nil
+	}
+	return marshalResponse(response)
+}
+
+func (s *Server) handleRequest(ctx context.Context, message json.RawMessage) (response *Response) {
+	var request Request
+	err := json.Unmarshal(message, &request)
+	if err != nil {
+		if !json.Valid(message) {
+			return errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()})
+		}
+		return errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: err.Error()})
+	}
+	if request.JSONRPC != Version || request.Method == "" {
+		return errorResponse(request.ID, &Error{Code: CodeInvalidRequest, Message: "invalid JSON-RPC 2.0 request"})
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			response = errorResponse(request.ID, &Error{Code: CodeInternalError, Message: fmt.Sprint(p)})
+			if request.IsNotification() {
+				response = nil
+			}
+		}
+	}()
+
+	result, err := s.call(ctx, &request)
+	if request.IsNotification() {
+		return nil
+	}
+	if err != nil {
+		return errorResponse(request.ID, asError(err))
+	}
+	return &Response{JSONRPC: Version, Result: result, ID: request.ID}
+}
+
+func (s *Server) call(ctx context.Context, request *Request) (json.RawMessage, error) {
+	info := command.LookupCommandByName(s.disp, request.Method, s.MethodSeparator)
+	if info == nil {
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method '%s' not found", request.Method)}
+	}
+
+	args, err := ArgsMap(info.Args, request.Params)
+	if err != nil {
+		return nil, err
+	}
+	resultVals, err := info.CallWithMapArgs(command.WithMiddleware(ctx, s.middleware...), args)
+	defer command.DiscardResultStreams(resultVals)
+	if err != nil {
+		return nil, err
+	}
+	result, err := command.ResultsJSON(resultVals, info.Results)
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	return result, nil
+}
+
+// asError returns err as Error with
+// CodeMethodNotFound for errors wrapping command.ErrNotFound,
+// CodeInvalidParams for command.ArgError and command.UsageError,
+// and CodeServerError for all other errors.
+func asError(err error) *Error {
+	var (
+		rpcErr *Error
+		argErr command.ArgError
+	)
+	switch {
+	case errors.As(err, &rpcErr):
+		return rpcErr
+	case errors.Is(err, command.ErrNotFound):
+		return &Error{Code: CodeMethodNotFound, Message: err.Error()}
+	case errors.As(err, &argErr):
+		return &Error{
+			Code:    CodeInvalidParams,
+			Message: err.Error(),
+			Data:    []ArgValidationError{{Arg: argErr.Arg, Message: argErr.Err.Error()}},
+		}
+	case command.IsUsageError(err):
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return &Error{Code: CodeServerError, Message: err.Error()}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	return &Response{JSONRPC: Version, Error: err, ID: id}
+}
+
+// marshalResponse returns response as single line JSON
+// without escaping HTML characters in error messages
+func marshalResponse(response interface{}) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(response)
+	if err != nil {
+		// Results are already marshalled, so this should never happen
+		buf.Reset()
+		encoder.Encode(errorResponse(nil, &Error{Code: CodeInternalError, Message: err.Error()}))
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})
+}
+
+// ServeHTTP implements http.Handler for JSON-RPC requests
+// and batches POSTed as request body.
+// Requests with only notifications are answered
+// with 204 No Content.
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		httperr.New(http.StatusMethodNotAllowed).ServeHTTP(writer, request)
+		return
+	}
+	defer request.Body.Close()
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		httperr.BadRequest.ServeHTTP(writer, request)
+		return
+	}
+	response := s.Handle(request.Context(), body)
+	if response == nil {
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.Write(response)
+}
+
+// Serve reads newline delimited JSON-RPC requests and batches from reader
+// and writes the responses line by line to writer
+// until reader returns io.EOF or ctx is canceled.
+func (s *Server) Serve(ctx context.Context, reader io.Reader, writer io.Writer) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineSize)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		response := s.Handle(ctx, line)
+		if response == nil {
+			continue
+		}
+		_, err := writer.Write(append(response, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ServeStdio calls Serve with os.Stdin and os.Stdout
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.Serve(ctx, os.Stdin, os.Stdout)
+}
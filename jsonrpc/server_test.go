@@ -0,0 +1,105 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ungerik/go-command"
+)
+
+type addArgs struct {
+	command.ArgsDef
+
+	A int `arg:"a" required:"true"`
+	B int `arg:"b" default:"10"`
+}
+
+func newTestServer(t *testing.T) *Server {
+	disp := command.NewSuperStringArgsDispatcher()
+	sub := disp.MustAddSuperCommand("math")
+	sub.MustAddCommand("add", "Adds a and b", func(ctx context.Context, a, b int) int { return a + b }, new(addArgs))
+	sub.MustAddAlias("add", "plus")
+	sub.MustAddCommand("fail", "Fails", func(ctx context.Context, a, b int) error { return errors.New("failed") }, new(addArgs))
+	return NewServer(disp)
+}
+
+func TestServer_Handle(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		request string
+		want    string
+	}{
+		{name: "named params", request: `{"jsonrpc":"2.0","method":"math.add","params":{"a":1,"b":2},"id":1}`, want: `{"jsonrpc":"2.0","result":3,"id":1}`},
+		{name: "positional params", request: `{"jsonrpc":"2.0","method":"math.add","params":[1,2],"id":"x"}`, want: `{"jsonrpc":"2.0","result":3,"id":"x"}`},
+		{name: "default arg", request: `{"jsonrpc":"2.0","method":"math.plus","params":{"a":1},"id":2}`, want: `{"jsonrpc":"2.0","result":11,"id":2}`},
+		{name: "string params", request: `{"jsonrpc":"2.0","method":"math.add","params":["1","2"],"id":7}`, want: `{"jsonrpc":"2.0","result":3,"id":7}`},
+		{name: "too many params", request: `{"jsonrpc":"2.0","method":"math.add","params":[1,2,3],"id":8}`, want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"3 arguments passed, but command has only 2"},"id":8}`},
+		{name: "unknown params", request: `{"jsonrpc":"2.0","method":"math.add","params":{"a":1,"b":2,"z":3,"c":4},"id":10}`, want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"unknown arguments passed","data":[{"arg":"c","message":"unknown argument <c>"},{"arg":"z","message":"unknown argument <z>"}]},"id":10}`},
+		{name: "invalid params", request: `{"jsonrpc":"2.0","method":"math.add","params":1,"id":9}`, want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"params must be an array or object"},"id":9}`},
+		{name: "notification", request: `{"jsonrpc":"2.0","method":"math.add","params":[1]}`, want: ``},
+		{name: "method not found", request: `{"jsonrpc":"2.0","method":"math.sub","id":3}`, want: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method 'math.sub' not found"},"id":3}`},
+		{name: "missing arg", request: `{"jsonrpc":"2.0","method":"math.add","id":4}`, want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"missing required argument <a>","data":[{"arg":"a","message":"missing required argument <a>"}]},"id":4}`},
+		{name: "command error", request: `{"jsonrpc":"2.0","method":"math.fail","params":[1],"id":5}`, want: `{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed"},"id":5}`},
+		{name: "invalid request", request: `{"method":"math.add","id":6}`, want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid JSON-RPC 2.0 request"},"id":6}`},
+		{name: "parse error", request: `{"jsonrpc":`, want: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"unexpected end of JSON input"},"id":null}`},
+		{name: "batch", request: `[{"jsonrpc":"2.0","method":"math.add","params":[1,1],"id":1},{"jsonrpc":"2.0","method":"math.add","params":[1]},{"jsonrpc":"2.0","method":"math.add","params":[2,2],"id":2}]`, want: `[{"jsonrpc":"2.0","result":2,"id":1},{"jsonrpc":"2.0","result":4,"id":2}]`},
+		{name: "empty batch", request: `[]`, want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"},"id":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(server.Handle(ctx, []byte(tt.request))))
+		})
+	}
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	httpServer := httptest.NewServer(newTestServer(t))
+	defer httpServer.Close()
+
+	response, err := http.Post(httpServer.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","method":"math.add","params":[1,2],"id":1}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		response.Body.Close()
+	}
+
+	response, err = http.Post(httpServer.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","method":"math.add","params":[1,2]}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNoContent, response.StatusCode)
+		response.Body.Close()
+	}
+}
+
+func TestServer_Serve(t *testing.T) {
+	input := strings.NewReader(`{"jsonrpc":"2.0","method":"math.add","params":[1,2],"id":1}
+
+{"jsonrpc":"2.0","method":"math.add","params":[1,2]}
+{"jsonrpc":"2.0","method":"math.add","params":[3,4],"id":2}
+`)
+	var output bytes.Buffer
+	err := newTestServer(t).Serve(context.Background(), input, &output)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":3,"id":1}`+"\n"+`{"jsonrpc":"2.0","result":7,"id":2}`+"\n", output.String())
+}
+
+func TestServer_DispatcherLoggers(t *testing.T) {
+	var logged []string
+	disp := command.NewStringArgsDispatcher(command.StringArgsCommandLoggerFunc(func(cmd string, args []string) {
+		logged = append(logged, cmd+" "+strings.Join(args, " "))
+	}))
+	disp.MustAddCommand("add", "Adds a and b", func(ctx context.Context, a, b int) int { return a + b }, new(addArgs))
+	server := NewServer(disp)
+
+	response := server.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":3,"id":1}`, string(response))
+	assert.Equal(t, []string{"add --a=1 --b=2"}, logged)
+}
@@ -0,0 +1,323 @@
+package command
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/domonda/go-types/nullable"
+)
+
+// JSONSchemaDraft is the $schema of the schemas
+// returned by JSONSchema and ResultsJSONSchema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	typeOfDuration = reflect.TypeOf(time.Duration(0))
+	typeOfNullable = reflect.TypeOf((*nullable.Nullable)(nil)).Elem()
+)
+
+// Schema is a JSON Schema (draft 2020-12) object
+// marshalled with encoding/json.
+type Schema struct {
+	Schema  string `json:"$schema,omitempty"`
+	Comment string `json:"$comment,omitempty"`
+
+	// Type is a string or a []string like
+	// ["string", "null"] for nullable types.
+	// An empty Type allows any JSON value.
+	Type        interface{}   `json:"type,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Examples    []interface{} `json:"examples,omitempty"`
+	WriteOnly   bool          `json:"writeOnly,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	PrefixItems          []*Schema          `json:"prefixItems,omitempty"`
+
+	Minimum         *float64 `json:"minimum,omitempty"`
+	Maximum         *float64 `json:"maximum,omitempty"`
+	MinLength       *int     `json:"minLength,omitempty"`
+	MaxLength       *int     `json:"maxLength,omitempty"`
+	Pattern         string   `json:"pattern,omitempty"`
+	ContentEncoding string   `json:"contentEncoding,omitempty"`
+}
+
+// JSONSchema returns the JSON schema of an object
+// with args as properties as accepted by JSONArgsFunc.
+//
+// The property names are the names of the json struct tags
+// of the arguments or the argument names.
+// Descriptions, defaults, enums, examples, required and secret
+// arguments are taken from the Arg fields and the constraints
+// of the schemas from the struct tags ArgMinTag, ArgMaxTag,
+// ArgMinLengthTag, ArgMaxLengthTag, and ArgPatternTag.
+func JSONSchema(args Args) *Schema {
+	schema := &Schema{
+		Schema:     JSONSchemaDraft,
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	for i, arg := range args.Args() {
+		name := strings.Split(args.ArgTag(i, "json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = arg.Name
+		}
+		schema.Properties[name] = ArgJSONSchema(args, i)
+		if arg.Required && arg.Default == "" && arg.Env == "" {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// ArgJSONSchema returns the JSON schema of the argument
+// with index of args as used for its property by JSONSchema.
+func ArgJSONSchema(args Args, index int) *Schema {
+	arg := args.Args()[index]
+	schema := jsonSchemaForType(arg.Type, nil)
+	schema.Description = arg.Description
+	schema.WriteOnly = arg.Secret
+	if arg.Default != "" {
+		schema.Default = jsonSchemaValue(arg.Type, arg.Default)
+	}
+	for _, e := range arg.Enum {
+		schema.Enum = append(schema.Enum, jsonSchemaValue(arg.Type, e))
+	}
+	if arg.Example != "" {
+		schema.Examples = []interface{}{jsonSchemaValue(arg.Type, arg.Example)}
+	}
+	if min, err := strconv.ParseFloat(args.ArgTag(index, ArgMinTag), 64); err == nil {
+		schema.Minimum = &min
+	}
+	if max, err := strconv.ParseFloat(args.ArgTag(index, ArgMaxTag), 64); err == nil {
+		schema.Maximum = &max
+	}
+	if minLength, err := strconv.Atoi(args.ArgTag(index, ArgMinLengthTag)); err == nil {
+		schema.MinLength = &minLength
+	}
+	if maxLength, err := strconv.Atoi(args.ArgTag(index, ArgMaxLengthTag)); err == nil {
+		schema.MaxLength = &maxLength
+	}
+	schema.Pattern = args.ArgTag(index, ArgPatternTag)
+	return schema
+}
+
+// ResultsJSONSchema returns the JSON schema of the results
+// of a command function with resultTypes not including the error result.
+//
+// If results is not nil, then the schema is an object with
+// the named results as properties like the JSON of NamedResultValues.
+// Else the schema is the one of a single result type,
+// an array of multiple results, or null for no results.
+func ResultsJSONSchema(resultTypes []reflect.Type, results Results) *Schema {
+	var schema *Schema
+	switch {
+	case results != nil && results.NumResults() > 0:
+		schema = &Schema{
+			Type:       "object",
+			Properties: make(map[string]*Schema),
+		}
+		for _, result := range results.Results() {
+			prop := jsonSchemaForType(result.Type, nil)
+			prop.Description = result.Description
+			schema.Properties[result.Name] = prop
+			schema.Required = append(schema.Required, result.Name)
+		}
+	case len(resultTypes) == 0:
+		schema = &Schema{Type: "null"}
+	case len(resultTypes) == 1:
+		schema = jsonSchemaForType(resultTypes[0], nil)
+	default:
+		schema = &Schema{Type: "array"}
+		for _, t := range resultTypes {
+			schema.PrefixItems = append(schema.PrefixItems, jsonSchemaForType(t, nil))
+		}
+	}
+	schema.Schema = JSONSchemaDraft
+	return schema
+}
+
+// ResultsJSON returns the JSON of resultVals
+// as described by ResultsJSONSchema.
+func ResultsJSON(resultVals []reflect.Value, results Results) ([]byte, error) {
+	switch {
+	case results != nil && results.NumResults() > 0:
+		return json.Marshal(NamedResultValues{Results: results, Values: resultVals})
+	case len(resultVals) == 0:
+		return []byte("null"), nil
+	case len(resultVals) == 1:
+		return json.Marshal(resultVals[0].Interface())
+	}
+	values := make([]interface{}, len(resultVals))
+	for i, resultVal := range resultVals {
+		values[i] = resultVal.Interface()
+	}
+	return json.Marshal(values)
+}
+
+// jsonSchemaForType returns a new schema for the JSON
+// representation of values of type t.
+// visiting holds the struct types whose schema is being built
+// to stop recursion at recursive types.
+func jsonSchemaForType(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	switch {
+	case t.Kind() == reflect.Interface:
+		return &Schema{}
+
+	case t.Kind() == reflect.Ptr:
+		return nullableSchema(jsonSchemaForType(t.Elem(), visiting))
+
+	case t == typeOfTime:
+		return &Schema{Type: "string", Format: "date-time"}
+
+	case t == typeOfNullableTime:
+		return &Schema{Type: []string{"string", "null"}, Format: "date-time"}
+
+	case t == typeOfDuration:
+		return &Schema{Type: "integer", Comment: "time.Duration in nanoseconds"}
+
+	case t.Implements(typeOfNullable) || reflect.PtrTo(t).Implements(typeOfNullable):
+		if t.Kind() == reflect.Struct && !implementsTextMarshaler(t) {
+			return &Schema{}
+		}
+		return nullableSchema(jsonSchemaForKind(t, visiting))
+
+	case implementsTextMarshaler(t) && !implementsJSONMarshaler(t):
+		return &Schema{Type: "string"}
+
+	case implementsJSONMarshaler(t) && t.Kind() == reflect.Struct:
+		return &Schema{}
+	}
+	return jsonSchemaForKind(t, visiting)
+}
+
+func jsonSchemaForKind(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		min := 0.0
+		return &Schema{Type: "integer", Minimum: &min}
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		// nil slices are marshalled as null
+		return &Schema{
+			Type:  []string{"array", "null"},
+			Items: jsonSchemaForType(t.Elem(), visiting),
+		}
+
+	case reflect.Array:
+		return &Schema{Type: "array", Items: jsonSchemaForType(t.Elem(), visiting)}
+
+	case reflect.Map:
+		return &Schema{
+			Type:                 []string{"object", "null"},
+			AdditionalProperties: jsonSchemaForType(t.Elem(), visiting),
+		}
+
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{Type: "object"}
+		}
+		if visiting == nil {
+			visiting = make(map[reflect.Type]bool)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addJSONSchemaStructFields(schema, t, visiting)
+		return schema
+	}
+	// Channels, functions, and complex numbers
+	// can't be marshalled as JSON
+	return &Schema{}
+}
+
+// addJSONSchemaStructFields adds the fields of the struct type t
+// as properties to schema following the rules of encoding/json
+// for field names and embedded structs.
+func addJSONSchemaStructFields(schema *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				addJSONSchemaStructFields(schema, fieldType, visiting)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = jsonSchemaForType(field.Type, visiting)
+	}
+}
+
+// nullableSchema adds "null" to the type of schema
+func nullableSchema(schema *Schema) *Schema {
+	if t, ok := schema.Type.(string); ok && t != "null" {
+		schema.Type = []string{t, "null"}
+	}
+	return schema
+}
+
+func implementsTextMarshaler(t reflect.Type) bool {
+	return t.Implements(typeOfTextMarshaler) || reflect.PtrTo(t).Implements(typeOfTextMarshaler)
+}
+
+func implementsJSONMarshaler(t reflect.Type) bool {
+	return t.Implements(typeOfJSONMarshaler) || reflect.PtrTo(t).Implements(typeOfJSONMarshaler)
+}
+
+// jsonSchemaValue returns str parsed as value of type t
+// in its JSON representation or str if it can't be parsed.
+func jsonSchemaValue(t reflect.Type, str string) interface{} {
+	val := reflect.New(t).Elem()
+	if assignString(val, str) != nil {
+		return str
+	}
+	b, err := json.Marshal(val.Interface())
+	if err != nil {
+		return str
+	}
+	var value interface{}
+	if json.Unmarshal(b, &value) != nil {
+		return str
+	}
+	return value
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/domonda/go-types/nullable"
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonSchemaTestAddress struct {
+	Street string `json:"street"`
+	City   string
+	Next   *jsonSchemaTestAddress `json:"next,omitempty"`
+	hidden int
+}
+
+type jsonSchemaTestArgs struct {
+	ArgsDef
+
+	Name     string                 `arg:"name" desc:"User name" required:"true" minLength:"1" maxLength:"64" pattern:"^[a-z]+$"`
+	Age      uint                   `arg:"age" max:"150" default:"18"`
+	Level    string                 `arg:"level" enum:"debug,info"`
+	Password string                 `arg:"password" secret:"true"`
+	Timeout  time.Duration          `arg:"timeout" default:"1s"`
+	Since    time.Time              `arg:"since"`
+	Until    nullable.Time          `arg:"until"`
+	Tags     []string               `arg:"tags" example:"[a,b]"`
+	Data     []byte                 `arg:"data"`
+	Limit    *int                   `arg:"limit" json:"max_items"`
+	Address  jsonSchemaTestAddress  `arg:"address"`
+	Extra    map[string]interface{} `arg:"extra"`
+}
+
+func Test_JSONSchema(t *testing.T) {
+	args := new(jsonSchemaTestArgs)
+	assert.NoError(t, args.Init(args))
+
+	schema := JSONSchema(args)
+	assert.Equal(t, JSONSchemaDraft, schema.Schema)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"name"}, schema.Required)
+
+	name := schema.Properties["name"]
+	assert.Equal(t, "string", name.Type)
+	assert.Equal(t, "User name", name.Description)
+	assert.Equal(t, 1, *name.MinLength)
+	assert.Equal(t, 64, *name.MaxLength)
+	assert.Equal(t, "^[a-z]+$", name.Pattern)
+
+	age := schema.Properties["age"]
+	assert.Equal(t, "integer", age.Type)
+	assert.Equal(t, 0.0, *age.Minimum)
+	assert.Equal(t, 150.0, *age.Maximum)
+	assert.Equal(t, 18.0, age.Default)
+
+	assert.Equal(t, []interface{}{"debug", "info"}, schema.Properties["level"].Enum)
+	assert.True(t, schema.Properties["password"].WriteOnly)
+	assert.Equal(t, float64(time.Second), schema.Properties["timeout"].Default)
+	assert.Equal(t, "date-time", schema.Properties["since"].Format)
+	assert.Equal(t, []string{"string", "null"}, schema.Properties["until"].Type)
+	assert.Equal(t, []interface{}{[]interface{}{"a", "b"}}, schema.Properties["tags"].Examples)
+	assert.Equal(t, "string", schema.Properties["tags"].Items.Type)
+	assert.Equal(t, "base64", schema.Properties["data"].ContentEncoding)
+	assert.Equal(t, []string{"integer", "null"}, schema.Properties["max_items"].Type)
+	assert.NotContains(t, schema.Properties, "limit")
+	assert.Equal(t, &Schema{}, schema.Properties["extra"].AdditionalProperties)
+
+	address := schema.Properties["address"]
+	assert.Equal(t, "object", address.Type)
+	assert.Len(t, address.Properties, 3)
+	assert.Equal(t, "string", address.Properties["street"].Type)
+	assert.Equal(t, "string", address.Properties["City"].Type)
+	assert.Equal(t, []string{"object", "null"}, address.Properties["next"].Type)
+	assert.Empty(t, address.Properties["next"].Properties, "recursion stopped")
+
+	_, err := json.Marshal(schema)
+	assert.NoError(t, err)
+}
+
+func Test_ResultsJSONSchema(t *testing.T) {
+	schema := ResultsJSONSchema(nil, nil)
+	assert.Equal(t, "null", schema.Type)
+
+	schema = ResultsJSONSchema([]reflect.Type{reflect.TypeOf([]int{})}, nil)
+	assert.Equal(t, JSONSchemaDraft, schema.Schema)
+	assert.Equal(t, []string{"array", "null"}, schema.Type)
+	assert.Equal(t, "integer", schema.Items.Type)
+
+	schema = ResultsJSONSchema([]reflect.Type{reflect.TypeOf(""), reflect.TypeOf(false)}, nil)
+	assert.Equal(t, "array", schema.Type)
+	if assert.Len(t, schema.PrefixItems, 2) {
+		assert.Equal(t, "string", schema.PrefixItems[0].Type)
+		assert.Equal(t, "boolean", schema.PrefixItems[1].Type)
+	}
+
+	results := new(struct {
+		ResultsDef
+
+		Count int  `result:"count" desc:"Number of items"`
+		Done  bool `result:"done"`
+	})
+	assert.NoError(t, results.Init(results))
+	schema = ResultsJSONSchema([]reflect.Type{reflect.TypeOf(0), reflect.TypeOf(false)}, results)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"count", "done"}, schema.Required)
+	assert.Equal(t, "Number of items", schema.Properties["count"].Description)
+	assert.Equal(t, "boolean", schema.Properties["done"].Type)
+}
+
+func Test_ResultsJSON(t *testing.T) {
+	j, err := ResultsJSON(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(j))
+
+	j, err = ResultsJSON([]reflect.Value{reflect.ValueOf(1)}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(j))
+
+	j, err = ResultsJSON([]reflect.Value{reflect.ValueOf(1), reflect.ValueOf("a")}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `[1,"a"]`, string(j))
+}
@@ -0,0 +1,252 @@
+// Package mcp serves the commands of a dispatcher as tools
+// with the Model Context Protocol over newline delimited stdio.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/ungerik/go-command"
+	"github.com/ungerik/go-command/jsonrpc"
+)
+
+// ProtocolVersions are the supported MCP versions, latest first
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// ToolNameSeparator joins the path elements of commands to tool names
+var ToolNameSeparator = "_"
+
+// Tool describes a command as MCP tool
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema *command.Schema `json:"inputSchema"`
+}
+
+// Content is a content block of a tool result
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult is the result of a tools/call request.
+// Errors of commands are returned with IsError set.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Server serves the commands of a command.CommandLister as MCP tools.
+//
+// The tool names are the paths of the commands joined
+// by ToolNameSeparator. The Default command of a dispatcher
+// has no name and is not listed as tool.
+// The input schema of a tool is the command.JSONSchema of its arguments
+// and tools are called with their arguments converted by
+// jsonrpc.ArgsMap with command.CommandInfo.CallWithMapArgs.
+type Server struct {
+	Name    string
+	Version string
+
+	disp       command.CommandLister
+	middleware []command.Middleware
+}
+
+// NewServer returns a Server for the commands of disp
+// identified by name and version to clients
+func NewServer(name, version string, disp command.CommandLister) *Server {
+	return &Server{Name: name, Version: version, disp: disp}
+}
+
+// Use adds middleware that wraps the calls
+// of all commands called by the server
+// around the middleware of the dispatcher.
+func (s *Server) Use(middleware ...command.Middleware) {
+	s.middleware = append(s.middleware, middleware...)
+}
+
+// Tools returns the commands as tools sorted by name
+func (s *Server) Tools() []Tool {
+	var tools []Tool
+	for _, info := range s.disp.Commands() {
+		if len(info.Path) == 0 {
+			continue
+		}
+		tools = append(tools, Tool{
+			Name:        strings.Join(info.Path, ToolNameSeparator),
+			Description: info.Description,
+			InputSchema: command.JSONSchema(info.Args),
+		})
+	}
+	return tools
+}
+
+// Serve reads newline delimited JSON-RPC messages from reader
+// and writes the responses line by line to writer
+// until reader returns io.EOF or ctx is canceled.
+func (s *Server) Serve(ctx context.Context, reader io.Reader, writer io.Writer) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), jsonrpc.MaxLineSize)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		response := s.handleMessage(ctx, line)
+		if response == nil {
+			continue
+		}
+		err := encoder.Encode(response)
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ServeStdio calls Serve with os.Stdin and os.Stdout
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// handleMessage returns the response to a request message
+// or nil for notifications and responses from the client.
+func (s *Server) handleMessage(ctx context.Context, message []byte) *jsonrpc.Response {
+	var request jsonrpc.Request
+	err := json.Unmarshal(message, &request)
+	if err != nil {
+		return errorResponse(nil, jsonrpc.CodeParseError, err.Error())
+	}
+	if request.JSONRPC != jsonrpc.Version || request.Method == "" {
+		if request.Method == "" && !request.IsNotification() {
+			// Response to a request of the server
+			return nil
+		}
+		return errorResponse(request.ID, jsonrpc.CodeInvalidRequest, "invalid JSON-RPC 2.0 request")
+	}
+	if request.IsNotification() {
+		// notifications/initialized and notifications/cancelled
+		// don't need to be handled
+		return nil
+	}
+
+	var result interface{}
+	switch request.Method {
+	case "initialize":
+		result, err = s.initialize(request.Params)
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = map[string]interface{}{"tools": s.Tools()}
+	case "tools/call":
+		result, err = s.callTool(ctx, request.Params)
+	default:
+		err = &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: fmt.Sprintf("method '%s' not found", request.Method)}
+	}
+	if err != nil {
+		if rpcErr, ok := err.(*jsonrpc.Error); ok {
+			return &jsonrpc.Response{JSONRPC: jsonrpc.Version, Error: rpcErr, ID: request.ID}
+		}
+		return errorResponse(request.ID, jsonrpc.CodeInternalError, err.Error())
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(request.ID, jsonrpc.CodeInternalError, err.Error())
+	}
+	return &jsonrpc.Response{JSONRPC: jsonrpc.Version, Result: resultJSON, ID: request.ID}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var initParams struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		err := json.Unmarshal(params, &initParams)
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+		}
+	}
+	protocolVersion := ProtocolVersions[0]
+	for _, version := range ProtocolVersions {
+		if version == initParams.ProtocolVersion {
+			protocolVersion = version
+		}
+	}
+	return map[string]interface{}{
+		"protocolVersion": protocolVersion,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{
+			"name":    s.Name,
+			"version": s.Version,
+		},
+	}, nil
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (result *ToolResult, err error) {
+	var callParams struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	err = json.Unmarshal(params, &callParams)
+	if err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+	}
+	info := command.LookupCommandByName(s.disp, callParams.Name, ToolNameSeparator)
+	if info == nil || len(info.Path) == 0 {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: fmt.Sprintf("unknown tool '%s'", callParams.Name)}
+	}
+
+	args, err := jsonrpc.ArgsMap(info.Args, callParams.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			result, err = &ToolResult{Content: []Content{{Type: "text", Text: fmt.Sprint(p)}}, IsError: true}, nil
+		}
+	}()
+	resultVals, err := info.CallWithMapArgs(command.WithMiddleware(ctx, s.middleware...), args)
+	defer command.DiscardResultStreams(resultVals)
+	if err != nil {
+		return &ToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	return resultContent(resultVals, info.Results), nil
+}
+
+// resultContent returns a text content block for a single string result
+// or the JSON of the results, or no content for no results.
+func resultContent(resultVals []reflect.Value, results command.Results) *ToolResult {
+	if len(resultVals) == 0 {
+		return &ToolResult{Content: []Content{}}
+	}
+	if str, ok := resultVals[0].Interface().(string); ok && len(resultVals) == 1 && results == nil {
+		return &ToolResult{Content: []Content{{Type: "text", Text: str}}}
+	}
+	resultJSON, err := command.ResultsJSON(resultVals, results)
+	if err != nil {
+		return &ToolResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	return &ToolResult{Content: []Content{{Type: "text", Text: string(resultJSON)}}}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *jsonrpc.Response {
+	return &jsonrpc.Response{
+		JSONRPC: jsonrpc.Version,
+		Error:   &jsonrpc.Error{Code: code, Message: message},
+		ID:      id,
+	}
+}
@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ungerik/go-command"
+)
+
+type greetArgs struct {
+	command.ArgsDef
+
+	Name  string `arg:"name" desc:"Name to greet" required:"true"`
+	Times int    `arg:"times" default:"1"`
+}
+
+type client struct {
+	t       *testing.T
+	writer  io.Writer
+	scanner *bufio.Scanner
+}
+
+// call sends a request and returns the JSON of the response
+func (c *client) call(request string) map[string]interface{} {
+	c.t.Helper()
+	_, err := io.WriteString(c.writer, request+"\n")
+	assert.NoError(c.t, err)
+	if !assert.True(c.t, c.scanner.Scan(), "response line") {
+		return nil
+	}
+	var response map[string]interface{}
+	assert.NoError(c.t, json.Unmarshal(c.scanner.Bytes(), &response))
+	return response
+}
+
+func TestServer(t *testing.T) {
+	disp := command.NewSuperStringArgsDispatcher()
+	greet := disp.MustAddSuperCommand("greet")
+	greet.MustAddCommand("hello", "Says hello", func(ctx context.Context, name string, times int) (string, error) {
+		if name == "nobody" {
+			return "", errors.New("nobody to greet")
+		}
+		return "Hello " + name, nil
+	}, new(greetArgs))
+	greet.MustAddCommand("count", "Counts greetings", func(ctx context.Context, name string, times int) []int {
+		return make([]int, times)
+	}, new(greetArgs))
+	server := NewServer("test", "1.0", disp)
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- server.Serve(context.Background(), serverReader, serverWriter)
+	}()
+	c := &client{t: t, writer: clientWriter, scanner: bufio.NewScanner(clientReader)}
+
+	response := c.call(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	result := response["result"].(map[string]interface{})
+	assert.Equal(t, "2024-11-05", result["protocolVersion"])
+	assert.Equal(t, map[string]interface{}{"name": "test", "version": "1.0"}, result["serverInfo"])
+
+	_, err := io.WriteString(clientWriter, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n")
+	assert.NoError(t, err)
+
+	response = c.call(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	tools := response["result"].(map[string]interface{})["tools"].([]interface{})
+	if assert.Len(t, tools, 2) {
+		tool := tools[1].(map[string]interface{})
+		assert.Equal(t, "greet_hello", tool["name"])
+		assert.Equal(t, "Says hello", tool["description"])
+		schema := tool["inputSchema"].(map[string]interface{})
+		assert.Equal(t, "object", schema["type"])
+		assert.Equal(t, []interface{}{"name"}, schema["required"])
+	}
+
+	response = c.call(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"greet_hello","arguments":{"name":"World"}}}`)
+	assert.Equal(t, map[string]interface{}{"content": []interface{}{map[string]interface{}{"type": "text", "text": "Hello World"}}}, response["result"])
+
+	response = c.call(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"greet_count","arguments":{"name":"World","times":2}}}`)
+	assert.Equal(t, map[string]interface{}{"content": []interface{}{map[string]interface{}{"type": "text", "text": "[0,0]"}}}, response["result"])
+
+	response = c.call(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"greet_hello","arguments":{"name":"nobody"}}}`)
+	assert.Equal(t, true, response["result"].(map[string]interface{})["isError"])
+
+	response = c.call(`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"greet_hello","arguments":{}}}`)
+	assert.Equal(t, true, response["result"].(map[string]interface{})["isError"], "missing required argument")
+
+	response = c.call(`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"greet_hello","arguments":{"name":"World","nmae":"World"}}}`)
+	assert.Equal(t, float64(-32602), response["error"].(map[string]interface{})["code"], "unknown argument")
+
+	response = c.call(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"unknown"}}`)
+	assert.Equal(t, float64(-32602), response["error"].(map[string]interface{})["code"])
+
+	response = c.call(`{"jsonrpc":"2.0","id":8,"method":"resources/list"}`)
+	assert.Equal(t, float64(-32601), response["error"].(map[string]interface{})["code"])
+
+	clientWriter.Close()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"context"
+	"reflect"
+)
+
+// Invocation is a call of a command function
+// passed through the Middleware of the call
+type Invocation struct {
+	// Command is the name of the called command
+	// if it was set with WithCommand like the dispatchers do
+	Command     string
+	Description string
+	Args        Args
+	// ArgVals are the parsed argument values
+	// without the context.Context argument
+	// that can be replaced by Middleware
+	ArgVals []reflect.Value
+}
+
+// Invoker calls the command function of an invocation
+// and returns its results without the error result
+type Invoker func(ctx context.Context, inv *Invocation) (resultVals []reflect.Value, err error)
+
+// Middleware returns an Invoker that wraps next.
+// It can inspect or change the invocation,
+// measure or log the call, recover panics,
+// or return results without calling next.
+type Middleware func(next Invoker) Invoker
+
+type middlewareKey struct{}
+
+type commandKey struct{}
+
+type commandInfo struct {
+	command     string
+	description string
+}
+
+// WithMiddleware returns a context that wraps all command function
+// calls made with it in middleware after the Middleware already
+// added to ctx. The first Middleware is the outermost one.
+// Middleware is not passed on to calls made by the command function.
+func WithMiddleware(ctx context.Context, middleware ...Middleware) context.Context {
+	if len(middleware) == 0 {
+		return ctx
+	}
+	outer, _ := ctx.Value(middlewareKey{}).([]Middleware)
+	combined := make([]Middleware, 0, len(outer)+len(middleware))
+	combined = append(append(combined, outer...), middleware...)
+	return context.WithValue(ctx, middlewareKey{}, combined)
+}
+
+// WithCommand returns a context that sets the
+// Invocation.Command and Invocation.Description
+// of command function calls made with it.
+func WithCommand(ctx context.Context, command, description string) context.Context {
+	return context.WithValue(ctx, commandKey{}, commandInfo{command: command, description: description})
+}
+
+// chainMiddleware returns invoke wrapped by middleware
+// with the first Middleware as the outermost one.
+func chainMiddleware(invoke Invoker, middleware []Middleware) Invoker {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		invoke = middleware[i](invoke)
+	}
+	return invoke
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StringArgsDispatcher_Use(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		trace []string
+	)
+	tracing := func(name string) Middleware {
+		return func(next Invoker) Invoker {
+			return func(ctx context.Context, inv *Invocation) ([]reflect.Value, error) {
+				trace = append(trace, fmt.Sprintf("%s %s %v", name, inv.Command, inv.ArgVals[0]))
+				resultVals, err := next(ctx, inv)
+				trace = append(trace, fmt.Sprintf("%s %v %v", name, resultVals, err))
+				return resultVals, err
+			}
+		}
+	}
+	recovering := func(next Invoker) Invoker {
+		return func(ctx context.Context, inv *Invocation) (resultVals []reflect.Value, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("recovered: %v", r)
+				}
+			}()
+			return next(ctx, inv)
+		}
+	}
+	doubling := func(next Invoker) Invoker {
+		return func(ctx context.Context, inv *Invocation) ([]reflect.Value, error) {
+			inv.ArgVals = []reflect.Value{reflect.ValueOf(int(inv.ArgVals[0].Int()) * 2)}
+			return next(ctx, inv)
+		}
+	}
+
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("count", "Counts", func(count int) (int, error) {
+		if count < 0 {
+			panic("negative")
+		}
+		return count, nil
+	}, new(struct {
+		ArgsDef
+		Count int `arg:"count"`
+	}), PrintlnTo(&buf))
+	disp.Use(recovering, tracing("outer"), tracing("inner"))
+
+	err := disp.Dispatch(context.Background(), "count", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "3\n", buf.String())
+	assert.Equal(t, []string{"outer count 3", "inner count 3", "inner [<int Value>] <nil>", "outer [<int Value>] <nil>"}, trace)
+
+	err = disp.Dispatch(context.Background(), "count", "-1")
+	assert.EqualError(t, err, "recovered: negative")
+
+	disp.Use(doubling)
+	buf.Reset()
+	err = disp.Dispatch(context.Background(), "count", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "6\n", buf.String())
+
+	super := NewSuperStringArgsDispatcher()
+	sub := super.MustAddSuperCommand("math")
+	sub.MustAddCommand("fail", "Fails", func() error { return errors.New("failed") }, new(struct{ ArgsDef }))
+	trace = nil
+	super.Use(func(next Invoker) Invoker {
+		return func(ctx context.Context, inv *Invocation) ([]reflect.Value, error) {
+			trace = append(trace, inv.Command)
+			return nil, nil // short-circuit
+		}
+	})
+	_, _, err = super.DispatchCombinedCommandAndArgs(context.Background(), []string{"math", "fail"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fail"}, trace)
+}
+
+func Test_WithMiddleware_notNested(t *testing.T) {
+	calls := 0
+	counting := func(next Invoker) Invoker {
+		return func(ctx context.Context, inv *Invocation) ([]reflect.Value, error) {
+			calls++
+			return next(ctx, inv)
+		}
+	}
+	inner := MustGetStringArgsFunc(func() {}, new(struct{ ArgsDef }))
+	outer := MustGetStringArgsFunc(func(ctx context.Context) error { return inner(ctx) }, new(struct{ ArgsDef }))
+	err := outer(WithMiddleware(context.Background(), counting))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_Middleware_replacedArgsToResultsHandlers(t *testing.T) {
+	var called, handled string
+	replacing := func(next Invoker) Invoker {
+		return func(ctx context.Context, inv *Invocation) ([]reflect.Value, error) {
+			inv.ArgVals = []reflect.Value{reflect.ValueOf("replaced")}
+			return next(ctx, inv)
+		}
+	}
+	handler := ResultsHandlerFunc(func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		handled = argVals[0].String()
+		return nil
+	})
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("echo", "", func(s string) { called = s }, new(struct {
+		ArgsDef
+		S string `arg:"s"`
+	}), handler)
+	disp.Use(replacing)
+
+	err := disp.Dispatch(context.Background(), "echo", "orig")
+	assert.NoError(t, err)
+	assert.Equal(t, "replaced", called)
+	assert.Equal(t, "replaced", handled)
+}
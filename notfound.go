@@ -0,0 +1,107 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxSuggestions is the maximum number of command names
+// suggested by a CommandNotFoundError
+var MaxSuggestions = 3
+
+// CommandNotFoundError is returned by dispatchers for commands
+// that are not registered. It matches ErrNotFound with errors.Is
+// and carries the names of the registered commands nearest
+// to Command by edit distance as Suggestions.
+// Err is the wrapped cause if any, like SuperCommandNotFound
+// for super commands that are not registered.
+type CommandNotFoundError struct {
+	Command     string
+	Suggestions []string
+	Err         error
+}
+
+func (e CommandNotFoundError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("command '%s' not found", e.Command)
+	}
+	quoted := make([]string, len(e.Suggestions))
+	for i, s := range e.Suggestions {
+		quoted[i] = "'" + s + "'"
+	}
+	return fmt.Sprintf("command '%s' not found, did you mean %s?", e.Command, strings.Join(quoted, " or "))
+}
+
+// Is returns true if target is ErrNotFound
+func (e CommandNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// Unwrap returns the wrapped Err
+func (e CommandNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// suggestCommands returns up to MaxSuggestions names
+// that have command as prefix or are within an
+// edit distance of 2 to command, nearest first.
+func suggestCommands(command string, names []string) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	var suggestions []suggestion
+	for _, name := range names {
+		if name == Default {
+			continue
+		}
+		distance := editDistance(command, name)
+		if strings.HasPrefix(name, command) || (distance <= 2 && distance < len(command)) {
+			suggestions = append(suggestions, suggestion{name, distance})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance == suggestions[j].distance {
+			return suggestions[i].name < suggestions[j].name
+		}
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	if len(suggestions) > MaxSuggestions {
+		suggestions = suggestions[:MaxSuggestions]
+	}
+	result := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		result[i] = s.name
+	}
+	return result
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
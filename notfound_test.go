@@ -0,0 +1,89 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StringArgsDispatcher_Aliases(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		calls []string
+	)
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("remove", "Removes something", func() { calls = append(calls, "remove") }, new(struct{ ArgsDef }))
+	disp.MustAddCommand("deploy", "Deploys something", func() { calls = append(calls, "deploy") }, new(struct{ ArgsDef }))
+	disp.MustAddCommand("delete", "Deletes something", func() { calls = append(calls, "delete") }, new(struct{ ArgsDef }))
+	disp.MustAddAlias("remove", "rm")
+	disp.EnableHelp("app", &buf)
+	disp.EnableCompletion(&buf)
+
+	assert.Error(t, disp.AddAlias("remove", "deploy"), "alias is a command")
+	assert.Error(t, disp.AddAlias("deploy", "rm"), "alias already added")
+	assert.Error(t, disp.AddAlias("unknown", "u"), "command not found")
+	assert.Error(t, disp.AddCommand("rm", "", func() {}, new(struct{ ArgsDef })), "command is an alias")
+
+	assert.NoError(t, disp.Dispatch(context.Background(), "rm"))
+	assert.Equal(t, []string{"remove"}, calls)
+
+	err := disp.Dispatch(context.Background(), "deplyo")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.Equal(t, CommandNotFoundError{Command: "deplyo", Suggestions: []string{"deploy"}}, err)
+	assert.EqualError(t, err, "command 'deplyo' not found, did you mean 'deploy'?")
+
+	err = disp.Dispatch(context.Background(), "dep")
+	assert.True(t, errors.Is(err, ErrNotFound), "prefix matching not enabled")
+
+	disp.EnablePrefixMatching()
+	calls = nil
+	assert.NoError(t, disp.Dispatch(context.Background(), "dep"))
+	assert.NoError(t, disp.Dispatch(context.Background(), "r"))
+	assert.Equal(t, []string{"deploy", "remove"}, calls)
+	err = disp.Dispatch(context.Background(), "de")
+	assert.EqualError(t, err, "command 'de' not found, did you mean 'delete' or 'deploy'?")
+
+	buf.Reset()
+	assert.NoError(t, disp.Dispatch(context.Background(), "help", "rm"))
+	assert.Equal(t, "Usage:\n  app remove\n\nAliases:\n  rm\n\nRemoves something\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, disp.Dispatch(context.Background(), CompleteCommand, "r"))
+	assert.Equal(t, "remove\nrm\n", buf.String())
+}
+
+func Test_SuperStringArgsDispatcher_NotFound(t *testing.T) {
+	disp := NewSuperStringArgsDispatcher()
+	app := disp.MustAddSuperCommand("app")
+	app.MustAddCommand("deploy", "", func() {}, new(struct{ ArgsDef }))
+	app.MustAddCommand("delete", "", func() {}, new(struct{ ArgsDef }))
+	disp.MustAddSuperCommand("apply")
+	ctx := context.Background()
+
+	err := disp.Dispatch(ctx, "ap", "deploy")
+	assert.Equal(t, CommandNotFoundError{Command: "ap", Suggestions: []string{"app", "apply"}, Err: SuperCommandNotFound("ap")}, err)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	var superErr SuperCommandNotFound
+	assert.True(t, errors.As(err, &superErr))
+	assert.Equal(t, SuperCommandNotFound("ap"), superErr)
+	_, _, err = disp.DispatchCombinedCommandAndArgs(ctx, []string{"aap", "deploy"})
+	assert.EqualError(t, err, "command 'aap' not found, did you mean 'app'?")
+	assert.ErrorIs(t, err, SuperCommandNotFound("aap"))
+
+	err = disp.Dispatch(ctx, "app", "deplyo")
+	assert.Equal(t, CommandNotFoundError{Command: "app deplyo", Suggestions: []string{"app deploy"}}, err)
+	_, _, err = disp.DispatchCombinedCommandAndArgs(ctx, []string{"app", "delte"})
+	assert.EqualError(t, err, "command 'app delte' not found, did you mean 'app delete'?")
+}
+
+func Test_suggestCommands(t *testing.T) {
+	names := []string{"deploy", "delete", "list", "status", ""}
+	assert.Equal(t, []string{"deploy"}, suggestCommands("deplyo", names))
+	assert.Equal(t, []string{"list"}, suggestCommands("lst", names))
+	assert.Equal(t, []string{"status"}, suggestCommands("stauts", names))
+	assert.Empty(t, suggestCommands("x", names))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
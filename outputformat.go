@@ -0,0 +1,28 @@
+package command
+
+import (
+	"io"
+	"sort"
+)
+
+// OutputFormats maps the names of the output formats that can be
+// selected with the OutputFlag to functions returning
+// a ResultsHandler that writes results in that format to writer.
+var OutputFormats = map[string]func(writer io.Writer) ResultsHandler{
+	"text":   func(writer io.Writer) ResultsHandler { return PrintlnTo(writer) },
+	"json":   func(writer io.Writer) ResultsHandler { return PrintJSONTo(writer) },
+	"ndjson": func(writer io.Writer) ResultsHandler { return PrintNDJSON(writer) },
+	"yaml":   func(writer io.Writer) ResultsHandler { return PrintYAML(writer) },
+	"table":  func(writer io.Writer) ResultsHandler { return PrintTable(writer) },
+	"csv":    func(writer io.Writer) ResultsHandler { return PrintCSV(writer) },
+}
+
+// OutputFormatNames returns the sorted names of the OutputFormats
+func OutputFormatNames() []string {
+	names := make([]string, 0, len(OutputFormats))
+	for name := range OutputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/ungerik/go-reflection"
+	"golang.org/x/term"
+)
+
+// argPrompt prompts for argument values
+type argPrompt struct {
+	in         *bufio.Reader
+	out        io.Writer
+	readSecret func() (string, error)
+}
+
+type promptMissingArgsKey struct{}
+
+// withPromptMissingArgs returns a context that enables
+// prompting for missing required arguments
+func withPromptMissingArgs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, promptMissingArgsKey{}, true)
+}
+
+// promptMissingArgs returns if PromptMissingArgs is true
+// or prompting was enabled for ctx with withPromptMissingArgs
+func promptMissingArgs(ctx context.Context) bool {
+	enabled, _ := ctx.Value(promptMissingArgsKey{}).(bool)
+	return PromptMissingArgs || enabled
+}
+
+// newTerminalArgPrompt returns an argPrompt reading from os.Stdin
+// and writing to os.Stderr if os.Stdin is a terminal, else nil.
+var newTerminalArgPrompt = func() *argPrompt {
+	fd := os.Stdin.Fd()
+	if !isatty.IsTerminal(fd) && !isatty.IsCygwinTerminal(fd) {
+		return nil
+	}
+	return &argPrompt{
+		in:  bufio.NewReader(os.Stdin),
+		out: os.Stderr,
+		readSecret: func() (string, error) {
+			secret, err := term.ReadPassword(int(fd))
+			return string(secret), err
+		},
+	}
+}
+
+// promptArg prompts for the value of arg until a valid value
+// was entered and assigned to argVal.
+// The Arg.Description is used as label if available,
+// the Arg.Default is used for empty input,
+// Arg.Enum values are presented as numbered menu,
+// and Arg.Secret values are read without echo.
+func (p *argPrompt) promptArg(arg *Arg, argVal reflect.Value) error {
+	label := arg.Description
+	if label == "" {
+		label = arg.Name
+	}
+	defaultSuffix := ""
+	if arg.Default != "" {
+		defaultSuffix = fmt.Sprintf(" [%s]", arg.Default)
+	}
+	for {
+		if len(arg.Enum) > 0 {
+			fmt.Fprintf(p.out, "%s:\n", label)
+			for i, option := range arg.Enum {
+				fmt.Fprintf(p.out, "  %d) %s\n", i+1, option)
+			}
+			fmt.Fprintf(p.out, "Select 1-%d%s: ", len(arg.Enum), defaultSuffix)
+		} else {
+			fmt.Fprintf(p.out, "%s <%s>%s: ", label, reflection.DerefType(arg.Type), defaultSuffix)
+		}
+
+		var (
+			input string
+			err   error
+		)
+		if arg.Secret {
+			input, err = p.readSecret()
+			fmt.Fprintln(p.out)
+		} else {
+			input, err = p.readLine()
+		}
+		if err == io.EOF {
+			return fmt.Errorf("missing required argument <%s>", arg.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		input = strings.TrimRight(input, "\r\n")
+		if !arg.Secret {
+			input = strings.TrimSpace(input)
+		}
+		if input == "" {
+			if arg.Default == "" {
+				fmt.Fprintf(p.out, "A value for <%s> is required\n", arg.Name)
+				continue
+			}
+			input = arg.Default
+		}
+		if len(arg.Enum) > 0 && !containsString(arg.Enum, input) {
+			n, err := strconv.Atoi(input)
+			if err != nil || n < 1 || n > len(arg.Enum) {
+				fmt.Fprintf(p.out, "Invalid selection %q\n", input)
+				continue
+			}
+			input = arg.Enum[n-1]
+		}
+		err = assignString(argVal, input)
+		if err != nil {
+			fmt.Fprintf(p.out, "Invalid value: %s\n", err)
+			continue
+		}
+		return nil
+	}
+}
+
+func (p *argPrompt) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return line, err
+}
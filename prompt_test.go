@@ -0,0 +1,97 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setArgPrompt replaces newTerminalArgPrompt for the duration of the test
+func setArgPrompt(t *testing.T, prompt *argPrompt) {
+	orig := newTerminalArgPrompt
+	newTerminalArgPrompt = func() *argPrompt { return prompt }
+	t.Cleanup(func() { newTerminalArgPrompt = orig })
+}
+
+type promptTestArgsDef struct {
+	ArgsDef
+
+	Count    int    `arg:"count" desc:"Number of items" required:"true"`
+	Color    string `arg:"color" required:"true" enum:"red,green,blue" default:"green"`
+	Password string `arg:"password" required:"true" secret:"true"`
+}
+
+func Test_PromptMissingArgs(t *testing.T) {
+	var (
+		args   promptTestArgsDef
+		out    strings.Builder
+		called string
+	)
+	input := bufio.NewReader(strings.NewReader("NaN\n\n3\n7\nblue\n"))
+	setArgPrompt(t, &argPrompt{
+		in:         input,
+		out:        &out,
+		readSecret: func() (string, error) { return "p4ss word\n", nil },
+	})
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("cmd", "", func(count int, color, password string) {
+		called = strings.Join([]string{color, password}, "|")
+		assert.Equal(t, 3, count)
+	}, &args)
+
+	err := disp.Dispatch(context.Background(), "cmd")
+	assert.True(t, IsUsageError(err), "no prompt if not enabled")
+	assert.Empty(t, out.String())
+
+	var runOut strings.Builder
+	exitCode := (&Runner{Stdout: &runOut, Stderr: &runOut}).Run(context.Background(), disp, []string{"app", "cmd"})
+	assert.Equal(t, ExitSuccess, exitCode, "Runner enables prompt")
+	assert.Empty(t, runOut.String())
+	assert.Equal(t, "blue|p4ss word", called)
+	assert.Equal(t, `Number of items <int>: Invalid value: assignString(int, "NaN"): expected integer
+Number of items <int>: A value for <count> is required
+Number of items <int>: color:
+  1) red
+  2) green
+  3) blue
+Select 1-3 [green]: Invalid selection "7"
+color:
+  1) red
+  2) green
+  3) blue
+Select 1-3 [green]: password <string>: 
+`, out.String())
+
+	setArgPrompt(t, nil)
+	err = disp.Dispatch(withPromptMissingArgs(context.Background()), "cmd")
+	assert.True(t, IsUsageError(err), "no prompt without terminal")
+}
+
+type promptEnvTestArgsDef struct {
+	ArgsDef
+
+	Name string `arg:"name" required:"true" env:"PROMPT_TEST_NAME"`
+}
+
+func Test_PromptMissingArgs_Env(t *testing.T) {
+	var (
+		args   promptEnvTestArgsDef
+		out    strings.Builder
+		called bool
+	)
+	setArgPrompt(t, &argPrompt{in: bufio.NewReader(strings.NewReader("")), out: &out})
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("cmd", "", func(name string) {
+		called = true
+		assert.Equal(t, "", name)
+	}, &args)
+
+	t.Setenv("PROMPT_TEST_NAME", "")
+	err := disp.Dispatch(withPromptMissingArgs(context.Background()), "cmd")
+	assert.NoError(t, err)
+	assert.True(t, called)
+	assert.Empty(t, out.String(), "no prompt for empty but set env var")
+}
@@ -0,0 +1,498 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Query is a parsed result query expression that selects
+// and filters parts of the JSON representation of results.
+//
+// The query language is a simplified JSONPath/JMESPath:
+//
+//	name              object field "name"
+//	user.name         nested object field
+//	["first name"]    object field with special characters
+//	items[0]          array element, negative indices count from the end
+//	items[*].name     projection: "name" of every element
+//	items[?status==failed].name
+//	                  filter projection: "name" of every element
+//	                  with field "status" equal to "failed"
+//
+// Filters support the comparison operators ==, !=, <, <=, >, >=
+// with string, number, true, false, and null literals.
+// String literals can be quoted with ' or ".
+// A filter without operator like [?enabled] selects all
+// elements where the field is not null, false, or empty.
+// The current element is referenced as @ in filters: [?@>10].
+//
+// Projections return arrays with the results of the following
+// steps applied to every element, omitting null results.
+type Query struct {
+	expr  string
+	steps []queryStep
+}
+
+type queryStepKind int
+
+const (
+	queryField queryStepKind = iota
+	queryIndex
+	queryProject
+	queryFilter
+)
+
+type queryStep struct {
+	kind   queryStepKind
+	field  string
+	index  int
+	filter *queryFilterExpr
+}
+
+type queryFilterExpr struct {
+	path  []string
+	op    string
+	value interface{}
+}
+
+// ParseQuery parses a query expression,
+// an empty expression selects the whole result.
+func ParseQuery(expr string) (*Query, error) {
+	p := queryParser{expr: expr}
+	steps, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Query{expr: expr, steps: steps}, nil
+}
+
+// MustParseQuery parses a query expression and panics on errors
+func MustParseQuery(expr string) *Query {
+	q, err := ParseQuery(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// String returns the query expression
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Apply applies the query to the JSON representation of value.
+// The result consists of the types returned by encoding/json
+// when unmarshalling to interface{} with json.Number for numbers.
+func (q *Query) Apply(value interface{}) (interface{}, error) {
+	normalized, err := normalizeQueryValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return evalQuerySteps(normalized, q.steps), nil
+}
+
+// ApplyToResults applies the query to every result value
+// and returns the query results as new result values.
+func (q *Query) ApplyToResults(resultVals []reflect.Value) ([]reflect.Value, error) {
+	queried := make([]reflect.Value, len(resultVals))
+	for i, resultVal := range resultVals {
+		result, err := q.Apply(resultVal.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("can't apply query %q to result %d because: %w", q.expr, i, err)
+		}
+		if result == nil {
+			queried[i] = reflect.ValueOf(&result).Elem()
+		} else {
+			queried[i] = reflect.ValueOf(result)
+		}
+	}
+	return queried, nil
+}
+
+// WithQuery returns a ResultsHandler that applies query
+// to the result values before passing them to resultsHandlers.
+func WithQuery(query *Query, resultsHandlers ...ResultsHandler) ResultsHandlerFunc {
+	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		if resultErr == nil {
+			var err error
+			resultVals, err = query.ApplyToResults(resultVals)
+			if err != nil {
+				return err
+			}
+		}
+		for _, resultsHandler := range resultsHandlers {
+			err := resultsHandler.HandleResults(args, argVals, resultVals, resultErr)
+			if err != nil && err != resultErr {
+				return err
+			}
+		}
+		return resultErr
+	}
+}
+
+func normalizeQueryValue(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var normalized interface{}
+	err = decoder.Decode(&normalized)
+	if err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func evalQuerySteps(value interface{}, steps []queryStep) interface{} {
+	if len(steps) == 0 || value == nil {
+		return value
+	}
+	step, rest := steps[0], steps[1:]
+	switch step.kind {
+	case queryField:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		return evalQuerySteps(obj[step.field], rest)
+
+	case queryIndex:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		index := step.index
+		if index < 0 {
+			index += len(arr)
+		}
+		if index < 0 || index >= len(arr) {
+			return nil
+		}
+		return evalQuerySteps(arr[index], rest)
+
+	case queryProject, queryFilter:
+		arr, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		projected := make([]interface{}, 0, len(arr))
+		for _, elem := range arr {
+			if step.kind == queryFilter && !step.filter.match(elem) {
+				continue
+			}
+			result := evalQuerySteps(elem, rest)
+			if result != nil {
+				projected = append(projected, result)
+			}
+		}
+		return projected
+	}
+	return nil
+}
+
+func (f *queryFilterExpr) match(elem interface{}) bool {
+	value := elem
+	for _, field := range f.path {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			value = nil
+			break
+		}
+		value = obj[field]
+	}
+
+	if f.op == "" {
+		switch v := value.(type) {
+		case nil:
+			return false
+		case bool:
+			return v
+		case string:
+			return v != ""
+		case []interface{}:
+			return len(v) > 0
+		case map[string]interface{}:
+			return len(v) > 0
+		}
+		return true
+	}
+
+	cmp, comparable := compareQueryValues(value, f.value)
+	switch f.op {
+	case "==":
+		return comparable && cmp == 0
+	case "!=":
+		return !comparable || cmp != 0
+	case "<":
+		return comparable && cmp < 0
+	case "<=":
+		return comparable && cmp <= 0
+	case ">":
+		return comparable && cmp > 0
+	case ">=":
+		return comparable && cmp >= 0
+	}
+	return false
+}
+
+// compareQueryValues compares numbers, strings, booleans, and nulls
+func compareQueryValues(a, b interface{}) (cmp int, comparable bool) {
+	switch av := a.(type) {
+	case nil:
+		return 0, b == nil
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return 0, false
+		}
+		af, errA := av.Float64()
+		bf, errB := bv.Float64()
+		if errA != nil || errB != nil {
+			return 0, false
+		}
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(av, bv), true
+	case bool:
+		bv, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av == bv:
+			return 0, true
+		case !av:
+			return -1, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+type queryParser struct {
+	expr string
+	pos  int
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid query %q at position %d: %s", p.expr, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *queryParser) parse() (steps []queryStep, err error) {
+	p.skipSpace()
+	if strings.HasPrefix(p.expr[p.pos:], "$") {
+		p.pos++
+	}
+	for p.pos < len(p.expr) {
+		switch c := p.expr[p.pos]; {
+		case c == '.':
+			p.pos++
+			if p.pos >= len(p.expr) {
+				return nil, p.errorf("expected field name after '.'")
+			}
+			if p.expr[p.pos] == '[' {
+				continue
+			}
+			name := p.identifier()
+			if name == "" {
+				return nil, p.errorf("expected field name after '.'")
+			}
+			steps = append(steps, queryStep{kind: queryField, field: name})
+
+		case c == '[':
+			step, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+
+		case isQueryIdentChar(c) && len(steps) == 0:
+			steps = append(steps, queryStep{kind: queryField, field: p.identifier()})
+
+		default:
+			return nil, p.errorf("unexpected character %q", c)
+		}
+		p.skipSpace()
+	}
+	return steps, nil
+}
+
+func (p *queryParser) bracket() (step queryStep, err error) {
+	p.pos++ // skip '['
+	p.skipSpace()
+	if p.pos >= len(p.expr) {
+		return step, p.errorf("missing ']'")
+	}
+	switch c := p.expr[p.pos]; {
+	case c == ']':
+		step = queryStep{kind: queryProject}
+
+	case c == '*':
+		p.pos++
+		step = queryStep{kind: queryProject}
+
+	case c == '?':
+		p.pos++
+		filter, err := p.filter()
+		if err != nil {
+			return step, err
+		}
+		step = queryStep{kind: queryFilter, filter: filter}
+
+	case c == '\'' || c == '"':
+		name, err := p.quoted()
+		if err != nil {
+			return step, err
+		}
+		step = queryStep{kind: queryField, field: name}
+
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9' {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.expr[start:p.pos])
+		if err != nil {
+			p.pos = start
+			return step, p.errorf("invalid index")
+		}
+		step = queryStep{kind: queryIndex, index: index}
+
+	default:
+		return step, p.errorf("unexpected character %q in brackets", c)
+	}
+	p.skipSpace()
+	if p.pos >= len(p.expr) || p.expr[p.pos] != ']' {
+		return step, p.errorf("missing ']'")
+	}
+	p.pos++
+	return step, nil
+}
+
+func (p *queryParser) filter() (*queryFilterExpr, error) {
+	p.skipSpace()
+	filter := new(queryFilterExpr)
+	if p.pos < len(p.expr) && p.expr[p.pos] == '@' {
+		p.pos++
+	} else {
+		for {
+			name := p.identifier()
+			if name == "" {
+				return nil, p.errorf("expected field name in filter")
+			}
+			filter.path = append(filter.path, name)
+			if p.pos >= len(p.expr) || p.expr[p.pos] != '.' {
+				break
+			}
+			p.pos++
+		}
+	}
+	p.skipSpace()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(p.expr[p.pos:], op) {
+			filter.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if filter.op == "" {
+		return filter, nil
+	}
+	p.skipSpace()
+	value, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	filter.value = value
+	return filter, nil
+}
+
+func (p *queryParser) literal() (interface{}, error) {
+	if p.pos >= len(p.expr) {
+		return nil, p.errorf("expected value")
+	}
+	if c := p.expr[p.pos]; c == '\'' || c == '"' {
+		return p.quoted()
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(" ]", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	word := p.expr[start:p.pos]
+	switch word {
+	case "":
+		return nil, p.errorf("expected value")
+	case "null":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if _, err := strconv.ParseFloat(word, 64); err == nil {
+		return json.Number(word), nil
+	}
+	return word, nil
+}
+
+func (p *queryParser) quoted() (string, error) {
+	quote := p.expr[p.pos]
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.expr) {
+		c := p.expr[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.expr):
+			p.pos++
+			b.WriteByte(p.expr[p.pos])
+		default:
+			b.WriteByte(c)
+		}
+		p.pos++
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+func (p *queryParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.expr) && isQueryIdentChar(p.expr[p.pos]) {
+		p.pos++
+	}
+	return p.expr[start:p.pos]
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.expr) && p.expr[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func isQueryIdentChar(c byte) bool {
+	return c == '_' || c == '-' || c == '$' ||
+		(c >= 'a' && c <= 'z') ||
+		(c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9') ||
+		c >= 0x80
+}
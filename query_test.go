@@ -0,0 +1,73 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Query(t *testing.T) {
+	type item struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Count  int    `json:"count"`
+	}
+	value := map[string]interface{}{
+		"items": []item{
+			{Name: "a", Status: "ok", Count: 1},
+			{Name: "b", Status: "failed", Count: 2},
+			{Name: "c", Status: "failed", Count: 3},
+		},
+		"user": map[string]string{"first name": "Erik"},
+	}
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{expr: "", want: value},
+		{expr: "items[?status==failed].name", want: []interface{}{"b", "c"}},
+		{expr: "items[?status!='failed'].name", want: []interface{}{"a"}},
+		{expr: "items[?count>=2].count", want: []interface{}{json.Number("2"), json.Number("3")}},
+		{expr: "items[*].name", want: []interface{}{"a", "b", "c"}},
+		{expr: "items[-1].name", want: "c"},
+		{expr: "items[5]", want: nil},
+		{expr: `user["first name"]`, want: "Erik"},
+		{expr: "$.missing.field", want: nil},
+		{expr: " $.items[0].name", want: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			want, err := normalizeQueryValue(tt.want)
+			assert.NoError(t, err)
+			got, err := MustParseQuery(tt.expr).Apply(value)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	for _, expr := range []string{"items[", "items[?]", "items.", "items[?status==]", "items[x]"} {
+		_, err := ParseQuery(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func Test_StringArgsDispatcher_QueryFlag(t *testing.T) {
+	var (
+		commandArgsDef TestCommandArgsDef
+		buf            bytes.Buffer
+	)
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("struct", "", CommandFuncStructResult, &commandArgsDef, PrintlnTo(&buf))
+	disp.EnableQueryFlag()
+
+	err := disp.Dispatch(context.Background(), "struct", "1", "str", "true", "--query=ResultMessage")
+	assert.NoError(t, err)
+	assert.Equal(t, "not found\n", buf.String())
+
+	err = disp.Dispatch(context.Background(), "struct", "1", "str", "true", "--query", "[")
+	assert.Error(t, err, "invalid query")
+}
@@ -0,0 +1,98 @@
+package command
+
+import (
+	"sort"
+	"strings"
+)
+
+// commandRegistry is a snapshot of the commands and aliases
+// of a StringArgsDispatcher that is never changed after
+// it has been stored in the dispatcher.
+// Changes are made to a clone that replaces the stored snapshot,
+// so dispatching is never blocked by adding or removing commands.
+type commandRegistry struct {
+	comm    map[string]*stringArgsCommand
+	aliases map[string]*stringArgsCommand
+}
+
+func newCommandRegistry() *commandRegistry {
+	return &commandRegistry{
+		comm:    make(map[string]*stringArgsCommand),
+		aliases: make(map[string]*stringArgsCommand),
+	}
+}
+
+func (reg *commandRegistry) clone() *commandRegistry {
+	c := &commandRegistry{
+		comm:    make(map[string]*stringArgsCommand, len(reg.comm)),
+		aliases: make(map[string]*stringArgsCommand, len(reg.aliases)),
+	}
+	for name, cmd := range reg.comm {
+		c.comm[name] = cmd
+	}
+	for alias, cmd := range reg.aliases {
+		c.aliases[alias] = cmd
+	}
+	return c
+}
+
+// setCommand adds or replaces cmd
+// and points the aliases of cmd to it.
+func (reg *commandRegistry) setCommand(cmd *stringArgsCommand) {
+	reg.comm[cmd.command] = cmd
+	for _, alias := range cmd.aliases {
+		reg.aliases[alias] = cmd
+	}
+}
+
+// removeCommand removes the command with its aliases
+func (reg *commandRegistry) removeCommand(cmd *stringArgsCommand) {
+	delete(reg.comm, cmd.command)
+	for _, alias := range cmd.aliases {
+		delete(reg.aliases, alias)
+	}
+}
+
+// commandNames returns the sorted names and aliases
+// of all commands except Default starting with prefix.
+func (reg *commandRegistry) commandNames(prefix string) []string {
+	var names []string
+	for command := range reg.comm {
+		if command != Default && strings.HasPrefix(command, prefix) {
+			names = append(names, command)
+		}
+	}
+	for alias := range reg.aliases {
+		if strings.HasPrefix(alias, prefix) {
+			names = append(names, alias)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookup returns the command registered with the name
+// or alias command, or if prefixMatching is true,
+// the command with a name or alias uniquely starting with command.
+func (reg *commandRegistry) lookup(command string, prefixMatching bool) (cmd *stringArgsCommand, found bool) {
+	if cmd, found = reg.comm[command]; found {
+		return cmd, true
+	}
+	if cmd, found = reg.aliases[command]; found {
+		return cmd, true
+	}
+	if !prefixMatching || command == Default || command == HelpCommand || command == CompleteCommand {
+		return nil, false
+	}
+	for _, name := range reg.commandNames(command) {
+		match, ok := reg.comm[name]
+		if !ok {
+			match = reg.aliases[name]
+		}
+		if cmd != nil && cmd != match {
+			return nil, false
+		}
+		cmd = match
+	}
+	return cmd, cmd != nil
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StringArgsDispatcher_ReplaceRemoveCommand(t *testing.T) {
+	var calls []string
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("remove", "Removes", func() { calls = append(calls, "remove v1") }, new(struct{ ArgsDef }))
+	disp.MustAddAlias("remove", "rm")
+
+	err := disp.ReplaceCommand("unknown", "", func() {}, new(struct{ ArgsDef }))
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	disp.MustReplaceCommand("remove", "Removes", func() { calls = append(calls, "remove v2") }, new(struct{ ArgsDef }))
+	assert.NoError(t, disp.Dispatch(context.Background(), "rm"))
+	assert.Equal(t, []string{"remove v2"}, calls)
+
+	disp.MustRemoveCommand("remove")
+	assert.False(t, disp.HasCommnd("remove"))
+	assert.True(t, errors.Is(disp.Dispatch(context.Background(), "rm"), ErrNotFound))
+	assert.True(t, errors.Is(disp.RemoveCommand("remove"), ErrNotFound))
+
+	// Failed alias changes are not applied partially
+	disp.MustAddCommand("list", "Lists", func() {}, new(struct{ ArgsDef }))
+	assert.Error(t, disp.AddAlias("list", "ls", "list"))
+	_, found := disp.lookup("ls")
+	assert.False(t, found)
+
+	reloaded := NewStringArgsDispatcher()
+	reloaded.MustAddCommand("status", "Status", func() {}, new(struct{ ArgsDef }))
+	disp.ReplaceCommands(reloaded)
+	assert.True(t, disp.HasCommnd("status"))
+	assert.False(t, disp.HasCommnd("list"))
+	// Changes of one dispatcher don't affect the other
+	disp.MustAddCommand("list", "Lists", func() {}, new(struct{ ArgsDef }))
+	assert.False(t, reloaded.HasCommnd("list"))
+}
+
+func Test_StringArgsDispatcher_concurrentChanges(t *testing.T) {
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("stable", "", func() {}, new(struct{ ArgsDef }))
+	super := NewSuperStringArgsDispatcher()
+	super.MustAddSuperCommand("stable").MustAddCommand("cmd", "", func() {}, new(struct{ ArgsDef }))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("cmd%d_%d", i, j)
+				disp.MustAddCommand(name, "", func() {}, new(struct{ ArgsDef }))
+				disp.MustAddAlias(name, "alias_"+name)
+				disp.MustReplaceCommand(name, "", func() {}, new(struct{ ArgsDef }))
+				disp.MustRemoveCommand(name)
+				super.MustAddSuperCommand(name)
+				super.MustRemoveSuperCommand(name)
+			}
+		}(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.NoError(t, disp.Dispatch(context.Background(), "stable"))
+				_, _, err := super.DispatchCombinedCommandAndArgs(context.Background(), []string{"stable", "cmd"})
+				assert.NoError(t, err)
+				disp.PrintCommandsTo(io.Discard, "")
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"stable"}, disp.commandNames(""))
+}
@@ -0,0 +1,423 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ungerik/go-reflection"
+	"golang.org/x/term"
+)
+
+// resultsRecorderKey is the context key for a func([]reflect.Value)
+// that is called with the results of successful command function calls.
+type resultsRecorderKey struct{}
+
+// CommandLineCompleter is implemented by StringArgsDispatcher,
+// SuperStringArgsDispatcher, and CommandTree to complete command lines.
+type CommandLineCompleter interface {
+	// Complete returns the completion candidates for the last
+	// of the command line words, which may be an empty string.
+	Complete(ctx context.Context, words []string) ([]string, error)
+}
+
+// REPLRunner runs read-eval-print loops, see REPL
+type REPLRunner struct {
+	// Prompt is printed before reading a line
+	// from a terminal, defaults to "> "
+	Prompt string
+	// HistoryFile is the path of a file where entered lines
+	// are appended and loaded from at start.
+	// An empty string disables the history file.
+	HistoryFile string
+	// MaxHistory is the maximum number of history lines
+	// loaded from HistoryFile, defaults to 1000
+	MaxHistory int
+}
+
+// REPL runs a read-eval-print loop that reads command lines from in
+// and dispatches them to disp until in ends, the line "exit" or "quit"
+// is entered, or ctx is canceled.
+//
+// Lines are split into words with SplitCommandLine.
+// Errors are written to out without ending the loop.
+// The result values of commands are numbered and written to out
+// like "$1 = value" and can be referenced as arguments of
+// following commands like $1 or "${1}".
+// The registered ResultsHandler of commands are called as usual.
+// If disp does not handle the HelpCommand,
+// then the usage of all commands is written to out.
+//
+// If in is a terminal, then a prompt is printed,
+// lines can be edited with history and tab completion
+// if disp implements CommandLineCompleter,
+// and Ctrl-C cancels the context of a running command.
+//
+// Use REPLRunner to configure the prompt and a history file.
+func REPL(ctx context.Context, disp CommandLineDispatcher, in io.Reader, out io.Writer) error {
+	var runner REPLRunner
+	return runner.Run(ctx, disp, in, out)
+}
+
+// Run runs a read-eval-print loop, see the REPL function for details.
+func (r *REPLRunner) Run(ctx context.Context, disp CommandLineDispatcher, in io.Reader, out io.Writer) error {
+	prompt := r.Prompt
+	if prompt == "" {
+		prompt = "> "
+	}
+	history, err := r.loadHistory()
+	if err != nil {
+		return err
+	}
+
+	var reader interface {
+		readLine(prompt string) (string, error)
+	}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		editor := &lineEditor{in: f, reader: bufio.NewReader(f), out: out, history: history}
+		if completer, ok := disp.(CommandLineCompleter); ok {
+			editor.complete = func(line string) (current string, candidates []string) {
+				return completeLine(ctx, completer, line)
+			}
+		}
+		reader = editor
+	} else {
+		reader = plainLineReader{bufio.NewReader(in)}
+	}
+
+	var results []string
+	expand := func(name string) (string, error) {
+		n, err := strconv.Atoi(name)
+		if err != nil || n < 1 || n > len(results) {
+			return "", fmt.Errorf("unknown result reference $%s", name)
+		}
+		return results[n-1], nil
+	}
+
+	for ctx.Err() == nil {
+		line, err := reader.readLine(prompt)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			continue
+		case "exit", "quit":
+			return nil
+		}
+		err = r.appendHistory(line)
+		if err != nil {
+			fmt.Fprintf(out, "Error: %s\n", err)
+		}
+
+		args, err := splitCommandLine(line, expand)
+		if err != nil {
+			fmt.Fprintf(out, "Error: %s\n", err)
+			continue
+		}
+
+		var recorded []reflect.Value
+		callCtx := context.WithValue(ctx, resultsRecorderKey{}, func(resultVals []reflect.Value) {
+			recorded = append(recorded, resultVals...)
+		})
+		callCtx, stop := signal.NotifyContext(callCtx, os.Interrupt)
+		err = disp.DispatchCommandLineArgs(callCtx, args)
+		stop()
+		switch {
+		case errors.Is(err, ErrNotFound) && len(args) == 1 && args[0] == HelpCommand:
+			disp.PrintCommandsTo(out, "")
+			continue
+		case err != nil:
+			fmt.Fprintf(out, "Error: %s\n", err)
+			continue
+		}
+
+		for _, resultVal := range recorded {
+			result, ok := replResultString(resultVal)
+			if !ok {
+				continue
+			}
+			results = append(results, result)
+			fmt.Fprintf(out, "$%d = %s\n", len(results), result)
+		}
+	}
+	return ctx.Err()
+}
+
+func (r *REPLRunner) loadHistory() ([]string, error) {
+	if r.HistoryFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(r.HistoryFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	maxHistory := r.MaxHistory
+	if maxHistory <= 0 {
+		maxHistory = 1000
+	}
+	history := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	return history, nil
+}
+
+func (r *REPLRunner) appendHistory(line string) error {
+	if r.HistoryFile == "" {
+		return nil
+	}
+	file, err := os.OpenFile(r.HistoryFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(file, line)
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// replResultString returns a result value as string
+// that can be used as command argument.
+// Strings are returned as is, structs, maps,
+// slices, and arrays are formatted as JSON.
+// Streams and readers are not returned
+// because they are consumed by ResultsHandler.
+func replResultString(resultVal reflect.Value) (string, bool) {
+	result := resultVal.Interface()
+	switch r := result.(type) {
+	case *ResultStream, io.Reader:
+		return "", false
+	case string:
+		return r, true
+	case []byte:
+		return string(r), true
+	}
+	switch reflection.DerefValue(resultVal).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		b, err := json.Marshal(result)
+		if err == nil {
+			return string(b), true
+		}
+	}
+	return fmt.Sprint(result), true
+}
+
+// completeLine returns the completion candidates
+// for the last word of line and that word.
+func completeLine(ctx context.Context, completer CommandLineCompleter, line string) (current string, candidates []string) {
+	words, err := SplitCommandLine(line)
+	if err != nil {
+		words = strings.Fields(line)
+	}
+	if len(words) == 0 || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	current = words[len(words)-1]
+	candidates, err = completer.Complete(ctx, words)
+	if err != nil {
+		return current, nil
+	}
+	return current, candidates
+}
+
+// plainLineReader reads lines from a reader that is not a terminal
+type plainLineReader struct {
+	reader *bufio.Reader
+}
+
+func (r plainLineReader) readLine(prompt string) (string, error) {
+	line, err := r.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// lineEditor reads lines from a terminal in raw mode
+// with line editing, history, and tab completion
+type lineEditor struct {
+	in       *os.File
+	reader   *bufio.Reader
+	out      io.Writer
+	history  []string
+	complete func(line string) (current string, candidates []string)
+}
+
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	fd := int(e.in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	var (
+		buf     []rune
+		pos     int
+		histPos = len(e.history)
+		edited  []rune
+	)
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	insert := func(s string) {
+		r := []rune(s)
+		buf = append(buf[:pos], append(r, buf[pos:]...)...)
+		pos += len(r)
+	}
+	showHistory := func(index int) {
+		if histPos == len(e.history) {
+			edited = buf
+		}
+		histPos = index
+		if histPos == len(e.history) {
+			buf = edited
+		} else {
+			buf = []rune(e.history[histPos])
+		}
+		pos = len(buf)
+	}
+
+	redraw()
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			line := string(buf)
+			if strings.TrimSpace(line) != "" {
+				e.history = append(e.history, line)
+			}
+			return line, nil
+
+		case 3: // Ctrl-C discards the line
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", nil
+
+		case 4: // Ctrl-D ends input on an empty line
+			if len(buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+
+		case 127, 8: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+
+		case 1: // Ctrl-A
+			pos = 0
+
+		case 5: // Ctrl-E
+			pos = len(buf)
+
+		case 21: // Ctrl-U
+			buf = append([]rune(nil), buf[pos:]...)
+			pos = 0
+
+		case '\t':
+			if e.complete == nil {
+				continue
+			}
+			current, candidates := e.complete(string(buf[:pos]))
+			candidates = filterPrefix(candidates, current)
+			switch len(candidates) {
+			case 0:
+			case 1:
+				insert(strings.TrimPrefix(candidates[0], current))
+				if !strings.HasSuffix(candidates[0], "/") {
+					insert(" ")
+				}
+			default:
+				if prefix := commonPrefix(candidates); len(prefix) > len(current) {
+					insert(strings.TrimPrefix(prefix, current))
+				} else {
+					fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+				}
+			}
+
+		case 27: // Escape sequences of cursor keys
+			if next, _, _ := e.reader.ReadRune(); next != '[' {
+				continue
+			}
+			key, _, _ := e.reader.ReadRune()
+			switch key {
+			case 'A': // Up
+				if histPos > 0 {
+					showHistory(histPos - 1)
+				}
+			case 'B': // Down
+				if histPos < len(e.history) {
+					showHistory(histPos + 1)
+				}
+			case 'C': // Right
+				if pos < len(buf) {
+					pos++
+				}
+			case 'D': // Left
+				if pos > 0 {
+					pos--
+				}
+			case 'H': // Home
+				pos = 0
+			case 'F': // End
+				pos = len(buf)
+			case '3': // Delete
+				if tilde, _, _ := e.reader.ReadRune(); tilde == '~' && pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+
+		default:
+			if r >= ' ' {
+				insert(string(r))
+			}
+		}
+		redraw()
+	}
+}
+
+func commonPrefix(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	prefix := []rune(list[0])
+	for _, s := range list[1:] {
+		i := 0
+		for _, r := range s {
+			if i == len(prefix) || r != prefix[i] {
+				break
+			}
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	return string(prefix)
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_REPL(t *testing.T) {
+	var (
+		args helpTestArgsDef
+		out  strings.Builder
+	)
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("greet", "", func(name, greet string) string { return greet + " " + name }, &args)
+	disp.MustAddCommand("count", "", CommandFuncChanResult, new(struct {
+		ArgsDef
+		Count int `arg:"count"`
+	}))
+
+	in := strings.NewReader(`greet "Erik Unger"
+greet World Hi
+greet "$2 ($1)" Hi
+greet $3 "${9}"
+count 3
+unknown
+help
+exit
+greet ignored
+`)
+	runner := REPLRunner{HistoryFile: filepath.Join(t.TempDir(), "history")}
+	err := runner.Run(context.Background(), disp, in, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, `$1 = Hello Erik Unger
+$2 = Hi World
+$3 = Hi Hi World (Hello Erik Unger)
+Error: unknown result reference $9 at column 11 of command line: greet $3 "${9}"
+Error: command 'unknown' not found
+  count <count:int>
+
+  greet <name:string> <greet:string>
+          <name:string> Name to greet
+          <greet:string> 
+
+`, out.String())
+
+	history, err := os.ReadFile(runner.HistoryFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, strings.Count(string(history), "\n"))
+}
+
+func Test_commonPrefix(t *testing.T) {
+	assert.Equal(t, "", commonPrefix(nil))
+	assert.Equal(t, "deploy", commonPrefix([]string{"deploy"}))
+	assert.Equal(t, "de", commonPrefix([]string{"deploy", "delete", "describe"}))
+	assert.Equal(t, "", commonPrefix([]string{"deploy", "list"}))
+	assert.Equal(t, "grü", commonPrefix([]string{"grün", "grüß"}), "multi byte runes")
+	assert.Equal(t, "", commonPrefix([]string{"ä", "ö"}), "runes with the same first byte")
+}
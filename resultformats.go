@@ -0,0 +1,536 @@
+package command
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/domonda/go-types/nullable"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	typeOfTime         = reflect.TypeOf(time.Time{})
+	typeOfNullableTime = reflect.TypeOf(nullable.Time{})
+	typeOfNamedResults = reflect.TypeOf(NamedResultValues{})
+
+	typeOfTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	typeOfJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	typeOfStringer      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// PrintTable prints the results as table with aligned columns to writer
+// using text/tabwriter.
+//
+// Every element of slice, array, and ResultStream results is a table row,
+// other results are printed as single row.
+// The columns are the exported fields of struct rows named by ResultColumnTag
+// or the field name with nested struct fields flattened as "Parent.Field",
+// the sorted keys of map rows, or a single column "value" for other types.
+// If columns are passed, then only those columns are printed in that order,
+// else the columns of the first row are used.
+func PrintTable(writer io.Writer, columns ...string) ResultsHandlerFunc {
+	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		if resultErr != nil {
+			return resultErr
+		}
+		tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		rows := newResultRows(columns)
+		err := rows.forEach(resultVals, func(record reflect.Value) error {
+			cols, err := rows.columnsFor(record)
+			if err != nil {
+				return err
+			}
+			if rows.count == 0 {
+				_, err = fmt.Fprintln(tw, strings.Join(cols, "\t"))
+				if err != nil {
+					return err
+				}
+			}
+			_, err = fmt.Fprintln(tw, strings.Join(rows.cells(record), "\t"))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return tw.Flush()
+	}
+}
+
+// PrintCSV writes the results as CSV with a header row to writer.
+// Rows and columns are determined like with PrintTable.
+// The elements of ResultStream results are written as soon as they are received.
+func PrintCSV(writer io.Writer, columns ...string) ResultsHandlerFunc {
+	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		if resultErr != nil {
+			return resultErr
+		}
+		w := csv.NewWriter(writer)
+		rows := newResultRows(columns)
+		err := rows.forEach(resultVals, func(record reflect.Value) error {
+			cols, err := rows.columnsFor(record)
+			if err != nil {
+				return err
+			}
+			if rows.count == 0 {
+				err = w.Write(cols)
+				if err != nil {
+					return err
+				}
+			}
+			err = w.Write(rows.cells(record))
+			if err != nil {
+				return err
+			}
+			w.Flush()
+			return w.Error()
+		})
+		if err != nil {
+			return err
+		}
+		w.Flush()
+		return w.Error()
+	}
+}
+
+// PrintNDJSON writes every result as one line of JSON to writer.
+// Every element of slice, array, and ResultStream results is written
+// as separate line as soon as it is available.
+// If columns are passed, then only those columns of the rows
+// are written as JSON object like with PrintTable.
+func PrintNDJSON(writer io.Writer, columns ...string) ResultsHandlerFunc {
+	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		if resultErr != nil {
+			return resultErr
+		}
+		rows := newResultRows(columns)
+		return rows.forEach(resultVals, func(record reflect.Value) error {
+			line, err := rows.marshalJSON(record)
+			if err != nil {
+				return err
+			}
+			_, err = writer.Write(append(line, '\n'))
+			return err
+		})
+	}
+}
+
+// PrintYAML writes the results as YAML to writer,
+// multiple results are written as separate YAML documents.
+// Values are converted like their JSON representation
+// so that types like time.Time and nullable types
+// are formatted consistently with the JSON output.
+// If columns are passed, then only those columns of the rows
+// are written like with PrintTable.
+func PrintYAML(writer io.Writer, columns ...string) ResultsHandlerFunc {
+	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		if resultErr != nil {
+			return resultErr
+		}
+		rows := newResultRows(columns)
+		for i, resultVal := range resultVals {
+			if i > 0 {
+				_, err := io.WriteString(writer, "---\n")
+				if err != nil {
+					return err
+				}
+			}
+			if !isResultRowList(resultVal) {
+				err := writeYAML(writer, rows, resultVal, false)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			numRows := 0
+			err := rows.forEach([]reflect.Value{resultVal}, func(record reflect.Value) error {
+				numRows++
+				return writeYAML(writer, rows, record, true)
+			})
+			if err != nil {
+				return err
+			}
+			if numRows == 0 {
+				_, err = io.WriteString(writer, "[]\n")
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func writeYAML(writer io.Writer, rows *resultRows, record reflect.Value, asListItem bool) error {
+	j, err := rows.marshalJSON(record)
+	if err != nil {
+		return err
+	}
+	// Parsing JSON as YAML node keeps the order of object keys
+	var doc yaml.Node
+	err = yaml.Unmarshal(j, &doc)
+	if err != nil {
+		return err
+	}
+	node := doc.Content[0]
+	resetYAMLStyle(node)
+	if asListItem {
+		node = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{node}}
+	}
+	y, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(y)
+	return err
+}
+
+// resetYAMLStyle resets the flow and quoting style of nodes parsed from JSON
+// so that they are written in YAML block style.
+func resetYAMLStyle(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		// Keep quotes for strings that would be
+		// parsed as another type without quotes
+		var v interface{}
+		if yaml.Unmarshal([]byte(node.Value), &v) == nil {
+			if _, isString := v.(string); isString {
+				node.Style = 0
+			}
+		}
+	} else {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// isResultRowList returns if the elements of result
+// are handled as separate rows.
+func isResultRowList(result reflect.Value) bool {
+	if _, isStream := result.Interface().(*ResultStream); isStream {
+		return true
+	}
+	result = derefResult(result)
+	switch result.Kind() {
+	case reflect.Slice, reflect.Array:
+		return result.Type().Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+func derefResult(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// maxNestedColumnDepth limits the flattening of nested structs to columns
+const maxNestedColumnDepth = 8
+
+// resultRows iterates over result values as rows
+// and formats them in columns.
+type resultRows struct {
+	selected []string
+	columns  []string
+	count    int
+	// structColumns caches the field index paths
+	// of the columns of struct types
+	structColumns map[reflect.Type]map[string][]int
+}
+
+func newResultRows(columns []string) *resultRows {
+	return &resultRows{
+		selected:      columns,
+		structColumns: make(map[reflect.Type]map[string][]int),
+	}
+}
+
+// forEach calls f for every row of the results.
+func (rows *resultRows) forEach(resultVals []reflect.Value, f func(record reflect.Value) error) error {
+	each := func(record reflect.Value) error {
+		err := f(record)
+		if err != nil {
+			return err
+		}
+		rows.count++
+		return nil
+	}
+	for _, resultVal := range resultVals {
+		if stream, ok := resultVal.Interface().(*ResultStream); ok {
+			err := stream.Range(each)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if isResultRowList(resultVal) {
+			list := derefResult(resultVal)
+			for i := 0; i < list.Len(); i++ {
+				err := each(list.Index(i))
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		err := each(resultVal)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnsFor returns the selected columns or the columns
+// of the first record and checks if the selected columns
+// are available in record.
+func (rows *resultRows) columnsFor(record reflect.Value) ([]string, error) {
+	if rows.columns != nil {
+		return rows.columns, nil
+	}
+	available := rows.recordColumns(record)
+	if len(rows.selected) == 0 {
+		rows.columns = available
+		return rows.columns, nil
+	}
+	for _, col := range rows.selected {
+		found := false
+		for _, a := range available {
+			if a == col {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown result column %q, available columns: %s", col, strings.Join(available, ", "))
+		}
+	}
+	rows.columns = rows.selected
+	return rows.columns, nil
+}
+
+func (rows *resultRows) recordColumns(record reflect.Value) []string {
+	record = derefResult(record)
+	switch {
+	case record.Type() == typeOfNamedResults:
+		return record.Interface().(NamedResultValues).Names()
+
+	case isRecordStruct(record.Type()):
+		return rows.structColumnNames(record.Type())
+
+	case record.Kind() == reflect.Map:
+		keys := make([]string, 0, record.Len())
+		iter := record.MapRange()
+		for iter.Next() {
+			keys = append(keys, fmt.Sprint(iter.Key().Interface()))
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	return []string{"value"}
+}
+
+// isRecordStruct returns if t is a struct type
+// whose fields are used as columns.
+func isRecordStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == typeOfTime || t == typeOfNullableTime {
+		return false
+	}
+	p := reflect.PtrTo(t)
+	return !p.Implements(typeOfTextMarshaler) && !p.Implements(typeOfJSONMarshaler) && !p.Implements(typeOfStringer)
+}
+
+func (rows *resultRows) structColumnNames(t reflect.Type) []string {
+	var names []string
+	visitStructColumns(t, "", nil, func(name string, index []int) {
+		names = append(names, name)
+	})
+	return names
+}
+
+func (rows *resultRows) structColumnIndex(t reflect.Type) map[string][]int {
+	if index, ok := rows.structColumns[t]; ok {
+		return index
+	}
+	index := make(map[string][]int)
+	visitStructColumns(t, "", nil, func(name string, i []int) {
+		index[name] = i
+	})
+	rows.structColumns[t] = index
+	return index
+}
+
+// visitStructColumns calls visit with the column name and field index path
+// of every exported field of the struct type t.
+// Fields of embedded structs are visited without and fields
+// of nested structs with the name of the parent field as prefix.
+func visitStructColumns(t reflect.Type, prefix string, parentIndex []int, visit func(name string, index []int)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int(nil), parentIndex...), i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		// Don't flatten recursive types
+		flatten := isRecordStruct(fieldType) && fieldType != t
+		if field.Anonymous && flatten {
+			visitStructColumns(fieldType, prefix, index, visit)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup(ResultColumnTag); ok {
+			if pos := strings.IndexByte(tag, ','); pos != -1 {
+				tag = tag[:pos]
+			}
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		if flatten && len(parentIndex) < maxNestedColumnDepth {
+			visitStructColumns(fieldType, prefix+name+".", index, visit)
+			continue
+		}
+		visit(prefix+name, index)
+	}
+}
+
+// cellValue returns the value of column in record
+// or an invalid reflect.Value if not available.
+func (rows *resultRows) cellValue(record reflect.Value, column string) reflect.Value {
+	record = derefResult(record)
+	switch {
+	case record.Type() == typeOfNamedResults:
+		named := record.Interface().(NamedResultValues)
+		for i, name := range named.Names() {
+			if name == column {
+				return named.Values[i]
+			}
+		}
+		return reflect.Value{}
+
+	case isRecordStruct(record.Type()):
+		index, ok := rows.structColumnIndex(record.Type())[column]
+		if !ok {
+			return reflect.Value{}
+		}
+		v := record
+		for _, i := range index {
+			v = derefResult(v)
+			if v.Kind() != reflect.Struct {
+				// nil pointer to struct
+				return reflect.Value{}
+			}
+			v = v.Field(i)
+		}
+		return v
+
+	case record.Kind() == reflect.Map:
+		iter := record.MapRange()
+		for iter.Next() {
+			if fmt.Sprint(iter.Key().Interface()) == column {
+				return iter.Value()
+			}
+		}
+		return reflect.Value{}
+	}
+	if column == "value" {
+		return record
+	}
+	return reflect.Value{}
+}
+
+func (rows *resultRows) cells(record reflect.Value) []string {
+	cells := make([]string, len(rows.columns))
+	for i, column := range rows.columns {
+		cells[i] = formatResultCell(rows.cellValue(record, column))
+	}
+	return cells
+}
+
+// marshalJSON marshals record as JSON or as JSON object
+// of the selected columns if there are any.
+func (rows *resultRows) marshalJSON(record reflect.Value) ([]byte, error) {
+	if len(rows.selected) == 0 {
+		return json.Marshal(record.Interface())
+	}
+	columns, err := rows.columnsFor(record)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		buf.Write(key)
+		buf.WriteByte(':')
+		val := rows.cellValue(record, column)
+		if !val.IsValid() {
+			buf.WriteString("null")
+			continue
+		}
+		b, err := json.Marshal(val.Interface())
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// formatResultCell formats v as string for a table or CSV cell.
+// Nil and null values are formatted as empty string,
+// times using ResultTimeFormat, and slices, maps,
+// and structs that don't implement encoding.TextMarshaler
+// or fmt.Stringer as JSON.
+func formatResultCell(v reflect.Value) string {
+	if !v.IsValid() || nullable.ReflectIsNull(v) {
+		return ""
+	}
+	v = derefResult(v)
+	switch v.Type() {
+	case typeOfTime:
+		return v.Interface().(time.Time).Format(ResultTimeFormat)
+	case typeOfNullableTime:
+		return v.Interface().(nullable.Time).Get().Format(ResultTimeFormat)
+	}
+	switch x := v.Interface().(type) {
+	case []byte:
+		return string(x)
+	case encoding.TextMarshaler:
+		text, err := x.MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	case fmt.Stringer:
+		return x.String()
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		b, err := json.Marshal(v.Interface())
+		if err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,18 @@
+package command
+
+import (
+	"reflect"
+)
+
+type Results interface {
+	NumResults() int
+	Results() []Result
+	ResultTag(index int, tag string) string
+	String() string
+}
+
+type Result struct {
+	Name        string
+	Description string
+	Type        reflect.Type
+}
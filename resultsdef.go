@@ -0,0 +1,200 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	reflection "github.com/ungerik/go-reflection"
+)
+
+// ResultsDef implements Results.
+//
+// It is embedded in a struct like ArgsDef to describe the result
+// values of a command function using the struct fields
+// tagged with ResultNameTag and ResultDescriptionTag:
+//
+//	var createUserResults struct {
+//		command.ResultsDef
+//
+//		User    *User `result:"user"    desc:"The user"`
+//		Created bool  `result:"created" desc:"If the user was created"`
+//	}
+type ResultsDef struct {
+	outerStructType    reflect.Type
+	resultStructFields []reflection.NamedStructField
+	resultInfos        []Result
+	initialized        bool
+}
+
+func (def *ResultsDef) NumResults() int {
+	return len(def.resultInfos)
+}
+
+func (def *ResultsDef) Results() []Result {
+	return def.resultInfos
+}
+
+func (def *ResultsDef) ResultTag(index int, tag string) string {
+	return def.resultStructFields[index].Field.Tag.Get(tag)
+}
+
+// String implements the fmt.Stringer interface.
+func (def *ResultsDef) String() string {
+	if !def.initialized {
+		return "ResultsDef not initialized"
+	}
+	var b strings.Builder
+	for _, result := range def.resultInfos {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "<%s:%s>", result.Name, reflection.DerefType(result.Type))
+	}
+	return b.String()
+}
+
+// Init initializes ResultsDef with the reflection data from
+// outerStructPtr wich has to be the address of the struct
+// variable that embedds ResultsDef.
+func (def *ResultsDef) Init(outerStructPtr interface{}) error {
+	if def.initialized {
+		return nil
+	}
+
+	if _, ok := outerStructPtr.(Results); !ok {
+		return fmt.Errorf("outerStructPtr of type %T does not implement interface Results", outerStructPtr)
+	}
+
+	def.outerStructType = reflection.DerefType(reflect.TypeOf(outerStructPtr))
+	if def.outerStructType.Kind() != reflect.Struct {
+		return fmt.Errorf("ResultsDef must be contained in a struct, but outer type is %s", def.outerStructType)
+	}
+
+	def.resultStructFields = reflection.FlatExportedNamedStructFields(def.outerStructType, ResultNameTag)
+
+	def.resultInfos = make([]Result, len(def.resultStructFields))
+	for i := range def.resultInfos {
+		def.resultInfos[i].Name = def.resultStructFields[i].Name
+		def.resultInfos[i].Description = def.ResultTag(i, ResultDescriptionTag)
+		def.resultInfos[i].Type = def.resultStructFields[i].Field.Type
+	}
+
+	def.initialized = true
+	return nil
+}
+
+type resultsImpl interface {
+	Init(outerStructPtr interface{}) error
+}
+
+// GetResults initializes the ResultsDef embedded in the struct
+// pointed to by resultsStructPtr and returns it as Results.
+// Other implementations of Results are returned unchanged.
+func GetResults(resultsStructPtr interface{}) (Results, error) {
+	// Same as with ArgsDef, the address of the outer struct
+	// has to be passed to ResultsDef.Init
+	resultsImpl, ok := resultsStructPtr.(resultsImpl)
+	if !ok {
+		if results, ok := resultsStructPtr.(Results); ok {
+			return results, nil
+		}
+		return nil, fmt.Errorf("resultsStructPtr of type %T does not embed ResultsDef", resultsStructPtr)
+	}
+	err := resultsImpl.Init(resultsStructPtr)
+	if err != nil {
+		return nil, err
+	}
+	return resultsStructPtr.(Results), nil
+}
+
+func MustGetResults(resultsStructPtr interface{}) Results {
+	results, err := GetResults(resultsStructPtr)
+	if err != nil {
+		panic(err)
+	}
+	return results
+}
+
+// NamedResultValues holds the result values of a command
+// together with the Results definition naming them.
+//
+// It marshals as JSON object with the result names as keys
+// in the order of the Results definition.
+type NamedResultValues struct {
+	Results Results
+	Values  []reflect.Value
+}
+
+// Names returns the names of the result values
+func (n NamedResultValues) Names() []string {
+	names := make([]string, n.Results.NumResults())
+	for i, result := range n.Results.Results() {
+		names[i] = result.Name
+	}
+	return names
+}
+
+// Map returns the result values as map by name
+func (n NamedResultValues) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(n.Values))
+	for i, name := range n.Names() {
+		m[name] = n.Values[i].Interface()
+	}
+	return m
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (n NamedResultValues) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range n.Names() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(n.Values[i].Interface())
+		if err != nil {
+			return nil, fmt.Errorf("can't marshal result %q as JSON because: %w", name, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func checkResultsDef(results Results, commandFunc interface{}) error {
+	funcType := reflect.TypeOf(commandFunc)
+	if funcType == nil || funcType.Kind() != reflect.Func {
+		return fmt.Errorf("expected a function or method, but got %T", commandFunc)
+	}
+	resultTypes := make([]reflect.Type, 0, funcType.NumOut())
+	for i := 0; i < funcType.NumOut(); i++ {
+		if i == funcType.NumOut()-1 && funcType.Out(i) == typeOfError {
+			break
+		}
+		resultTypes = append(resultTypes, funcType.Out(i))
+	}
+	if results.NumResults() != len(resultTypes) {
+		return fmt.Errorf("number of fields in command.Results struct (%d) does not match number of function results (%d)", results.NumResults(), len(resultTypes))
+	}
+	for i, result := range results.Results() {
+		if result.Type != resultTypes[i] {
+			return fmt.Errorf(
+				"type of command.Results struct field '%s' is %s, which does not match function result %d type %s",
+				result.Name,
+				result.Type,
+				i,
+				resultTypes[i],
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestCommandResultsDef struct {
+	ResultsDef
+
+	Result0 *ResultStruct `result:"result0" desc:"First result"`
+	Str1    string        `result:"str1"`
+}
+
+func CommandFuncMultipleResults(int0 int, str1 string, bool2 bool) (*ResultStruct, string, error) {
+	return &defaultResultStruct, str1, nil
+}
+
+func Test_AddCommandWithResults(t *testing.T) {
+	var (
+		commandArgsDef    TestCommandArgsDef
+		commandResultsDef TestCommandResultsDef
+		resultBuf         bytes.Buffer
+	)
+	disp := NewStringArgsDispatcher()
+	err := disp.AddCommandWithResults("multi", "", CommandFuncMultipleResults, &commandArgsDef, &commandResultsDef, PrintTo(&resultBuf))
+	assert.NoError(t, err, "AddCommandWithResults")
+	assert.Equal(t, []Result{
+		{Name: "result0", Description: "First result", Type: commandResultsDef.Results()[0].Type},
+		{Name: "str1", Description: "", Type: commandResultsDef.Results()[1].Type},
+	}, commandResultsDef.Results())
+
+	err = disp.Dispatch(context.Background(), "multi", "123", "Hello World!", "true")
+	assert.NoError(t, err, "Dispatch")
+	expected := "{\n  \"result0\": {\n    \"ResultCode\": 404,\n    \"ResultMessage\": \"not found\"\n  },\n  \"str1\": \"Hello World!\"\n}"
+	assert.Equal(t, expected, resultBuf.String())
+
+	err = disp.AddCommandWithResults("mismatch", "", CommandFuncStructResult, &commandArgsDef, &commandResultsDef)
+	assert.Error(t, err, "number of results does not match")
+}
+
+func Test_ReplaceCommandWithResults_Default(t *testing.T) {
+	var resultBuf bytes.Buffer
+	disp := NewStringArgsDispatcher()
+	disp.MustAddDefaultCommand("", CommandFuncMultipleResults, new(TestCommandArgsDef), PrintTo(&resultBuf))
+	err := disp.ReplaceCommandWithResults(Default, "", CommandFuncMultipleResults, new(TestCommandArgsDef), new(TestCommandResultsDef), PrintTo(&resultBuf))
+	assert.NoError(t, err, "ReplaceCommandWithResults")
+
+	err = disp.Dispatch(context.Background(), Default, "123", "Hello World!", "true")
+	assert.NoError(t, err, "Dispatch")
+	assert.Contains(t, resultBuf.String(), `"str1": "Hello World!"`, "named results")
+
+	err = disp.ReplaceCommandWithResults(Default, "", CommandFuncStructResult, new(TestCommandArgsDef), new(TestCommandResultsDef))
+	assert.Error(t, err, "number of results does not match")
+}
+
+// staticResults implements Results without ResultsDef
+type staticResults []Result
+
+func (r staticResults) NumResults() int                        { return len(r) }
+func (r staticResults) Results() []Result                      { return r }
+func (r staticResults) ResultTag(index int, tag string) string { return "" }
+func (r staticResults) String() string                         { return "staticResults" }
+
+func Test_WithNamedResults_Results(t *testing.T) {
+	results := staticResults{{Name: "a"}, {Name: "b"}}
+	var handled []reflect.Value
+	handler := WithNamedResults(results, ResultsHandlerFunc(func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		handled = resultVals
+		return resultErr
+	}))
+	err := handler(nil, nil, []reflect.Value{reflect.ValueOf(1), reflect.ValueOf("x")}, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, handled, 1) {
+		named := handled[0].Interface().(NamedResultValues)
+		assert.Equal(t, []string{"a", "b"}, named.Names())
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
 
+	"github.com/ungerik/go-fs"
 	"github.com/ungerik/go-reflection"
 )
 
@@ -19,58 +21,226 @@ func (f ResultsHandlerFunc) HandleResults(args Args, argVals, resultVals []refle
 	return f(args, argVals, resultVals, resultErr)
 }
 
+// WithNamedResults returns a ResultsHandler that passes the result values
+// as a single NamedResultValues value to resultsHandlers,
+// so that multiple results can be labeled with their names.
+// A results struct embedding ResultsDef is initialized with GetResults,
+// the function panics if that fails.
+func WithNamedResults(results Results, resultsHandlers ...ResultsHandler) ResultsHandlerFunc {
+	results = MustGetResults(results)
+	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		replay, err := replayableResults(resultVals, len(resultsHandlers))
+		if err != nil {
+			return err
+		}
+		for _, resultsHandler := range resultsHandlers {
+			resultVals := replay()
+			if len(resultVals) == results.NumResults() {
+				named := NamedResultValues{Results: results, Values: resultVals}
+				resultVals = []reflect.Value{reflect.ValueOf(named)}
+			}
+			err := resultsHandler.HandleResults(args, argVals, resultVals, resultErr)
+			if err != nil && err != resultErr {
+				return err
+			}
+		}
+		return resultErr
+	}
+}
+
 func resultsToInterfaces(results []reflect.Value) ([]interface{}, error) {
 	r := make([]interface{}, len(results))
 	for i, result := range results {
-		resultInterface := result.Interface()
-
-		if b, ok := resultInterface.([]byte); ok {
-			r[i] = string(b)
+		reader, err := openResultReader(result)
+		if err != nil {
+			return nil, err
+		}
+		if reader != nil {
+			data, err := io.ReadAll(reader)
+			if e := reader.Close(); err == nil {
+				err = e
+			}
+			if err != nil {
+				return nil, err
+			}
+			r[i] = string(data)
 			continue
 		}
+		r[i], err = resultToInterface(result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func resultToInterface(result reflect.Value) (interface{}, error) {
+	resultInterface := result.Interface()
 
-		switch reflection.DerefValue(result).Kind() {
-		case reflect.Struct, reflect.Slice, reflect.Array:
-			b, err := json.MarshalIndent(resultInterface, "", "  ")
+	if b, ok := resultInterface.([]byte); ok {
+		return string(b), nil
+	}
+
+	switch reflection.DerefValue(result).Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array:
+		b, err := json.MarshalIndent(resultInterface, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("can't print command result as JSON because: %w", err)
+		}
+		return string(b), nil
+	}
+
+	return resultInterface, nil
+}
+
+var typeOfFile = reflect.TypeOf(fs.File(""))
+
+// openResultReader returns a reader for the data of result
+// if it is an io.Reader or a fs.FileReader, else nil.
+// A fs.File result is only opened if the declared result type
+// is an interface like fs.FileReader, else its path is the result.
+// The returned reader must be closed after reading,
+// which closes the result if it implements io.Closer.
+func openResultReader(result reflect.Value) (io.ReadCloser, error) {
+	switch result.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if result.IsNil() {
+			return nil, nil
+		}
+	}
+	switch r := result.Interface().(type) {
+	case io.ReadCloser:
+		return r, nil
+	case io.Reader:
+		return io.NopCloser(r), nil
+	case fs.FileReader:
+		if result.Type() == typeOfFile {
+			return nil, nil
+		}
+		return r.OpenReader()
+	}
+	return nil, nil
+}
+
+// forEachResult calls f with every result converted by resultToInterface.
+// The elements of ResultStream results are passed to f one by one
+// as soon as they are received from the stream.
+// io.Reader and fs.FileReader results are passed as io.Reader
+// to f and closed after f returns.
+func forEachResult(results []reflect.Value, f func(r interface{}) error) error {
+	each := func(result reflect.Value) error {
+		reader, err := openResultReader(result)
+		if err != nil {
+			return err
+		}
+		if reader != nil {
+			err = f(reader)
+			if e := reader.Close(); err == nil {
+				err = e
+			}
+			return err
+		}
+		r, err := resultToInterface(result)
+		if err != nil {
+			return err
+		}
+		return f(r)
+	}
+
+	for _, result := range results {
+		if stream, ok := result.Interface().(*ResultStream); ok {
+			err := stream.Range(each)
 			if err != nil {
-				return nil, fmt.Errorf("can't print command result as JSON because: %w", err)
+				return err
 			}
-			r[i] = string(b)
 			continue
 		}
+		err := each(result)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		r[i] = resultInterface
+// fprintlnResult calls fmt.Fprintln on writer with the optional prefix
+// and r, or copies the data to writer if r is an io.Reader.
+func fprintlnResult(writer io.Writer, r interface{}, prefix ...string) error {
+	args := make([]interface{}, 0, len(prefix)+1)
+	for _, p := range prefix {
+		args = append(args, p)
 	}
-	return r, nil
+	if reader, ok := r.(io.Reader); ok {
+		if len(args) > 0 {
+			_, err := fmt.Fprint(writer, append(args, " ")...)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := io.Copy(writer, reader)
+		return err
+	}
+	_, err := fmt.Fprintln(writer, append(args, r)...)
+	return err
 }
 
-// PrintTo calls fmt.Fprint on writer with the result values as varidic arguments
+// PrintTo calls fmt.Fprint on writer with the result values as varidic arguments.
+// The data of io.Reader and fs.FileReader results is copied to writer.
 func PrintTo(writer io.Writer) ResultsHandlerFunc {
 	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
 		if resultErr != nil {
 			return resultErr
 		}
-		r, err := resultsToInterfaces(resultVals)
-		if err != nil || len(r) == 0 {
+		var pending []interface{}
+		flush := func() error {
+			if len(pending) == 0 {
+				return nil
+			}
+			_, err := fmt.Fprint(writer, pending...)
+			pending = nil
 			return err
 		}
-		_, err = fmt.Fprint(writer, r...)
-		return err
+		for _, resultVal := range resultVals {
+			reader, err := openResultReader(resultVal)
+			if err != nil {
+				return err
+			}
+			if reader != nil {
+				err = flush()
+				if err == nil {
+					_, err = io.Copy(writer, reader)
+				}
+				if e := reader.Close(); err == nil {
+					err = e
+				}
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			r, err := resultToInterface(resultVal)
+			if err != nil {
+				return err
+			}
+			pending = append(pending, r)
+		}
+		return flush()
 	}
 }
 
-// PrintlnTo calls fmt.Fprintln on writer for every result
-func PrintlnTo(writer io.Writer) ResultsHandlerFunc {
+// PrintJSONTo writes every result as indented JSON
+// followed by a newline to writer.
+func PrintJSONTo(writer io.Writer) ResultsHandlerFunc {
 	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
 		if resultErr != nil {
 			return resultErr
 		}
-		results, err := resultsToInterfaces(resultVals)
-		if err != nil || len(results) == 0 {
-			return err
-		}
-		for _, r := range results {
-			_, err = fmt.Fprintln(writer, r)
+		for _, resultVal := range resultVals {
+			b, err := json.MarshalIndent(resultVal.Interface(), "", "  ")
+			if err != nil {
+				return fmt.Errorf("can't print command result as JSON because: %w", err)
+			}
+			_, err = writer.Write(append(b, '\n'))
 			if err != nil {
 				return err
 			}
@@ -79,56 +249,102 @@ func PrintlnTo(writer io.Writer) ResultsHandlerFunc {
 	}
 }
 
-// Println calls fmt.Println for every result
+// PrintlnTo calls fmt.Fprintln on writer for every result.
+// The elements of ResultStream results are printed line by line
+// and the data of io.Reader and fs.FileReader results is copied.
+func PrintlnTo(writer io.Writer) ResultsHandlerFunc {
+	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		if resultErr != nil {
+			return resultErr
+		}
+		return forEachResult(resultVals, func(r interface{}) error {
+			return fprintlnResult(writer, r)
+		})
+	}
+}
+
+// Println calls fmt.Println for every result.
+// The elements of ResultStream results are printed line by line
+// and the data of io.Reader and fs.FileReader results is copied.
 var Println ResultsHandlerFunc = func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
 	if resultErr != nil {
 		return resultErr
 	}
-	results, err := resultsToInterfaces(resultVals)
-	if err != nil || len(results) == 0 {
-		return err
-	}
-	for _, r := range results {
-		_, err = fmt.Println(r)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return forEachResult(resultVals, func(r interface{}) error {
+		return fprintlnResult(os.Stdout, r)
+	})
 }
 
-// PrintlnWithPrefixTo calls fmt.Fprintln(writer, prefix, result) for every result value
+// PrintlnWithPrefixTo calls fmt.Fprintln(writer, prefix, result) for every result value.
+// The elements of ResultStream results are printed line by line
+// and the data of io.Reader and fs.FileReader results is copied.
 func PrintlnWithPrefixTo(prefix string, writer io.Writer) ResultsHandlerFunc {
 	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
 		if resultErr != nil {
 			return resultErr
 		}
-		results, err := resultsToInterfaces(resultVals)
-		if err != nil || len(results) == 0 {
-			return err
-		}
-		for _, result := range results {
-			_, err = fmt.Fprintln(writer, prefix, result)
-			if err != nil {
-				return err
-			}
-		}
-		return nil
+		return forEachResult(resultVals, func(r interface{}) error {
+			return fprintlnResult(writer, r, prefix)
+		})
 	}
 }
 
-// PrintlnWithPrefix calls fmt.Println(prefix, result) for every result value
+// PrintlnWithPrefix calls fmt.Println(prefix, result) for every result value.
+// The elements of ResultStream results are printed line by line
+// and the data of io.Reader and fs.FileReader results is copied.
 func PrintlnWithPrefix(prefix string) ResultsHandlerFunc {
 	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
 		if resultErr != nil {
 			return resultErr
 		}
-		results, err := resultsToInterfaces(resultVals)
-		if err != nil || len(results) == 0 {
+		return forEachResult(resultVals, func(r interface{}) error {
+			return fprintlnResult(os.Stdout, r, prefix)
+		})
+	}
+}
+
+// WriteToFile writes the binary data of results of type []byte, string,
+// io.Reader, or fs.FileReader to the go-fs file at path.
+// io.Closer results are closed after writing.
+func WriteToFile(path string) ResultsHandlerFunc {
+	return func(args Args, argVals, resultVals []reflect.Value, resultErr error) (err error) {
+		if resultErr != nil {
+			return resultErr
+		}
+		for _, resultVal := range resultVals {
+			switch resultVal.Interface().(type) {
+			case []byte, string, io.Reader, fs.FileReader:
+			default:
+				return fmt.Errorf("WriteToFile does not support result type %s", resultVal.Type())
+			}
+		}
+
+		writer, err := fs.File(path).OpenWriter()
+		if err != nil {
 			return err
 		}
-		for _, result := range results {
-			_, err = fmt.Println(prefix, result)
+		defer func() {
+			if e := writer.Close(); err == nil {
+				err = e
+			}
+		}()
+
+		for _, resultVal := range resultVals {
+			switch data := resultVal.Interface().(type) {
+			case []byte:
+				_, err = writer.Write(data)
+			case string:
+				_, err = io.WriteString(writer, data)
+			case io.Reader:
+				_, err = io.Copy(writer, data)
+				if closer, ok := data.(io.Closer); ok {
+					if e := closer.Close(); err == nil {
+						err = e
+					}
+				}
+			case fs.FileReader:
+				_, err = data.WriteTo(writer)
+			}
 			if err != nil {
 				return err
 			}
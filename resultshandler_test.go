@@ -0,0 +1,121 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/domonda/go-types/nullable"
+	"github.com/stretchr/testify/assert"
+	"github.com/ungerik/go-fs"
+)
+
+type testReadCloser struct {
+	io.Reader
+	closed bool
+}
+
+func (r *testReadCloser) Close() error {
+	r.closed = true
+	return nil
+}
+
+func Test_ReaderResults(t *testing.T) {
+	var (
+		args      TestCommandArgsDef
+		resultBuf bytes.Buffer
+		reader    *testReadCloser
+	)
+	commandFunc := func(int0 int, str1 string, bool2 bool) (io.Reader, error) {
+		reader = &testReadCloser{Reader: strings.NewReader(str1)}
+		return reader, nil
+	}
+
+	f, err := GetStringArgsFunc(commandFunc, &args, PrintlnTo(&resultBuf))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	err = f(context.Background(), "0", "Hello\nWorld!\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello\nWorld!\n", resultBuf.String())
+	assert.True(t, reader.closed, "reader closed")
+
+	file := fs.File(filepath.Join(t.TempDir(), "result.txt"))
+	f, err = GetStringArgsFunc(commandFunc, &args, WriteToFile(file.LocalPath()))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	err = f(context.Background(), "0", "Hello World!")
+	assert.NoError(t, err)
+	assert.True(t, reader.closed, "reader closed")
+	data, err := file.ReadAllString(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World!", data)
+
+	resultBuf.Reset()
+	fileReaderFunc := func(int0 int, str1 string, bool2 bool) fs.FileReader {
+		return fs.NewMemFile("mem.txt", []byte(str1))
+	}
+	f, err = GetStringArgsFunc(fileReaderFunc, &args, PrintTo(&resultBuf))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	err = f(context.Background(), "0", "File data")
+	assert.NoError(t, err)
+	assert.Equal(t, "File data", resultBuf.String())
+}
+
+type testTableRow struct {
+	Name    string `col:"name"`
+	Created time.Time
+	Deleted nullable.Time
+	Owner   *testTableOwner
+	Hidden  string `col:"-"`
+}
+
+type testTableOwner struct {
+	ID   int
+	Tags []string
+}
+
+func Test_TableResults(t *testing.T) {
+	created := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	rows := []testTableRow{
+		{Name: "first", Created: created, Owner: &testTableOwner{ID: 1, Tags: []string{"a", "b"}}},
+		{Name: "second, quoted", Created: created, Deleted: nullable.TimeFrom(created)},
+	}
+	resultVals := []reflect.Value{reflect.ValueOf(rows)}
+
+	var buf bytes.Buffer
+	err := PrintTable(&buf)(nil, nil, resultVals, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, ""+
+		"name            Created               Deleted               Owner.ID  Owner.Tags\n"+
+		"first           2022-09-01T12:00:00Z                        1         [\"a\",\"b\"]\n"+
+		"second, quoted  2022-09-01T12:00:00Z  2022-09-01T12:00:00Z            \n",
+		buf.String(),
+	)
+
+	buf.Reset()
+	err = PrintCSV(&buf, "Owner.ID", "name")(nil, nil, resultVals, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Owner.ID,name\n1,first\n,\"second, quoted\"\n", buf.String())
+
+	buf.Reset()
+	err = PrintCSV(&buf, "unknown")(nil, nil, resultVals, nil)
+	assert.Error(t, err, "unknown column")
+
+	buf.Reset()
+	err = PrintNDJSON(&buf, "name", "Deleted")(nil, nil, resultVals, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"name\":\"first\",\"Deleted\":null}\n{\"name\":\"second, quoted\",\"Deleted\":\"2022-09-01T12:00:00Z\"}\n", buf.String())
+
+	buf.Reset()
+	err = PrintYAML(&buf, "name", "Owner.ID")(nil, nil, resultVals, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "- name: first\n  Owner.ID: 1\n- name: second, quoted\n  Owner.ID: null\n", buf.String())
+
+	buf.Reset()
+	err = PrintYAML(&buf)(nil, nil, []reflect.Value{reflect.ValueOf(map[string]interface{}{"b": "123", "a": 1})}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\nb: \"123\"\n", buf.String())
+}
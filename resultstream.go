@@ -0,0 +1,245 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+var typeOfBool = reflect.TypeOf(false)
+
+// ResultStream wraps a command function result of a channel type
+// like <-chan T or of an iterator function type func(yield func(T) bool)
+// so that ResultsHandler implementations can consume the
+// result elements one by one instead of as a whole.
+//
+// The command function is called with a context that gets canceled
+// by DiscardResultStreams after the results were handled,
+// or when the context of the caller is canceled.
+// The stream stops when that context is canceled.
+// Producers sending to a channel must select on ctx.Done()
+// to not block forever when the stream gets canceled.
+type ResultStream struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	val      reflect.Value
+	elemType reflect.Type
+	// elems are the buffered elements if not nil
+	elems []reflect.Value
+}
+
+// IsResultStreamType returns if t is a receivable channel type
+// or an iterator function type func(yield func(T) bool)
+// that will be wrapped as ResultStream.
+func IsResultStreamType(t reflect.Type) bool {
+	_, ok := resultStreamElemType(t)
+	return ok
+}
+
+func resultStreamElemType(t reflect.Type) (elemType reflect.Type, ok bool) {
+	switch t.Kind() {
+	case reflect.Chan:
+		if t.ChanDir()&reflect.RecvDir == 0 {
+			return nil, false
+		}
+		return t.Elem(), true
+
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 || t.IsVariadic() {
+			return nil, false
+		}
+		yield := t.In(0)
+		if yield.Kind() != reflect.Func || yield.NumIn() != 1 || yield.NumOut() != 1 || yield.Out(0) != typeOfBool {
+			return nil, false
+		}
+		return yield.In(0), true
+	}
+	return nil, false
+}
+
+func newResultStream(ctx context.Context, cancel context.CancelFunc, val reflect.Value) *ResultStream {
+	elemType, _ := resultStreamElemType(val.Type())
+	return &ResultStream{ctx: ctx, cancel: cancel, val: val, elemType: elemType}
+}
+
+// Type returns the type of the wrapped channel or iterator function
+func (s *ResultStream) Type() reflect.Type {
+	return s.val.Type()
+}
+
+// ElemType returns the type of the stream elements
+func (s *ResultStream) ElemType() reflect.Type {
+	return s.elemType
+}
+
+// Range calls f for every element of the stream until the stream ends,
+// f returns an error, or the context of the command call is canceled.
+// The error from f or the context is returned.
+// A channel stream can only be ranged once.
+func (s *ResultStream) Range(f func(elem reflect.Value) error) error {
+	if s.elems != nil {
+		for _, elem := range s.elems {
+			err := f(elem)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if s.val.IsNil() {
+		return nil
+	}
+	if s.val.Kind() == reflect.Chan {
+		return s.rangeChan(f)
+	}
+	return s.rangeFunc(f)
+}
+
+func (s *ResultStream) rangeChan(f func(elem reflect.Value) error) error {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: s.val},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.ctx.Done())},
+	}
+	for {
+		chosen, elem, ok := reflect.Select(cases)
+		if chosen == 1 {
+			return s.ctx.Err()
+		}
+		if !ok {
+			return nil
+		}
+		err := f(elem)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *ResultStream) rangeFunc(f func(elem reflect.Value) error) (err error) {
+	yieldType := s.val.Type().In(0)
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		if err == nil {
+			err = s.ctx.Err()
+		}
+		if err == nil {
+			err = f(args[0])
+		}
+		return []reflect.Value{reflect.ValueOf(err == nil)}
+	})
+	s.val.Call([]reflect.Value{yield})
+	return err
+}
+
+// MarshalJSON implements the json.Marshaler interface
+// by marshalling all stream elements as JSON array.
+func (s *ResultStream) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	err := s.Range(func(elem reflect.Value) error {
+		b, err := json.Marshal(elem.Interface())
+		if err != nil {
+			return err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(b)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal result stream as JSON because: %w", err)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// String implements the fmt.Stringer interface.
+func (s *ResultStream) String() string {
+	return fmt.Sprintf("ResultStream(%s)", s.val.Type())
+}
+
+// wrapResultStreams replaces stream result values
+// with ResultStream values that are canceled with cancel
+func wrapResultStreams(ctx context.Context, cancel context.CancelFunc, resultVals []reflect.Value) {
+	for i, resultVal := range resultVals {
+		if IsResultStreamType(resultVal.Type()) {
+			resultVals[i] = reflect.ValueOf(newResultStream(ctx, cancel, resultVal))
+		}
+	}
+}
+
+// replayableResults returns a function that returns resultVals
+// once for each of numHandlers ResultsHandler.
+// For multiple handlers the elements of ResultStream results
+// and the data of io.Reader and fs.FileReader results are buffered,
+// so that every handler can consume them and not only the first one.
+func replayableResults(resultVals []reflect.Value, numHandlers int) (func() []reflect.Value, error) {
+	if numHandlers <= 1 {
+		return func() []reflect.Value { return resultVals }, nil
+	}
+	streams := make(map[int]*ResultStream)
+	readers := make(map[int][]byte)
+	for i, resultVal := range resultVals {
+		if stream, ok := resultVal.Interface().(*ResultStream); ok {
+			buffered := *stream
+			buffered.elems = []reflect.Value{}
+			err := stream.Range(func(elem reflect.Value) error {
+				buffered.elems = append(buffered.elems, elem)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			streams[i] = &buffered
+			continue
+		}
+		reader, err := openResultReader(resultVal)
+		if err != nil {
+			return nil, err
+		}
+		if reader != nil {
+			data, err := io.ReadAll(reader)
+			if e := reader.Close(); err == nil {
+				err = e
+			}
+			if err != nil {
+				return nil, err
+			}
+			readers[i] = data
+		}
+	}
+	if len(streams) == 0 && len(readers) == 0 {
+		return func() []reflect.Value { return resultVals }, nil
+	}
+	return func() []reflect.Value {
+		replay := make([]reflect.Value, len(resultVals))
+		for i, resultVal := range resultVals {
+			switch {
+			case streams[i] != nil:
+				stream := *streams[i]
+				replay[i] = reflect.ValueOf(&stream)
+			case readers[i] != nil:
+				replay[i] = reflect.ValueOf(io.NopCloser(bytes.NewReader(readers[i])))
+			default:
+				replay[i] = resultVal
+			}
+		}
+		return replay
+	}, nil
+}
+
+// DiscardResultStreams cancels the context of the command function call
+// that returned the ResultStream values from resultVals,
+// so that goroutines sending to channel streams that select
+// on the context are not blocked forever.
+// Streams can't be ranged after they have been discarded.
+func DiscardResultStreams(resultVals []reflect.Value) {
+	for _, resultVal := range resultVals {
+		if stream, ok := resultVal.Interface().(*ResultStream); ok {
+			stream.cancel()
+		}
+	}
+}
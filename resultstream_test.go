@@ -0,0 +1,162 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func CommandFuncChanResult(ctx context.Context, count int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 0; i < count; i++ {
+			select {
+			case ch <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func CommandFuncIterResult(count int) func(yield func(string) bool) {
+	return func(yield func(string) bool) {
+		for i := 0; i < count; i++ {
+			if !yield(string(rune('a' + i))) {
+				return
+			}
+		}
+	}
+}
+
+type TestCountArgsDef struct {
+	ArgsDef
+
+	Count int `arg:"count"`
+}
+
+func Test_ResultStream(t *testing.T) {
+	var (
+		countArgs TestCountArgsDef
+		resultBuf bytes.Buffer
+	)
+	f, err := GetStringArgsFunc(CommandFuncChanResult, &countArgs, PrintlnTo(&resultBuf))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	err = f(context.Background(), "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "0\n1\n2\n", resultBuf.String())
+
+	resultBuf.Reset()
+	f, err = GetStringArgsFunc(CommandFuncIterResult, &countArgs, PrintlnTo(&resultBuf))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	err = f(context.Background(), "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "a\nb\nc\n", resultBuf.String())
+
+	resultBuf.Reset()
+	f, err = GetStringArgsFunc(CommandFuncIterResult, &countArgs, PrintTo(&resultBuf))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	err = f(context.Background(), "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "[\n  \"a\",\n  \"b\"\n]", resultBuf.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f, err = GetStringArgsFunc(CommandFuncIterResult, &countArgs, PrintlnTo(&resultBuf))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	err = f(ctx, "3")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Channel streams not consumed by any handler get canceled
+	f, err = GetStringArgsFunc(CommandFuncChanResult, &countArgs)
+	assert.NoError(t, err, "GetStringArgsFunc")
+	err = f(context.Background(), "100")
+	assert.NoError(t, err)
+}
+
+func Test_ResultStreamCanceled(t *testing.T) {
+	stopErr := errors.New("stop")
+	producerDone := make(chan struct{}, 2)
+	endless := func(ctx context.Context) <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer func() { producerDone <- struct{}{} }()
+			for i := 0; ; i++ {
+				select {
+				case ch <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	}
+	failingHandler := ResultsHandlerFunc(func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		return stopErr
+	})
+	partialHandler := ResultsHandlerFunc(func(args Args, argVals, resultVals []reflect.Value, resultErr error) error {
+		stream := resultVals[0].Interface().(*ResultStream)
+		return stream.Range(func(elem reflect.Value) error {
+			if elem.Int() == 2 {
+				return stopErr
+			}
+			return nil
+		})
+	})
+
+	for _, handler := range []ResultsHandler{failingHandler, partialHandler} {
+		f, err := GetStringArgsFunc(endless, new(struct{ ArgsDef }), handler)
+		assert.NoError(t, err, "GetStringArgsFunc")
+		done := make(chan error)
+		go func() { done <- f(context.Background()) }()
+		select {
+		case err = <-done:
+			assert.Equal(t, stopErr, err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("call did not return after the results handler returned")
+		}
+		select {
+		case <-producerDone:
+		case <-time.After(2 * time.Second):
+			t.Fatal("producer was not canceled after the results handler returned")
+		}
+	}
+}
+
+func Test_ResultStream_MultipleHandlers(t *testing.T) {
+	var (
+		countArgs TestCountArgsDef
+		buf1      bytes.Buffer
+		buf2      bytes.Buffer
+	)
+	f, err := GetStringArgsFunc(CommandFuncChanResult, &countArgs, PrintlnTo(&buf1), PrintlnTo(&buf2))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	assert.NoError(t, f(context.Background(), "3"))
+	assert.Equal(t, "0\n1\n2\n", buf1.String())
+	assert.Equal(t, "0\n1\n2\n", buf2.String(), "second handler gets the stream elements too")
+
+	buf1.Reset()
+	buf2.Reset()
+	f, err = GetStringArgsFunc(func() *bytes.Buffer { return bytes.NewBufferString("data") }, new(struct{ ArgsDef }), PrintTo(&buf1), PrintTo(&buf2))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	assert.NoError(t, f(context.Background()))
+	assert.Equal(t, "data", buf1.String())
+	assert.Equal(t, "data", buf2.String(), "second handler gets the reader data too")
+}
+
+func Test_ResultStream_ContextNotCanceled(t *testing.T) {
+	var funcCtx context.Context
+	f, err := GetStringArgsFunc(func(ctx context.Context) { funcCtx = ctx }, new(struct{ ArgsDef }))
+	assert.NoError(t, err, "GetStringArgsFunc")
+	ctx := context.Background()
+	assert.NoError(t, f(ctx))
+	assert.Equal(t, ctx, funcCtx, "functions without stream results get the caller's context")
+	assert.NoError(t, funcCtx.Err())
+}
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+// Exit codes returned by Run
+const (
+	ExitSuccess     = 0
+	ExitFailure     = 1
+	ExitUsage       = 2
+	ExitInterrupted = 130
+)
+
+// ExitCoder can be implemented by errors returned
+// from command functions to make Run return a custom exit code.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// UsageError wraps errors caused by invalid usage of a command line
+// like unknown flags or arguments that can't be parsed.
+type UsageError struct {
+	Err error
+}
+
+func (e UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e UsageError) Unwrap() error {
+	return e.Err
+}
+
+// IsUsageError returns if err is or wraps a UsageError,
+// ErrNotFound, or SuperCommandNotFound.
+func IsUsageError(err error) bool {
+	var (
+		usageErr UsageError
+		superErr SuperCommandNotFound
+	)
+	return errors.Is(err, ErrNotFound) ||
+		errors.As(err, &usageErr) ||
+		errors.As(err, &superErr)
+}
+
+// CommandLineDispatcher is implemented by StringArgsDispatcher,
+// SuperStringArgsDispatcher, and CommandTree.
+type CommandLineDispatcher interface {
+	// DispatchCommandLineArgs dispatches the command
+	// and its arguments from commandAndArgs.
+	DispatchCommandLineArgs(ctx context.Context, commandAndArgs []string) error
+
+	// PrintCommandsTo prints the usage of all commands to writer
+	PrintCommandsTo(writer io.Writer, appName string)
+}
+
+// Runner runs command line applications, see Run
+type Runner struct {
+	// Stdout is used to print the command usage
+	// if no command was passed, defaults to os.Stdout
+	Stdout io.Writer
+	// Stderr is used to print errors and the command usage
+	// after usage errors, defaults to os.Stderr
+	Stderr io.Writer
+	// Signals cancel the context passed to the command,
+	// defaults to os.Interrupt and syscall.SIGTERM
+	Signals []os.Signal
+}
+
+// Run dispatches the command line args as passed in os.Args
+// with the program name as first element to disp
+// and returns an exit code for os.Exit.
+//
+// ExitUsage is returned after printing the error and the usage
+// of all commands to os.Stderr for usage errors like unknown commands.
+// ExitFailure is returned after printing the error to os.Stderr
+// if the command returned an error that does not implement ExitCoder.
+// ExitInterrupted is returned if the command was canceled
+// by SIGINT or SIGTERM, which cancel the context passed to the command.
+//
+// Example:
+//
+//	func main() {
+//	    os.Exit(command.Run(context.Background(), disp, os.Args))
+//	}
+func Run(ctx context.Context, disp CommandLineDispatcher, args []string) int {
+	var runner Runner
+	return runner.Run(ctx, disp, args)
+}
+
+// Run dispatches the command line args with the program name
+// as first element to disp and returns an exit code.
+// See the Run function for details.
+func (r *Runner) Run(ctx context.Context, disp CommandLineDispatcher, args []string) int {
+	stdout, stderr, signals := r.Stdout, r.Stderr, r.Signals
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	if signals == nil {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	appName := ""
+	if len(args) > 0 {
+		appName = filepath.Base(args[0])
+		args = args[1:]
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, signals...)
+	defer stop()
+	ctx = withPromptMissingArgs(ctx)
+
+	err := disp.DispatchCommandLineArgs(ctx, args)
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var exitCoder ExitCoder
+	switch {
+	case errors.As(err, &exitCoder):
+		fmt.Fprintf(stderr, "%s: %s\n", appName, err)
+		return exitCoder.ExitCode()
+
+	case len(args) == 0 && errors.Is(err, ErrNotFound):
+		disp.PrintCommandsTo(stdout, appName)
+		return ExitUsage
+
+	case IsUsageError(err):
+		fmt.Fprintf(stderr, "%s: %s\n", appName, err)
+		disp.PrintCommandsTo(stderr, appName)
+		return ExitUsage
+
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		fmt.Fprintf(stderr, "%s: %s\n", appName, err)
+		return ExitInterrupted
+	}
+
+	fmt.Fprintf(stderr, "%s: %s\n", appName, err)
+	return ExitFailure
+}
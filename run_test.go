@@ -0,0 +1,56 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testExitError int
+
+func (e testExitError) Error() string { return "exit error" }
+func (e testExitError) ExitCode() int { return int(e) }
+
+func Test_Run(t *testing.T) {
+	var (
+		commandArgsDef TestCommandArgsDef
+		stdout, stderr bytes.Buffer
+	)
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("ok", "", CommandFunc, &commandArgsDef)
+	disp.MustAddCommand("fail", "", CommandFuncErrResult, &commandArgsDef)
+	disp.MustAddCommand("exit", "", func() error { return testExitError(42) }, new(struct{ ArgsDef }))
+	disp.MustAddCommand("ctx", "", func(ctx context.Context) error { return ctx.Err() }, new(struct{ ArgsDef }))
+	runner := Runner{Stdout: &stdout, Stderr: &stderr}
+	ctx := context.Background()
+
+	assert.Equal(t, ExitSuccess, runner.Run(ctx, disp, []string{"/bin/app", "ok", "1", "a", "true"}))
+	assert.Empty(t, stderr.String())
+
+	assert.Equal(t, ExitFailure, runner.Run(ctx, disp, []string{"/bin/app", "fail"}))
+	assert.Equal(t, "app: "+assert.AnError.Error()+"\n", stderr.String())
+
+	stderr.Reset()
+	assert.Equal(t, 42, runner.Run(ctx, disp, []string{"app", "exit"}))
+	assert.Equal(t, "app: exit error\n", stderr.String())
+
+	stderr.Reset()
+	assert.Equal(t, ExitUsage, runner.Run(ctx, disp, []string{"app", "unknown"}))
+	assert.Contains(t, stderr.String(), "app: command 'unknown' not found\n")
+	assert.Contains(t, stderr.String(), "app ok <int0:int> <str1:string> <bool2:bool>")
+
+	stderr.Reset()
+	assert.Equal(t, ExitUsage, runner.Run(ctx, disp, []string{"app", "ok", "NaN"}))
+	assert.Contains(t, stderr.String(), "NaN")
+
+	stderr.Reset()
+	assert.Equal(t, ExitUsage, runner.Run(ctx, disp, []string{"app"}))
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "app fail")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, ExitInterrupted, runner.Run(canceled, disp, []string{"app", "ctx"}))
+}
@@ -6,7 +6,11 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"unicode"
+
+	"github.com/fatih/color"
 )
 
 type constError string
@@ -25,9 +29,40 @@ type stringArgsCommand struct {
 	command         string
 	description     string
 	args            Args
+	results         Results
 	commandFunc     interface{}
-	stringArgsFunc  StringArgsFunc
+	handlersFunc    stringArgsHandlersFunc
 	resultsHandlers []ResultsHandler
+	// used by the calls of CommandInfo
+	stringMapArgsFunc StringMapArgsResultValuesFunc
+	mapArgsFunc       MapArgsResultValuesFunc
+	completers        map[string]Completer
+	aliases           []string
+}
+
+// initFuncs initializes the functions
+// calling cmd.commandFunc with cmd.args
+func (cmd *stringArgsCommand) initFuncs() (err error) {
+	cmd.handlersFunc, err = getStringArgsHandlersFunc(cmd.commandFunc, cmd.args)
+	if err != nil {
+		return err
+	}
+	impl := cmd.args.(argsImpl)
+	cmd.stringMapArgsFunc, err = impl.StringMapArgsResultValuesFunc(cmd.commandFunc)
+	if err != nil {
+		return err
+	}
+	cmd.mapArgsFunc, err = impl.MapArgsResultValuesFunc(cmd.commandFunc)
+	return err
+}
+
+// call calls the command function with args
+// and passes the results to resultsHandlers.
+func (cmd *stringArgsCommand) call(ctx context.Context, args []string, resultsHandlers []ResultsHandler) error {
+	if cmd.results != nil {
+		resultsHandlers = []ResultsHandler{WithNamedResults(cmd.results, resultsHandlers...)}
+	}
+	return cmd.handlersFunc(ctx, args, resultsHandlers)
 }
 
 func checkCommandChars(command string) error {
@@ -53,61 +88,155 @@ func (f StringArgsCommandLoggerFunc) LogStringArgsCommand(command string, args [
 	f(command, args)
 }
 
+// StringArgsDispatcher dispatches commands with string arguments.
+// Commands can be added, replaced, and removed
+// concurrently to dispatching them.
 type StringArgsDispatcher struct {
-	comm    map[string]*stringArgsCommand
-	loggers []StringArgsCommandLogger
+	registry       atomic.Pointer[commandRegistry]
+	registryMutex  sync.Mutex
+	loggers        []StringArgsCommandLogger
+	flags          dispatchFlags
+	help           helpFlag
+	completion     completion
+	prefixMatching bool
+	middleware     []Middleware
+	// superCommand is the name of the super command
+	// if added by SuperStringArgsDispatcher.AddSuperCommand
+	superCommand string
 }
 
 func NewStringArgsDispatcher(loggers ...StringArgsCommandLogger) *StringArgsDispatcher {
-	return &StringArgsDispatcher{
-		comm:    make(map[string]*stringArgsCommand),
-		loggers: loggers,
+	disp := &StringArgsDispatcher{loggers: loggers}
+	disp.registry.Store(newCommandRegistry())
+	return disp
+}
+
+// commands returns the current snapshot of the registered commands
+func (disp *StringArgsDispatcher) commands() *commandRegistry {
+	return disp.registry.Load()
+}
+
+// updateCommands calls change with a clone of the registered commands
+// that replaces them if change returns no error.
+func (disp *StringArgsDispatcher) updateCommands(change func(reg *commandRegistry) error) error {
+	disp.registryMutex.Lock()
+	defer disp.registryMutex.Unlock()
+
+	reg := disp.commands().clone()
+	err := change(reg)
+	if err != nil {
+		return err
 	}
+	disp.registry.Store(reg)
+	return nil
 }
 
 func (disp *StringArgsDispatcher) AddCommand(command, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
-	if _, exists := disp.comm[command]; exists {
-		return fmt.Errorf("Command '%s' already added", command)
+	return disp.AddCommandWithResults(command, description, commandFunc, args, nil, resultsHandlers...)
+}
+
+func (disp *StringArgsDispatcher) MustAddCommand(command, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) {
+	err := disp.AddCommand(command, description, commandFunc, args, resultsHandlers...)
+	if err != nil {
+		panic(err)
 	}
+}
+
+// AddCommandWithResults adds a command like AddCommand
+// with an additional results definition that names
+// the result values of commandFunc.
+// results can be nil for commands without named results.
+func (disp *StringArgsDispatcher) AddCommandWithResults(command, description string, commandFunc interface{}, args Args, results Results, resultsHandlers ...ResultsHandler) error {
+	cmd, err := newStringArgsCommand(command, description, commandFunc, args, results, resultsHandlers)
+	if err != nil {
+		return err
+	}
+	return disp.updateCommands(func(reg *commandRegistry) error {
+		if _, exists := reg.comm[command]; exists {
+			return fmt.Errorf("Command '%s' already added", command)
+		}
+		if other, exists := reg.aliases[command]; exists {
+			return fmt.Errorf("Command '%s' already added as alias of '%s'", command, other.command)
+		}
+		reg.setCommand(cmd)
+		return nil
+	})
+}
+
+// newStringArgsCommand returns a command with the checked name command
+// that calls commandFunc with args and passes the named results to resultsHandlers.
+func newStringArgsCommand(command, description string, commandFunc interface{}, args Args, results Results, resultsHandlers []ResultsHandler) (*stringArgsCommand, error) {
 	if err := checkCommandChars(command); err != nil {
-		return fmt.Errorf("Command '%s' returned: %w", command, err)
+		return nil, fmt.Errorf("Command '%s' returned: %w", command, err)
 	}
-	stringArgsFunc, err := GetStringArgsFunc(commandFunc, args, resultsHandlers...)
+	results, err := getCheckedResults(results, commandFunc)
 	if err != nil {
-		return fmt.Errorf("Command '%s' returned: %w", command, err)
+		return nil, fmt.Errorf("Command '%s' returned: %w", command, err)
 	}
-	disp.comm[command] = &stringArgsCommand{
+	cmd := &stringArgsCommand{
 		command:         command,
 		description:     description,
 		args:            args,
+		results:         results,
 		commandFunc:     commandFunc,
-		stringArgsFunc:  stringArgsFunc,
 		resultsHandlers: resultsHandlers,
 	}
-	return nil
+	err = cmd.initFuncs()
+	if err != nil {
+		return nil, fmt.Errorf("Command '%s' returned: %w", command, err)
+	}
+	return cmd, nil
 }
 
-func (disp *StringArgsDispatcher) MustAddCommand(command, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) {
-	err := disp.AddCommand(command, description, commandFunc, args, resultsHandlers...)
+// getCheckedResults returns the initialized results
+// checked against commandFunc or nil for nil results
+func getCheckedResults(results Results, commandFunc interface{}) (Results, error) {
+	if results == nil {
+		return nil, nil
+	}
+	results, err := GetResults(results)
+	if err != nil {
+		return nil, err
+	}
+	return results, checkResultsDef(results, commandFunc)
+}
+
+func (disp *StringArgsDispatcher) MustAddCommandWithResults(command, description string, commandFunc interface{}, args Args, results Results, resultsHandlers ...ResultsHandler) {
+	err := disp.AddCommandWithResults(command, description, commandFunc, args, results, resultsHandlers...)
 	if err != nil {
 		panic(err)
 	}
 }
 
 func (disp *StringArgsDispatcher) AddDefaultCommand(description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
-	stringArgsFunc, err := GetStringArgsFunc(commandFunc, args, resultsHandlers...)
+	cmd, err := newDefaultStringArgsCommand(description, commandFunc, args, nil, resultsHandlers)
 	if err != nil {
-		return fmt.Errorf("Default command: %w", err)
+		return err
 	}
-	disp.comm[Default] = &stringArgsCommand{
+	return disp.updateCommands(func(reg *commandRegistry) error {
+		reg.setCommand(cmd)
+		return nil
+	})
+}
+
+func newDefaultStringArgsCommand(description string, commandFunc interface{}, args Args, results Results, resultsHandlers []ResultsHandler) (*stringArgsCommand, error) {
+	results, err := getCheckedResults(results, commandFunc)
+	if err != nil {
+		return nil, fmt.Errorf("Default command: %w", err)
+	}
+	cmd := &stringArgsCommand{
 		command:         Default,
 		description:     description,
 		args:            args,
+		results:         results,
 		commandFunc:     commandFunc,
-		stringArgsFunc:  stringArgsFunc,
 		resultsHandlers: resultsHandlers,
 	}
-	return nil
+	err = cmd.initFuncs()
+	if err != nil {
+		return nil, fmt.Errorf("Default command: %w", err)
+	}
+	return cmd, nil
 }
 
 func (disp *StringArgsDispatcher) MustAddDefaultCommand(description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) {
@@ -117,25 +246,239 @@ func (disp *StringArgsDispatcher) MustAddDefaultCommand(description string, comm
 	}
 }
 
+// AddAlias adds aliases as alternative names for command
+// that are shown in the help and completed like commands.
+func (disp *StringArgsDispatcher) AddAlias(command string, aliases ...string) error {
+	return disp.updateCommands(func(reg *commandRegistry) error {
+		cmd, found := reg.comm[command]
+		if !found {
+			return fmt.Errorf("Command '%s': %w", command, ErrNotFound)
+		}
+		changed := *cmd
+		changed.aliases = append([]string(nil), cmd.aliases...)
+		for _, alias := range aliases {
+			if _, exists := reg.comm[alias]; exists {
+				return fmt.Errorf("Alias '%s' of command '%s' already added as command", alias, command)
+			}
+			if other, exists := reg.aliases[alias]; exists {
+				return fmt.Errorf("Alias '%s' of command '%s' already added for command '%s'", alias, command, other.command)
+			}
+			if err := checkCommandChars(alias); err != nil {
+				return fmt.Errorf("Alias '%s' of command '%s': %w", alias, command, err)
+			}
+			changed.aliases = append(changed.aliases, alias)
+		}
+		reg.setCommand(&changed)
+		return nil
+	})
+}
+
+func (disp *StringArgsDispatcher) MustAddAlias(command string, aliases ...string) {
+	err := disp.AddAlias(command, aliases...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ReplaceCommand replaces the already added command
+// keeping its aliases. Calls of the replaced command that are
+// already dispatched are not affected.
+func (disp *StringArgsDispatcher) ReplaceCommand(command, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
+	return disp.ReplaceCommandWithResults(command, description, commandFunc, args, nil, resultsHandlers...)
+}
+
+func (disp *StringArgsDispatcher) MustReplaceCommand(command, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) {
+	err := disp.ReplaceCommand(command, description, commandFunc, args, resultsHandlers...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ReplaceCommandWithResults replaces a command like ReplaceCommand
+// with an additional results definition, see AddCommandWithResults.
+func (disp *StringArgsDispatcher) ReplaceCommandWithResults(command, description string, commandFunc interface{}, args Args, results Results, resultsHandlers ...ResultsHandler) error {
+	var (
+		cmd *stringArgsCommand
+		err error
+	)
+	if command == Default {
+		cmd, err = newDefaultStringArgsCommand(description, commandFunc, args, results, resultsHandlers)
+	} else {
+		cmd, err = newStringArgsCommand(command, description, commandFunc, args, results, resultsHandlers)
+	}
+	if err != nil {
+		return err
+	}
+	return disp.updateCommands(func(reg *commandRegistry) error {
+		replaced, found := reg.comm[command]
+		if !found {
+			return fmt.Errorf("Command '%s': %w", command, ErrNotFound)
+		}
+		cmd.aliases = replaced.aliases
+		reg.setCommand(cmd)
+		return nil
+	})
+}
+
+// RemoveCommand removes the command with its aliases.
+// Calls of the command that are already dispatched are not affected.
+func (disp *StringArgsDispatcher) RemoveCommand(command string) error {
+	return disp.updateCommands(func(reg *commandRegistry) error {
+		cmd, found := reg.comm[command]
+		if !found {
+			return fmt.Errorf("Command '%s': %w", command, ErrNotFound)
+		}
+		reg.removeCommand(cmd)
+		return nil
+	})
+}
+
+func (disp *StringArgsDispatcher) MustRemoveCommand(command string) {
+	err := disp.RemoveCommand(command)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ReplaceCommands atomically replaces all commands and aliases
+// of the dispatcher with the ones of commands,
+// which can be used to reload a whole command set.
+// Other settings like loggers or enabled flags are not taken over.
+func (disp *StringArgsDispatcher) ReplaceCommands(commands *StringArgsDispatcher) {
+	disp.registryMutex.Lock()
+	defer disp.registryMutex.Unlock()
+
+	// Snapshots are never changed, so they can be shared
+	disp.registry.Store(commands.commands())
+}
+
 func (disp *StringArgsDispatcher) HasCommnd(command string) bool {
-	_, found := disp.comm[command]
+	_, found := disp.commands().comm[command]
 	return found
 }
 
 func (disp *StringArgsDispatcher) HasDefaultCommnd() bool {
-	_, found := disp.comm[Default]
+	_, found := disp.commands().comm[Default]
 	return found
 }
 
+// EnableOutputFlag enables the OutputFlag for all commands of the dispatcher.
+// If the flag is passed as command argument like --output=json,
+// then the results are written in the selected format from OutputFormats
+// to output instead of being passed to the registered ResultsHandler.
+// If output is nil, then os.Stdout will be used.
+// Flags after a "--" argument are passed as arguments to the command.
+func (disp *StringArgsDispatcher) EnableOutputFlag(output io.Writer) {
+	disp.flags.enableOutput(output)
+}
+
+// EnableQueryFlag enables the QueryFlag for all commands of the dispatcher.
+// If the flag is passed as command argument like --query='items[?status==failed].name',
+// then the Query is applied to the results before they are passed
+// to the ResultsHandler. See Query for the expression syntax.
+func (disp *StringArgsDispatcher) EnableQueryFlag() {
+	disp.flags.enableQuery()
+}
+
+// Use adds middleware that wraps the calls
+// of all command functions of the dispatcher.
+// The first Middleware is the outermost one.
+func (disp *StringArgsDispatcher) Use(middleware ...Middleware) {
+	disp.middleware = append(disp.middleware, middleware...)
+}
+
+// EnablePrefixMatching enables dispatching commands
+// by a prefix of their name or alias, like "dep" for "deploy",
+// if the prefix is unique among all names and aliases.
+func (disp *StringArgsDispatcher) EnablePrefixMatching() {
+	disp.prefixMatching = true
+}
+
+// EnableHelp enables the HelpCommand and the -h and --help flags
+// that write help pages to output instead of calling a command:
+//
+//	help            usage of all commands
+//	help <command>  help page of a command
+//	<command> -h    help page of a command
+//
+// Commands registered with the name HelpCommand take precedence.
+// If appName is empty, then the base name of os.Args[0] will be used.
+// If output is nil, then os.Stdout will be used.
+func (disp *StringArgsDispatcher) EnableHelp(appName string, output io.Writer) {
+	disp.help.enable(appName, output)
+}
+
+// EnableCompletion enables the hidden CompleteCommand
+// that writes completion candidates to output.
+// It is called by the scripts from WriteCompletionScript.
+// If output is nil, then os.Stdout will be used.
+func (disp *StringArgsDispatcher) EnableCompletion(output io.Writer) {
+	disp.completion.enable(output)
+}
+
+// SetArgCompleter sets the Completer for the argument arg of command
+// used by the CompleteCommand.
+func (disp *StringArgsDispatcher) SetArgCompleter(command, arg string, completer Completer) error {
+	return disp.updateCommands(func(reg *commandRegistry) error {
+		cmd, found := reg.lookup(command, disp.prefixMatching)
+		if !found {
+			return fmt.Errorf("Command '%s': %w", command, ErrNotFound)
+		}
+		hasArg := false
+		for _, a := range cmd.args.Args() {
+			hasArg = hasArg || a.Name == arg
+		}
+		if !hasArg {
+			return fmt.Errorf("Command '%s' has no argument '%s'", command, arg)
+		}
+		changed := *cmd
+		changed.completers = make(map[string]Completer, len(cmd.completers)+1)
+		for name, c := range cmd.completers {
+			changed.completers[name] = c
+		}
+		changed.completers[arg] = completer
+		reg.setCommand(&changed)
+		return nil
+	})
+}
+
+func (disp *StringArgsDispatcher) MustSetArgCompleter(command, arg string, completer Completer) {
+	err := disp.SetArgCompleter(command, arg, completer)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func (disp *StringArgsDispatcher) Dispatch(ctx context.Context, command string, args ...string) error {
-	cmd, found := disp.comm[command]
+	if disp.isCompleteCommand(command) {
+		return disp.writeCompletions(ctx, args)
+	}
+	flags, args, err := disp.flags.parse(args)
+	if err != nil {
+		return err
+	}
+	return disp.dispatch(ctx, command, args, flags)
+}
+
+func (disp *StringArgsDispatcher) dispatch(ctx context.Context, command string, args []string, flags invocationFlags) error {
+	cmd, found := disp.lookup(command)
+	if disp.help.enabled {
+		switch {
+		case !found && (command == HelpCommand || isHelpArg(command)):
+			return disp.writeHelp(args)
+		case found && hasHelpArg(args, cmd.declaresArgs()):
+			return writeCommandHelp(disp.help.writer, cmd.help(disp.help.appName, cmd.command))
+		}
+	}
 	if !found {
-		return ErrNotFound
+		return disp.notFound(command)
 	}
+	args = withoutArgsTerminator(args)
 	for _, logger := range disp.loggers {
-		logger.LogStringArgsCommand(command, args)
+		logger.LogStringArgsCommand(cmd.command, args)
 	}
-	return cmd.stringArgsFunc(ctx, args...)
+	ctx = WithMiddleware(WithCommand(ctx, cmd.command, cmd.description), disp.middleware...)
+	return cmd.call(ctx, args, disp.flags.resultsHandlers(flags, cmd.resultsHandlers))
 }
 
 func (disp *StringArgsDispatcher) MustDispatch(ctx context.Context, command string, args ...string) {
@@ -157,12 +500,48 @@ func (disp *StringArgsDispatcher) MustDispatchDefaultCommand() {
 }
 
 func (disp *StringArgsDispatcher) DispatchCombinedCommandAndArgs(ctx context.Context, commandAndArgs []string) (command string, err error) {
+	if len(commandAndArgs) > 0 && disp.isCompleteCommand(commandAndArgs[0]) {
+		return commandAndArgs[0], disp.writeCompletions(ctx, commandAndArgs[1:])
+	}
+	flags, commandAndArgs, err := disp.flags.parse(commandAndArgs)
+	if err != nil {
+		return "", err
+	}
 	if len(commandAndArgs) == 0 {
-		return Default, disp.DispatchDefaultCommand()
+		return Default, disp.dispatch(ctx, Default, nil, flags)
 	}
 	command = commandAndArgs[0]
 	args := commandAndArgs[1:]
-	return command, disp.Dispatch(ctx, command, args...)
+	return command, disp.dispatch(ctx, command, args, flags)
+}
+
+// DispatchCommandLineArgs dispatches the command and
+// its arguments from commandAndArgs like DispatchCombinedCommandAndArgs.
+func (disp *StringArgsDispatcher) DispatchCommandLineArgs(ctx context.Context, commandAndArgs []string) error {
+	_, err := disp.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+	return err
+}
+
+// DispatchCommandLine splits line into the command and its arguments
+// with SplitCommandLine and dispatches them like DispatchCombinedCommandAndArgs.
+// Errors from splitting line wrap a *CommandLineError.
+func (disp *StringArgsDispatcher) DispatchCommandLine(ctx context.Context, line string) (command string, err error) {
+	commandAndArgs, err := SplitCommandLine(line)
+	if err != nil {
+		return "", UsageError{Err: err}
+	}
+	return disp.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+}
+
+// DispatchCommandLineLookup is like DispatchCommandLine but replaces
+// variable references like ${NAME} in line with the values from lookup,
+// see SplitCommandLineLookup.
+func (disp *StringArgsDispatcher) DispatchCommandLineLookup(ctx context.Context, line string, lookup func(name string) (value string, found bool)) (command string, err error) {
+	commandAndArgs, err := SplitCommandLineLookup(line, lookup)
+	if err != nil {
+		return "", UsageError{Err: err}
+	}
+	return disp.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
 }
 
 func (disp *StringArgsDispatcher) MustDispatchCombinedCommandAndArgs(ctx context.Context, commandAndArgs []string) (command string) {
@@ -174,38 +553,141 @@ func (disp *StringArgsDispatcher) MustDispatchCombinedCommandAndArgs(ctx context
 }
 
 func (disp *StringArgsDispatcher) PrintCommands(appName string) {
-	list := make([]*stringArgsCommand, 0, len(disp.comm))
-	for _, cmd := range disp.comm {
+	disp.PrintCommandsTo(color.Output, appName)
+}
+
+// PrintCommandsTo prints the usage of all commands
+// to writer using the CommandsHelpTemplate.
+func (disp *StringArgsDispatcher) PrintCommandsTo(writer io.Writer, appName string) {
+	_ = writeCommandsHelp(writer, disp.commandsHelp(appName))
+}
+
+func (disp *StringArgsDispatcher) commandsHelp(appName string) *CommandsHelp {
+	reg := disp.commands()
+	list := make([]*stringArgsCommand, 0, len(reg.comm))
+	for _, cmd := range reg.comm {
 		list = append(list, cmd)
 	}
 	sort.Slice(list, func(i, j int) bool {
 		return list[i].command < list[j].command
 	})
 
+	help := &CommandsHelp{AppName: appName}
 	for _, cmd := range list {
-		CommandUsageColor.Printf("  %s %s %s\n", appName, cmd.command, cmd.args)
-		if cmd.description != "" {
-			CommandDescriptionColor.Printf("      %s\n", cmd.description)
+		help.Commands = append(help.Commands, cmd.help(appName, cmd.command))
+	}
+	return help
+}
+
+// writeHelp writes the usage of all commands if args are empty,
+// else the help page of the command args[0].
+func (disp *StringArgsDispatcher) writeHelp(args []string) error {
+	if len(args) == 0 {
+		return writeCommandsHelp(disp.help.writer, disp.commandsHelp(disp.help.appName))
+	}
+	cmd, found := disp.lookup(args[0])
+	if !found {
+		return helpCommandNotFound(args[0])
+	}
+	return writeCommandHelp(disp.help.writer, cmd.help(disp.help.appName, cmd.command))
+}
+
+func (disp *StringArgsDispatcher) PrintCommandsUsageIntro(appName string, output io.Writer) {
+	if len(disp.commands().comm) > 0 {
+		fmt.Fprint(output, "Commands:\n")
+		disp.PrintCommandsTo(output, appName)
+		fmt.Fprint(output, "Flags:\n")
+	}
+}
+
+func (disp *StringArgsDispatcher) isCompleteCommand(command string) bool {
+	_, registered := disp.commands().comm[command]
+	return disp.completion.enabled && command == CompleteCommand && !registered
+}
+
+func (disp *StringArgsDispatcher) writeCompletions(ctx context.Context, words []string) error {
+	candidates, err := disp.Complete(ctx, words)
+	if err != nil {
+		return err
+	}
+	return disp.completion.write(candidates)
+}
+
+// Complete returns the completion candidates for the last
+// of the command line words, which may be an empty string.
+func (disp *StringArgsDispatcher) Complete(ctx context.Context, words []string) ([]string, error) {
+	preceding, current, candidates, done := splitCompletionWords(&disp.flags, &disp.help, words)
+	if done {
+		return candidates, nil
+	}
+	return disp.complete(ctx, preceding, current)
+}
+
+// complete returns the candidates for current
+// following the command and argument words preceding.
+func (disp *StringArgsDispatcher) complete(ctx context.Context, preceding []string, current string) ([]string, error) {
+	reg := disp.commands()
+	_, hasHelpCommand := reg.comm[HelpCommand]
+	isHelp := disp.help.enabled && !hasHelpCommand
+	if len(preceding) == 0 {
+		candidates := reg.commandNames(current)
+		if isHelp && strings.HasPrefix(HelpCommand, current) {
+			candidates = append(candidates, HelpCommand)
 		}
-		hasAnyArgDesc := false
-		for _, arg := range cmd.args.Args() {
-			if arg.Description != "" {
-				hasAnyArgDesc = true
+		if cmd, found := reg.comm[Default]; found {
+			argCandidates, err := cmd.completeArg(ctx, 0, current)
+			if err != nil {
+				return nil, err
 			}
+			candidates = append(candidates, argCandidates...)
 		}
-		if hasAnyArgDesc {
-			for _, arg := range cmd.args.Args() {
-				CommandDescriptionColor.Printf("          <%s:%s> %s\n", arg.Name, arg.Type, arg.Description)
-			}
+		return candidates, nil
+	}
+	if isHelp && preceding[0] == HelpCommand {
+		if len(preceding) > 1 {
+			return nil, nil
 		}
-		CommandDescriptionColor.Println()
+		return reg.commandNames(current), nil
+	}
+	cmd, found := reg.lookup(preceding[0], disp.prefixMatching)
+	argIndex := len(withoutArgsTerminator(preceding[1:]))
+	if !found {
+		cmd, found = reg.comm[Default]
+		argIndex = len(withoutArgsTerminator(preceding))
+	}
+	if !found {
+		return nil, nil
 	}
+	return cmd.completeArg(ctx, argIndex, current)
 }
 
-func (disp *StringArgsDispatcher) PrintCommandsUsageIntro(appName string, output io.Writer) {
-	if len(disp.comm) > 0 {
-		fmt.Fprint(output, "Commands:\n")
-		disp.PrintCommands(appName)
-		fmt.Fprint(output, "Flags:\n")
+// commandNames returns the sorted names and aliases
+// of all commands except Default starting with prefix.
+func (disp *StringArgsDispatcher) commandNames(prefix string) []string {
+	return disp.commands().commandNames(prefix)
+}
+
+// lookup returns the command registered with the name
+// or alias command, or if prefix matching is enabled,
+// the command with a name or alias uniquely starting with command.
+func (disp *StringArgsDispatcher) lookup(command string) (cmd *stringArgsCommand, found bool) {
+	return disp.commands().lookup(command, disp.prefixMatching)
+}
+
+// notFound returns ErrNotFound for the Default command,
+// else a CommandNotFoundError with suggestions for command.
+// Command and suggestions are prefixed with the super command
+// of the dispatcher.
+func (disp *StringArgsDispatcher) notFound(command string) error {
+	if command == Default {
+		return ErrNotFound
+	}
+	suggestions := suggestCommands(command, disp.commandNames(""))
+	for i, suggestion := range suggestions {
+		suggestions[i] = joinSuperCommand(disp.superCommand, suggestion)
+	}
+	return CommandNotFoundError{
+		Command:     joinSuperCommand(disp.superCommand, command),
+		Suggestions: suggestions,
 	}
 }
@@ -0,0 +1,76 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StringArgsDispatcher_OutputFlag(t *testing.T) {
+	var (
+		commandArgsDef TestCommandArgsDef
+		registeredBuf  bytes.Buffer
+		outputBuf      bytes.Buffer
+	)
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("struct", "", CommandFuncStructResult, &commandArgsDef, PrintTo(&registeredBuf))
+	disp.EnableOutputFlag(&outputBuf)
+
+	err := disp.Dispatch(context.Background(), "struct", "1", "str", "true")
+	assert.NoError(t, err)
+	assert.Equal(t, string(defaultResultStructJSON), registeredBuf.String(), "registered handler without flag")
+	assert.Empty(t, outputBuf.String())
+
+	registeredBuf.Reset()
+	_, err = disp.DispatchCombinedCommandAndArgs(context.Background(), []string{"--output", "csv", "struct", "1", "str", "true"})
+	assert.NoError(t, err)
+	assert.Empty(t, registeredBuf.String(), "registered handler not used with flag")
+	assert.Equal(t, "ResultCode,ResultMessage\n404,not found\n", outputBuf.String())
+
+	outputBuf.Reset()
+	err = disp.Dispatch(context.Background(), "struct", "1", "--output=yaml", "str", "true")
+	assert.NoError(t, err)
+	assert.Equal(t, "ResultCode: 404\nResultMessage: not found\n", outputBuf.String())
+
+	err = disp.Dispatch(context.Background(), "struct", "1", "str", "true", "--output=xml")
+	assert.Error(t, err, "unknown output format")
+
+	super := NewSuperStringArgsDispatcher()
+	super.MustAddSuperCommand("test").MustAddCommand("struct", "", CommandFuncStructResult, &commandArgsDef, PrintTo(&registeredBuf))
+	super.EnableOutputFlag(&outputBuf)
+	outputBuf.Reset()
+	_, _, err = super.DispatchCombinedCommandAndArgs(context.Background(), []string{"test", "struct", "--output", "json", "1", "str", "true"})
+	assert.NoError(t, err)
+	assert.Equal(t, string(defaultResultStructJSON)+"\n", outputBuf.String())
+}
+
+func Test_StringArgsDispatcher_FlagsTerminator(t *testing.T) {
+	var (
+		commandArgsDef TestCommandArgsDef
+		passedStr      string
+		outputBuf      bytes.Buffer
+	)
+	disp := NewStringArgsDispatcher()
+	disp.MustAddCommand("echo", "", func(int0 int, str1 string, bool2 bool) string {
+		passedStr = str1
+		return str1
+	}, &commandArgsDef)
+	disp.EnableOutputFlag(&outputBuf)
+
+	err := disp.Dispatch(context.Background(), "echo", "--output", "json", "1", "--", "--output=yaml", "true")
+	assert.NoError(t, err)
+	assert.Equal(t, "--output=yaml", passedStr, "flag after terminator passed as argument")
+	assert.Equal(t, "\"--output=yaml\"\n", outputBuf.String())
+
+	outputBuf.Reset()
+	_, err = disp.DispatchCombinedCommandAndArgs(context.Background(), []string{"echo", "1", "--", "--output", "true"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--output", passedStr)
+	assert.Empty(t, outputBuf.String(), "no output flag before terminator")
+
+	err = disp.Dispatch(context.Background(), "echo", "--output", "json", "1", "x", "--output=yaml", "true")
+	assert.True(t, IsUsageError(err), "duplicate flag")
+	assert.EqualError(t, err, "flag --output passed more than once")
+}
@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fatih/color"
 )
 
 type SuperCommandNotFound string
@@ -13,16 +18,50 @@ func (s SuperCommandNotFound) Error() string {
 	return fmt.Sprintf("Super command '%s' not found", string(s))
 }
 
+// SuperStringArgsDispatcher dispatches commands of super commands
+// like "super command args...".
+// Super commands can be added and removed
+// concurrently to dispatching them.
 type SuperStringArgsDispatcher struct {
-	sub     map[string]*StringArgsDispatcher
-	loggers []StringArgsCommandLogger
+	sub            atomic.Pointer[map[string]*StringArgsDispatcher]
+	subMutex       sync.Mutex
+	loggers        []StringArgsCommandLogger
+	flags          dispatchFlags
+	help           helpFlag
+	completion     completion
+	prefixMatching bool
+	middleware     []Middleware
 }
 
 func NewSuperStringArgsDispatcher(loggers ...StringArgsCommandLogger) *SuperStringArgsDispatcher {
-	return &SuperStringArgsDispatcher{
-		sub:     make(map[string]*StringArgsDispatcher),
-		loggers: loggers,
+	disp := &SuperStringArgsDispatcher{loggers: loggers}
+	disp.sub.Store(&map[string]*StringArgsDispatcher{})
+	return disp
+}
+
+// superCommands returns the current snapshot of the super commands
+// which must not be changed, see updateSuperCommands.
+func (disp *SuperStringArgsDispatcher) superCommands() map[string]*StringArgsDispatcher {
+	return *disp.sub.Load()
+}
+
+// updateSuperCommands calls change with a copy of the super commands
+// that replaces them if change returns no error.
+func (disp *SuperStringArgsDispatcher) updateSuperCommands(change func(sub map[string]*StringArgsDispatcher) error) error {
+	disp.subMutex.Lock()
+	defer disp.subMutex.Unlock()
+
+	current := disp.superCommands()
+	sub := make(map[string]*StringArgsDispatcher, len(current)+1)
+	for superCommand, subDisp := range current {
+		sub[superCommand] = subDisp
 	}
+	err := change(sub)
+	if err != nil {
+		return err
+	}
+	disp.sub.Store(&sub)
+	return nil
 }
 
 func (disp *SuperStringArgsDispatcher) AddSuperCommand(superCommand string) (subDisp *StringArgsDispatcher, err error) {
@@ -31,14 +70,42 @@ func (disp *SuperStringArgsDispatcher) AddSuperCommand(superCommand string) (sub
 			return nil, fmt.Errorf("Command '%s': %w", superCommand, err)
 		}
 	}
-	if _, exists := disp.sub[superCommand]; exists {
-		return nil, fmt.Errorf("super command already added: '%s'", superCommand)
-	}
 	subDisp = NewStringArgsDispatcher(disp.loggers...)
-	disp.sub[superCommand] = subDisp
+	subDisp.flags = disp.flags
+	subDisp.prefixMatching = disp.prefixMatching
+	subDisp.superCommand = superCommand
+	err = disp.updateSuperCommands(func(sub map[string]*StringArgsDispatcher) error {
+		if _, exists := sub[superCommand]; exists {
+			return fmt.Errorf("super command already added: '%s'", superCommand)
+		}
+		sub[superCommand] = subDisp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return subDisp, nil
 }
 
+// RemoveSuperCommand removes the super command with all its commands.
+// Calls of the commands that are already dispatched are not affected.
+func (disp *SuperStringArgsDispatcher) RemoveSuperCommand(superCommand string) error {
+	return disp.updateSuperCommands(func(sub map[string]*StringArgsDispatcher) error {
+		if _, exists := sub[superCommand]; !exists {
+			return SuperCommandNotFound(superCommand)
+		}
+		delete(sub, superCommand)
+		return nil
+	})
+}
+
+func (disp *SuperStringArgsDispatcher) MustRemoveSuperCommand(superCommand string) {
+	err := disp.RemoveSuperCommand(superCommand)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func (disp *SuperStringArgsDispatcher) MustAddSuperCommand(superCommand string) (subDisp *StringArgsDispatcher) {
 	subDisp, err := disp.AddSuperCommand(superCommand)
 	if err != nil {
@@ -62,7 +129,7 @@ func (disp *SuperStringArgsDispatcher) MustAddDefaultCommand(description string,
 	}
 }
 func (disp *SuperStringArgsDispatcher) HasCommnd(superCommand string) bool {
-	sub, ok := disp.sub[superCommand]
+	sub, ok := disp.superCommands()[superCommand]
 	if !ok {
 		return false
 	}
@@ -70,19 +137,86 @@ func (disp *SuperStringArgsDispatcher) HasCommnd(superCommand string) bool {
 }
 
 func (disp *SuperStringArgsDispatcher) HasSubCommnd(superCommand, command string) bool {
-	sub, ok := disp.sub[superCommand]
+	sub, ok := disp.superCommands()[superCommand]
 	if !ok {
 		return false
 	}
 	return sub.HasCommnd(command)
 }
 
+// EnableOutputFlag enables the OutputFlag for all commands of the dispatcher.
+// If the flag is passed as command argument like --output=json,
+// then the results are written in the selected format from OutputFormats
+// to output instead of being passed to the registered ResultsHandler.
+// If output is nil, then os.Stdout will be used.
+func (disp *SuperStringArgsDispatcher) EnableOutputFlag(output io.Writer) {
+	disp.flags.enableOutput(output)
+	for _, sub := range disp.superCommands() {
+		sub.flags = disp.flags
+	}
+}
+
+// EnableQueryFlag enables the QueryFlag for all commands of the dispatcher.
+// If the flag is passed as command argument like --query='items[?status==failed].name',
+// then the Query is applied to the results before they are passed
+// to the ResultsHandler. See Query for the expression syntax.
+func (disp *SuperStringArgsDispatcher) EnableQueryFlag() {
+	disp.flags.enableQuery()
+	for _, sub := range disp.superCommands() {
+		sub.flags = disp.flags
+	}
+}
+
+// Use adds middleware that wraps the calls of all command
+// functions of all super commands of the dispatcher
+// around the Middleware of the super command dispatchers.
+// The first Middleware is the outermost one.
+func (disp *SuperStringArgsDispatcher) Use(middleware ...Middleware) {
+	disp.middleware = append(disp.middleware, middleware...)
+}
+
+// EnablePrefixMatching enables dispatching the commands
+// of all super commands by a unique prefix of their name or alias,
+// see StringArgsDispatcher.EnablePrefixMatching.
+// Super commands have to be passed with their full name.
+func (disp *SuperStringArgsDispatcher) EnablePrefixMatching() {
+	disp.prefixMatching = true
+	for _, sub := range disp.superCommands() {
+		sub.prefixMatching = true
+	}
+}
+
+// EnableHelp enables the HelpCommand and the -h and --help flags
+// that write help pages to output instead of calling a command
+// when dispatching with DispatchCombinedCommandAndArgs:
+//
+//	help                          usage of all commands
+//	help <super>                  usage of all commands of a super command
+//	help <super> <command>        help page of a command
+//	<super> <command> -h          help page of a command
+//
+// Super commands registered with the name HelpCommand take precedence.
+// If appName is empty, then the base name of os.Args[0] will be used.
+// If output is nil, then os.Stdout will be used.
+func (disp *SuperStringArgsDispatcher) EnableHelp(appName string, output io.Writer) {
+	disp.help.enable(appName, output)
+}
+
+// EnableCompletion enables the hidden CompleteCommand
+// that writes completion candidates to output
+// when dispatching with DispatchCombinedCommandAndArgs.
+// It is called by the scripts from WriteCompletionScript.
+// If output is nil, then os.Stdout will be used.
+func (disp *SuperStringArgsDispatcher) EnableCompletion(output io.Writer) {
+	disp.completion.enable(output)
+}
+
 func (disp *SuperStringArgsDispatcher) Dispatch(ctx context.Context, superCommand, command string, args ...string) error {
-	sub, ok := disp.sub[superCommand]
+	sub, ok := disp.superCommands()[superCommand]
 	if !ok {
-		return SuperCommandNotFound(superCommand)
+		return disp.superCommandNotFound(superCommand)
 	}
-	return sub.Dispatch(ctx, command, args...)
+	return sub.Dispatch(WithMiddleware(ctx, disp.middleware...), command, args...)
 }
 
 func (disp *SuperStringArgsDispatcher) MustDispatch(ctx context.Context, superCommand, command string, args ...string) {
@@ -104,6 +238,21 @@ func (disp *SuperStringArgsDispatcher) MustDispatchDefaultCommand() {
 }
 
 func (disp *SuperStringArgsDispatcher) DispatchCombinedCommandAndArgs(ctx context.Context, commandAndArgs []string) (superCommand, command string, err error) {
+	subs := disp.superCommands()
+	if len(commandAndArgs) > 0 && disp.completion.enabled && commandAndArgs[0] == CompleteCommand {
+		if _, isSuper := subs[CompleteCommand]; !isSuper {
+			return CompleteCommand, Default, disp.writeCompletions(ctx, commandAndArgs[1:])
+		}
+	}
+	flags, commandAndArgs, err := disp.flags.parse(commandAndArgs)
+	if err != nil {
+		return "", "", err
+	}
+	if disp.help.enabled && len(commandAndArgs) > 0 {
+		if _, isSuper := subs[commandAndArgs[0]]; !isSuper && (commandAndArgs[0] == HelpCommand || isHelpArg(commandAndArgs[0])) {
+			return commandAndArgs[0], Default, disp.writeHelp(commandAndArgs[1:])
+		}
+	}
 	var args []string
 	switch len(commandAndArgs) {
 	case 0:
@@ -114,7 +263,7 @@ func (disp *SuperStringArgsDispatcher) DispatchCombinedCommandAndArgs(ctx contex
 		command = Default
 	default:
 		superCommand = commandAndArgs[0]
-		sub, ok := disp.sub[superCommand]
+		sub, ok := subs[superCommand]
 		if ok && sub.HasDefaultCommnd() {
 			command = Default
 			args = commandAndArgs[1:]
@@ -123,7 +272,50 @@ func (disp *SuperStringArgsDispatcher) DispatchCombinedCommandAndArgs(ctx contex
 			args = commandAndArgs[2:]
 		}
 	}
-	return superCommand, command, disp.Dispatch(ctx, superCommand, command, args...)
+	sub, ok := subs[superCommand]
+	if !ok {
+		return superCommand, command, disp.superCommandNotFound(superCommand)
+	}
+	if disp.help.enabled {
+		cmd, found := sub.lookup(command)
+		switch {
+		case !found && isHelpArg(command):
+			return superCommand, command, disp.writeHelp([]string{superCommand})
+		case found && hasHelpArg(args, cmd.declaresArgs()):
+			return superCommand, command, writeCommandHelp(disp.help.writer, cmd.help(disp.help.appName, joinSuperCommand(superCommand, cmd.command)))
+		}
+	}
+	return superCommand, command, sub.dispatch(WithMiddleware(ctx, disp.middleware...), command, args, flags)
+}
+
+// DispatchCommandLineArgs dispatches the super command, command and
+// its arguments from commandAndArgs like DispatchCombinedCommandAndArgs.
+func (disp *SuperStringArgsDispatcher) DispatchCommandLineArgs(ctx context.Context, commandAndArgs []string) error {
+	_, _, err := disp.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+	return err
+}
+
+// DispatchCommandLine splits line into the super command, command and
+// its arguments with SplitCommandLine and dispatches them
+// like DispatchCombinedCommandAndArgs.
+// Errors from splitting line wrap a *CommandLineError.
+func (disp *SuperStringArgsDispatcher) DispatchCommandLine(ctx context.Context, line string) (superCommand, command string, err error) {
+	commandAndArgs, err := SplitCommandLine(line)
+	if err != nil {
+		return "", "", UsageError{Err: err}
+	}
+	return disp.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+}
+
+// DispatchCommandLineLookup is like DispatchCommandLine but replaces
+// variable references like ${NAME} in line with the values from lookup,
+// see SplitCommandLineLookup.
+func (disp *SuperStringArgsDispatcher) DispatchCommandLineLookup(ctx context.Context, line string, lookup func(name string) (value string, found bool)) (superCommand, command string, err error) {
+	commandAndArgs, err := SplitCommandLineLookup(line, lookup)
+	if err != nil {
+		return "", "", UsageError{Err: err}
+	}
+	return disp.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
 }
 
 func (disp *SuperStringArgsDispatcher) MustDispatchCombinedCommandAndArgs(ctx context.Context, commandAndArgs []string) (superCommand, command string) {
@@ -135,14 +327,29 @@ func (disp *SuperStringArgsDispatcher) MustDispatchCombinedCommandAndArgs(ctx co
 }
 
 func (disp *SuperStringArgsDispatcher) PrintCommands(appName string) {
+	disp.PrintCommandsTo(color.Output, appName)
+}
+
+// PrintCommandsTo prints the usage of all commands
+// to writer using the CommandsHelpTemplate.
+func (disp *SuperStringArgsDispatcher) PrintCommandsTo(writer io.Writer, appName string) {
+	_ = writeCommandsHelp(writer, disp.commandsHelp(appName, nil))
+}
+
+// commandsHelp returns the help for the commands of all
+// super commands or only the one pointed to by superCommand.
+func (disp *SuperStringArgsDispatcher) commandsHelp(appName string, superCommand *string) *CommandsHelp {
 	type superCmd struct {
 		super string
 		cmd   *stringArgsCommand
 	}
 
 	var list []superCmd
-	for super, sub := range disp.sub {
-		for _, cmd := range sub.comm {
+	for super, sub := range disp.superCommands() {
+		if superCommand != nil && super != *superCommand {
+			continue
+		}
+		for _, cmd := range sub.commands().comm {
 			list = append(list, superCmd{super: super, cmd: cmd})
 		}
 	}
@@ -153,36 +360,137 @@ func (disp *SuperStringArgsDispatcher) PrintCommands(appName string) {
 		return list[i].super < list[j].super
 	})
 
+	help := &CommandsHelp{AppName: appName}
 	for i := range list {
 		cmd := list[i].cmd
-		command := list[i].super
-		if cmd.command != Default {
-			command += " " + cmd.command
-		}
+		help.Commands = append(help.Commands, cmd.help(appName, joinSuperCommand(list[i].super, cmd.command)))
+	}
+	return help
+}
 
-		CommandUsageColor.Printf("  %s %s %s\n", appName, command, cmd.args)
-		if cmd.description != "" {
-			CommandDescriptionColor.Printf("      %s\n", cmd.description)
+// writeHelp writes the usage of all commands if args are empty,
+// the usage of all commands of the super command args[0],
+// or the help page of the command args[1] of the super command args[0].
+func (disp *SuperStringArgsDispatcher) writeHelp(args []string) error {
+	if len(args) == 0 {
+		return writeCommandsHelp(disp.help.writer, disp.commandsHelp(disp.help.appName, nil))
+	}
+	superCommand := args[0]
+	sub, ok := disp.superCommands()[superCommand]
+	if !ok {
+		return helpCommandNotFound(superCommand)
+	}
+	if len(args) == 1 {
+		return writeCommandsHelp(disp.help.writer, disp.commandsHelp(disp.help.appName, &superCommand))
+	}
+	cmd, found := sub.lookup(args[1])
+	if !found {
+		return helpCommandNotFound(joinSuperCommand(superCommand, args[1]))
+	}
+	return writeCommandHelp(disp.help.writer, cmd.help(disp.help.appName, joinSuperCommand(superCommand, cmd.command)))
+}
+
+func joinSuperCommand(superCommand, command string) string {
+	if command == Default {
+		return superCommand
+	}
+	if superCommand == Default {
+		return command
+	}
+	return superCommand + " " + command
+}
+
+func (disp *SuperStringArgsDispatcher) PrintCommandsUsageIntro(appName string, output io.Writer) {
+	if len(disp.superCommands()) > 0 {
+		fmt.Fprint(output, "Commands:\n")
+		disp.PrintCommandsTo(output, appName)
+		fmt.Fprint(output, "Flags:\n")
+	}
+}
+
+func (disp *SuperStringArgsDispatcher) writeCompletions(ctx context.Context, words []string) error {
+	candidates, err := disp.Complete(ctx, words)
+	if err != nil {
+		return err
+	}
+	return disp.completion.write(candidates)
+}
+
+// Complete returns the completion candidates for the last
+// of the command line words, which may be an empty string.
+func (disp *SuperStringArgsDispatcher) Complete(ctx context.Context, words []string) ([]string, error) {
+	preceding, current, candidates, done := splitCompletionWords(&disp.flags, &disp.help, words)
+	if done {
+		return candidates, nil
+	}
+	return disp.complete(ctx, preceding, current)
+}
+
+// complete returns the candidates for current following
+// the super command, command, and argument words preceding.
+func (disp *SuperStringArgsDispatcher) complete(ctx context.Context, preceding []string, current string) ([]string, error) {
+	subs := disp.superCommands()
+	_, hasHelpCommand := subs[HelpCommand]
+	isHelp := disp.help.enabled && !hasHelpCommand
+	if len(preceding) == 0 {
+		candidates := disp.superCommandNames(current)
+		if isHelp && strings.HasPrefix(HelpCommand, current) {
+			candidates = append(candidates, HelpCommand)
 		}
-		hasAnyArgDesc := false
-		for _, arg := range cmd.args.Args() {
-			if arg.Description != "" {
-				hasAnyArgDesc = true
+		if sub, ok := subs[Default]; ok {
+			subCandidates, err := sub.complete(ctx, nil, current)
+			if err != nil {
+				return nil, err
 			}
+			candidates = append(candidates, subCandidates...)
 		}
-		if hasAnyArgDesc {
-			for _, arg := range cmd.args.Args() {
-				CommandDescriptionColor.Printf("          <%s:%s> %s\n", arg.Name, arg.Type, arg.Description)
+		return candidates, nil
+	}
+	if isHelp && preceding[0] == HelpCommand {
+		switch len(preceding) {
+		case 1:
+			return disp.superCommandNames(current), nil
+		case 2:
+			if sub, ok := subs[preceding[1]]; ok {
+				return sub.commandNames(current), nil
 			}
 		}
-		CommandDescriptionColor.Println()
+		return nil, nil
+	}
+	sub, ok := subs[preceding[0]]
+	if !ok {
+		sub, ok = subs[Default]
+		if !ok {
+			return nil, nil
+		}
+		return sub.complete(ctx, preceding, current)
 	}
+	return sub.complete(ctx, preceding[1:], current)
 }
 
-func (disp *SuperStringArgsDispatcher) PrintCommandsUsageIntro(appName string, output io.Writer) {
-	if len(disp.sub) > 0 {
-		fmt.Fprint(output, "Commands:\n")
-		disp.PrintCommands(appName)
-		fmt.Fprint(output, "Flags:\n")
+// superCommandNotFound returns ErrNotFound for the Default super command,
+// else a CommandNotFoundError wrapping SuperCommandNotFound
+// with suggestions for superCommand.
+func (disp *SuperStringArgsDispatcher) superCommandNotFound(superCommand string) error {
+	if superCommand == Default {
+		return ErrNotFound
+	}
+	return CommandNotFoundError{
+		Command:     superCommand,
+		Suggestions: suggestCommands(superCommand, disp.superCommandNames("")),
+		Err:         SuperCommandNotFound(superCommand),
+	}
+}
+
+// superCommandNames returns the sorted names of all
+// super commands except Default starting with prefix.
+func (disp *SuperStringArgsDispatcher) superCommandNames(prefix string) []string {
+	var names []string
+	for superCommand := range disp.superCommands() {
+		if superCommand != Default && strings.HasPrefix(superCommand, prefix) {
+			names = append(names, superCommand)
+		}
 	}
+	sort.Strings(names)
+	return names
 }
@@ -0,0 +1,787 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// CommandTree dispatches commands organized as a tree of nodes
+// like "app cluster node drain <name>".
+// Every node can have sub command nodes, a default command,
+// and persistent arguments that are passed as --name=value flags
+// to all commands of the node and its sub nodes.
+//
+// Command lines are resolved with the following rules:
+//
+//   - enabled flags like --output and the persistent arguments
+//     of the passed nodes are removed from the command line
+//   - starting at the root, the next word is resolved as sub command
+//     if it is the name or alias of a sub node of the current node,
+//     or a unique prefix of one if prefix matching is enabled
+//   - the word "--" ends the resolving of sub commands
+//   - the default command of the resolved node is called
+//     with the remaining words as arguments
+//
+// So sub command names always take precedence over the
+// arguments of a default command, use "--" to pass
+// an argument with the name of a sub command.
+type CommandTree struct {
+	name        string
+	description string
+	parent      *CommandTree
+	children    map[string]*CommandTree
+	aliases     map[string]*CommandTree
+	aliasNames  []string
+	command     *stringArgsCommand
+	persistent  Args
+	middleware  []Middleware
+
+	// used only by the root node
+	loggers        []StringArgsCommandLogger
+	flags          dispatchFlags
+	help           helpFlag
+	completion     completion
+	prefixMatching bool
+}
+
+// NewCommandTree returns the root node of a new CommandTree
+func NewCommandTree(loggers ...StringArgsCommandLogger) *CommandTree {
+	return &CommandTree{
+		children: make(map[string]*CommandTree),
+		aliases:  make(map[string]*CommandTree),
+		loggers:  loggers,
+	}
+}
+
+// CommandTreeFromDispatcher returns a CommandTree with
+// the commands of disp as sub nodes of the root and
+// the Default command of disp as default command of the root.
+// Loggers, aliases, and enabled features are also taken over.
+func CommandTreeFromDispatcher(disp *StringArgsDispatcher) (*CommandTree, error) {
+	tree := NewCommandTree(disp.loggers...)
+	tree.flags = disp.flags
+	tree.help = disp.help
+	tree.completion = disp.completion
+	tree.prefixMatching = disp.prefixMatching
+	err := tree.addDispatcherCommands(disp)
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// CommandTreeFromSuperDispatcher returns a CommandTree with
+// a node for every super command of disp that has
+// the commands of the super command as sub nodes.
+// The commands of the Default super command are added
+// to the root of the tree.
+// Loggers, aliases, and enabled features are also taken over.
+func CommandTreeFromSuperDispatcher(disp *SuperStringArgsDispatcher) (*CommandTree, error) {
+	tree := NewCommandTree(disp.loggers...)
+	tree.flags = disp.flags
+	tree.help = disp.help
+	tree.completion = disp.completion
+	tree.prefixMatching = disp.prefixMatching
+	for superCommand, sub := range disp.superCommands() {
+		node := tree
+		if superCommand != Default {
+			var err error
+			node, err = tree.AddNode(superCommand, "")
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := node.addDispatcherCommands(sub)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
+
+func (t *CommandTree) addDispatcherCommands(disp *StringArgsDispatcher) error {
+	reg := disp.commands()
+	for command, cmd := range reg.comm {
+		if command == Default {
+			if t.command != nil {
+				return fmt.Errorf("Default command of '%s' already added", t.path())
+			}
+			t.command = cmd
+			continue
+		}
+		node, err := t.AddNode(command, cmd.description)
+		if err != nil {
+			return err
+		}
+		node.command = cmd
+	}
+	for alias, cmd := range reg.aliases {
+		err := t.AddAlias(cmd.command, alias)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the node which is empty for the root
+func (t *CommandTree) Name() string {
+	return t.name
+}
+
+// Parent returns the parent node or nil for the root
+func (t *CommandTree) Parent() *CommandTree {
+	return t.parent
+}
+
+// Node returns the node reached by following
+// the sub command names or aliases of path
+// or nil if there is no such node.
+func (t *CommandTree) Node(path ...string) *CommandTree {
+	node := t
+	for _, name := range path {
+		child, found := node.children[name]
+		if !found {
+			child, found = node.aliases[name]
+		}
+		if !found {
+			return nil
+		}
+		node = child
+	}
+	return node
+}
+
+// AddNode adds a sub command node without a command.
+// Use AddDefaultCommand of the returned node to add a command
+// that is called if no sub command of the node is passed.
+func (t *CommandTree) AddNode(name, description string) (*CommandTree, error) {
+	if err := checkCommandChars(name); err != nil {
+		return nil, fmt.Errorf("Command '%s': %w", joinSuperCommand(t.path(), name), err)
+	}
+	if _, exists := t.children[name]; exists {
+		return nil, fmt.Errorf("Command '%s' already added", joinSuperCommand(t.path(), name))
+	}
+	if node, exists := t.aliases[name]; exists {
+		return nil, fmt.Errorf("Command '%s' already added as alias of '%s'", joinSuperCommand(t.path(), name), node.path())
+	}
+	node := &CommandTree{
+		name:        name,
+		description: description,
+		parent:      t,
+		children:    make(map[string]*CommandTree),
+		aliases:     make(map[string]*CommandTree),
+	}
+	t.children[name] = node
+	return node, nil
+}
+
+func (t *CommandTree) MustAddNode(name, description string) *CommandTree {
+	node, err := t.AddNode(name, description)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// AddCommand adds a sub command node with a command
+// that calls commandFunc with the arguments following name.
+func (t *CommandTree) AddCommand(name, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
+	return t.AddCommandWithResults(name, description, commandFunc, args, nil, resultsHandlers...)
+}
+
+func (t *CommandTree) MustAddCommand(name, description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) {
+	err := t.AddCommand(name, description, commandFunc, args, resultsHandlers...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// AddCommandWithResults adds a command like AddCommand
+// with an additional results definition that names
+// the result values of commandFunc.
+// results can be nil for commands without named results.
+func (t *CommandTree) AddCommandWithResults(name, description string, commandFunc interface{}, args Args, results Results, resultsHandlers ...ResultsHandler) error {
+	cmd, err := newStringArgsCommand(name, description, commandFunc, args, results, resultsHandlers)
+	if err != nil {
+		return err
+	}
+	node, err := t.AddNode(name, description)
+	if err != nil {
+		return err
+	}
+	node.command = cmd
+	return nil
+}
+
+// AddDefaultCommand sets the command of the node that is called
+// if no sub command of the node is passed.
+func (t *CommandTree) AddDefaultCommand(description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) error {
+	if t.command != nil {
+		return fmt.Errorf("Default command of '%s' already added", t.path())
+	}
+	cmd := &stringArgsCommand{
+		command:         t.name,
+		description:     description,
+		args:            args,
+		commandFunc:     commandFunc,
+		resultsHandlers: resultsHandlers,
+	}
+	err := cmd.initFuncs()
+	if err != nil {
+		return fmt.Errorf("Default command of '%s': %w", t.path(), err)
+	}
+	t.command = cmd
+	return nil
+}
+
+func (t *CommandTree) MustAddDefaultCommand(description string, commandFunc interface{}, args Args, resultsHandlers ...ResultsHandler) {
+	err := t.AddDefaultCommand(description, commandFunc, args, resultsHandlers...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// AddAlias adds aliases as alternative names
+// for the sub command node name.
+func (t *CommandTree) AddAlias(name string, aliases ...string) error {
+	node, found := t.children[name]
+	if !found {
+		return fmt.Errorf("Command '%s': %w", joinSuperCommand(t.path(), name), ErrNotFound)
+	}
+	for _, alias := range aliases {
+		if _, exists := t.children[alias]; exists {
+			return fmt.Errorf("Alias '%s' of command '%s' already added as command", alias, node.path())
+		}
+		if other, exists := t.aliases[alias]; exists {
+			return fmt.Errorf("Alias '%s' of command '%s' already added for command '%s'", alias, node.path(), other.path())
+		}
+		if err := checkCommandChars(alias); err != nil {
+			return fmt.Errorf("Alias '%s' of command '%s': %w", alias, node.path(), err)
+		}
+		t.aliases[alias] = node
+		node.aliasNames = append(node.aliasNames, alias)
+	}
+	return nil
+}
+
+func (t *CommandTree) MustAddAlias(name string, aliases ...string) {
+	err := t.AddAlias(name, aliases...)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SetPersistentArgs sets the arguments of the node that are passed
+// as --name=value or --name value flags after the name of the node
+// to all commands of the node and its sub nodes.
+// Bool arguments are passed as --name switches or as --name=value.
+// args has to be the address of a struct embedding ArgsDef.
+// The Arg.Default, Arg.Env, and Arg.Required settings of the
+// struct fields are applied like for command arguments.
+// A copy of the struct with the passed values is added to
+// the context of the called command, see PersistentArgsFromContext.
+func (t *CommandTree) SetPersistentArgs(args Args) error {
+	impl, ok := args.(argsImpl)
+	if !ok {
+		return fmt.Errorf("persistent args of '%s' of type %T don't embed ArgsDef", t.path(), args)
+	}
+	err := impl.Init(args)
+	if err != nil {
+		return fmt.Errorf("persistent args of '%s': %w", t.path(), err)
+	}
+	t.persistent = args
+	return nil
+}
+
+func (t *CommandTree) MustSetPersistentArgs(args Args) {
+	err := t.SetPersistentArgs(args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Use adds middleware that wraps the calls of the command
+// functions of the node and all its sub nodes.
+// Middleware of parent nodes wraps the middleware of sub nodes
+// and the first Middleware of a node is the outermost one.
+func (t *CommandTree) Use(middleware ...Middleware) {
+	t.middleware = append(t.middleware, middleware...)
+}
+
+// persistentArgsKey is the context key for the
+// persistent args struct pointer of type typ
+type persistentArgsKey struct {
+	typ reflect.Type
+}
+
+// withPersistentArgs returns ctx with a copy of the persistent
+// args struct with values assigned, see PersistentArgsFromContext
+func withPersistentArgs(ctx context.Context, args Args, values map[string]string) (context.Context, error) {
+	argsStructPtr, err := args.(argsImpl).argsStructFromStringMapArgs(args, values)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, persistentArgsKey{reflect.TypeOf(argsStructPtr)}, argsStructPtr), nil
+}
+
+// PersistentArgsFromContext assigns the persistent arguments
+// of a CommandTree node passed to a command via ctx
+// to the struct pointed to by argsStructPtr.
+// argsStructPtr must have the same type as the args
+// passed to CommandTree.SetPersistentArgs.
+// Returns false if ctx has no persistent arguments of that type.
+func PersistentArgsFromContext(ctx context.Context, argsStructPtr Args) bool {
+	value := ctx.Value(persistentArgsKey{reflect.TypeOf(argsStructPtr)})
+	if value == nil {
+		return false
+	}
+	reflect.ValueOf(argsStructPtr).Elem().Set(reflect.ValueOf(value).Elem())
+	return true
+}
+
+// EnableOutputFlag enables the OutputFlag for all commands of the tree,
+// see StringArgsDispatcher.EnableOutputFlag.
+func (t *CommandTree) EnableOutputFlag(output io.Writer) {
+	t.root().flags.enableOutput(output)
+}
+
+// EnableQueryFlag enables the QueryFlag for all commands of the tree,
+// see StringArgsDispatcher.EnableQueryFlag.
+func (t *CommandTree) EnableQueryFlag() {
+	t.root().flags.enableQuery()
+}
+
+// EnablePrefixMatching enables resolving sub commands
+// by a prefix of their name or alias
+// if the prefix is unique among the sub commands of a node.
+func (t *CommandTree) EnablePrefixMatching() {
+	t.root().prefixMatching = true
+}
+
+// EnableHelp enables the HelpCommand and the -h and --help flags
+// that write help pages to output instead of calling a command:
+//
+//	help                  usage of all commands
+//	help <node>...        usage of all commands of a node
+//	                      or the help page of a command
+//	<node>... -h          the same as help <node>...
+//
+// Sub commands of the root with the name HelpCommand take precedence.
+// If appName is empty, then the base name of os.Args[0] will be used.
+// If output is nil, then os.Stdout will be used.
+func (t *CommandTree) EnableHelp(appName string, output io.Writer) {
+	t.root().help.enable(appName, output)
+}
+
+// EnableCompletion enables the hidden CompleteCommand
+// that writes completion candidates to output.
+// It is called by the scripts from WriteCompletionScript.
+// If output is nil, then os.Stdout will be used.
+func (t *CommandTree) EnableCompletion(output io.Writer) {
+	t.root().completion.enable(output)
+}
+
+// DispatchCombinedCommandAndArgs resolves the node of the command
+// from commandAndArgs and calls its default command with the
+// remaining arguments, see CommandTree for the resolving rules.
+// The returned command is the space separated path of the node.
+func (t *CommandTree) DispatchCombinedCommandAndArgs(ctx context.Context, commandAndArgs []string) (command string, err error) {
+	root := t.root()
+	if len(commandAndArgs) > 0 && t.isCompleteCommand(commandAndArgs[0]) {
+		return commandAndArgs[0], t.writeCompletions(ctx, commandAndArgs[1:])
+	}
+	flags, commandAndArgs, err := root.flags.parse(commandAndArgs)
+	if err != nil {
+		return "", err
+	}
+	if root.help.enabled && len(commandAndArgs) > 0 && commandAndArgs[0] == HelpCommand {
+		if _, hasHelpCommand := t.children[HelpCommand]; !hasHelpCommand {
+			return HelpCommand, t.writeHelp(commandAndArgs[1:])
+		}
+	}
+	node, args, persistent, err := t.resolve(commandAndArgs)
+	if err != nil {
+		return "", err
+	}
+	command = node.path()
+	if root.help.enabled && hasHelpArg(args, node.command != nil && node.command.declaresArgs()) {
+		return command, node.writeNodeHelp()
+	}
+	if node.command == nil {
+		return command, node.notFound(args)
+	}
+	for _, p := range persistent {
+		ctx, err = withPersistentArgs(ctx, p.node.persistent, p.values)
+		if err != nil {
+			return command, UsageError{Err: err}
+		}
+	}
+	for _, logger := range root.loggers {
+		logger.LogStringArgsCommand(command, args)
+	}
+	ctx = WithCommand(ctx, command, node.command.description)
+	ctx = WithMiddleware(ctx, node.pathMiddleware()...)
+	return command, node.command.call(ctx, args, root.flags.resultsHandlers(flags, node.command.resultsHandlers))
+}
+
+func (t *CommandTree) MustDispatchCombinedCommandAndArgs(ctx context.Context, commandAndArgs []string) (command string) {
+	command, err := t.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+	if err != nil {
+		panic(fmt.Errorf("MustDispatchCombinedCommandAndArgs(%v): %w", commandAndArgs, err))
+	}
+	return command
+}
+
+// DispatchCommandLineArgs dispatches the command and
+// its arguments from commandAndArgs like DispatchCombinedCommandAndArgs.
+func (t *CommandTree) DispatchCommandLineArgs(ctx context.Context, commandAndArgs []string) error {
+	_, err := t.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+	return err
+}
+
+// DispatchCommandLine splits line into the command and its arguments
+// with SplitCommandLine and dispatches them like DispatchCombinedCommandAndArgs.
+// Errors from splitting line wrap a *CommandLineError.
+func (t *CommandTree) DispatchCommandLine(ctx context.Context, line string) (command string, err error) {
+	commandAndArgs, err := SplitCommandLine(line)
+	if err != nil {
+		return "", UsageError{Err: err}
+	}
+	return t.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+}
+
+// DispatchCommandLineLookup is like DispatchCommandLine but replaces
+// variable references like ${NAME} in line with the values from lookup,
+// see SplitCommandLineLookup.
+func (t *CommandTree) DispatchCommandLineLookup(ctx context.Context, line string, lookup func(name string) (value string, found bool)) (command string, err error) {
+	commandAndArgs, err := SplitCommandLineLookup(line, lookup)
+	if err != nil {
+		return "", UsageError{Err: err}
+	}
+	return t.DispatchCombinedCommandAndArgs(ctx, commandAndArgs)
+}
+
+// persistentArgValues are the persistent argument
+// values passed for a node as flags
+type persistentArgValues struct {
+	node   *CommandTree
+	values map[string]string
+}
+
+// resolve returns the node reached by following the sub command
+// names at the start of words and the remaining words as args.
+// The persistent arguments of all passed nodes
+// are removed from words and returned as persistent.
+func (t *CommandTree) resolve(words []string) (node *CommandTree, args []string, persistent []persistentArgValues, err error) {
+	node = t
+	for {
+		if node.persistent != nil {
+			values, remaining, err := extractPersistentArgs(node.persistent, words)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			persistent = append(persistent, persistentArgValues{node: node, values: values})
+			words = remaining
+		}
+		if len(words) == 0 {
+			return node, words, persistent, nil
+		}
+		if words[0] == argsTerminator {
+			return node, words[1:], persistent, nil
+		}
+		child, found := node.lookupChild(words[0])
+		if !found {
+			return node, words, persistent, nil
+		}
+		node, words = child, words[1:]
+	}
+}
+
+// extractPersistentArgs returns the values of the
+// args passed as flags in the words before a "--" word
+// and words without those flags.
+// Bool args are switches that don't take the following word as value.
+func extractPersistentArgs(args Args, words []string) (values map[string]string, remaining []string, err error) {
+	end := len(words)
+	for i, word := range words {
+		if word == argsTerminator {
+			end = i
+			break
+		}
+	}
+	before, after := words[:end:end], words[end:]
+	values = make(map[string]string)
+	for _, arg := range args.Args() {
+		extract := extractFlag
+		if arg.Type.Kind() == reflect.Bool {
+			extract = extractSwitch
+		}
+		value, found, rest, err := extract(before, arg.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if found {
+			values[arg.Name] = value
+			before = rest
+		}
+	}
+	return values, append(before, after...), nil
+}
+
+// lookupChild returns the sub node with the name or alias name,
+// or if prefix matching is enabled, the sub node with
+// a name or alias uniquely starting with name.
+func (t *CommandTree) lookupChild(name string) (node *CommandTree, found bool) {
+	if node, found = t.children[name]; found {
+		return node, true
+	}
+	if node, found = t.aliases[name]; found {
+		return node, true
+	}
+	if !t.root().prefixMatching || name == "" || name == HelpCommand || name == CompleteCommand || strings.HasPrefix(name, "-") {
+		return nil, false
+	}
+	for _, childName := range t.childNames(name) {
+		match := t.Node(childName)
+		if node != nil && node != match {
+			return nil, false
+		}
+		node = match
+	}
+	return node, node != nil
+}
+
+// childNames returns the sorted names and aliases
+// of the sub nodes starting with prefix.
+func (t *CommandTree) childNames(prefix string) []string {
+	var names []string
+	for name := range t.children {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	for alias := range t.aliases {
+		if strings.HasPrefix(alias, prefix) {
+			names = append(names, alias)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// notFound returns the error for a node without a default command
+// called with args where args[0] is not a sub command.
+func (t *CommandTree) notFound(args []string) error {
+	if len(args) == 0 {
+		if t.parent == nil {
+			return ErrNotFound
+		}
+		return UsageError{Err: fmt.Errorf("command '%s' needs a sub command: %w", t.path(), ErrNotFound)}
+	}
+	suggestions := suggestCommands(args[0], t.childNames(""))
+	for i := range suggestions {
+		suggestions[i] = joinSuperCommand(t.path(), suggestions[i])
+	}
+	return CommandNotFoundError{
+		Command:     joinSuperCommand(t.path(), args[0]),
+		Suggestions: suggestions,
+	}
+}
+
+// pathMiddleware returns the Middleware of the
+// nodes from the root to t in that order
+func (t *CommandTree) pathMiddleware() []Middleware {
+	var middleware []Middleware
+	for node := t; node != nil; node = node.parent {
+		middleware = append(append([]Middleware(nil), node.middleware...), middleware...)
+	}
+	return middleware
+}
+
+// pathPersistentArgs returns the persistent args
+// of the nodes from the root to t
+func (t *CommandTree) pathPersistentArgs() []Args {
+	var persistent []Args
+	for node := t; node != nil; node = node.parent {
+		if node.persistent != nil {
+			persistent = append([]Args{node.persistent}, persistent...)
+		}
+	}
+	return persistent
+}
+
+func (t *CommandTree) root() *CommandTree {
+	root := t
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// path returns the space separated names
+// of the nodes from the root to t
+func (t *CommandTree) path() string {
+	if t.parent == nil {
+		return t.name
+	}
+	return joinSuperCommand(t.parent.path(), t.name)
+}
+
+func (t *CommandTree) PrintCommands(appName string) {
+	t.PrintCommandsTo(color.Output, appName)
+}
+
+// PrintCommandsTo prints the usage of all commands
+// of the node and its sub nodes to writer
+// using the CommandsHelpTemplate.
+func (t *CommandTree) PrintCommandsTo(writer io.Writer, appName string) {
+	_ = writeCommandsHelp(writer, t.commandsHelp(appName))
+}
+
+func (t *CommandTree) PrintCommandsUsageIntro(appName string, output io.Writer) {
+	if len(t.children) > 0 || t.command != nil {
+		fmt.Fprint(output, "Commands:\n")
+		t.PrintCommandsTo(output, appName)
+		fmt.Fprint(output, "Flags:\n")
+	}
+}
+
+// commandHelp returns the help for the default command of the node
+func (t *CommandTree) commandHelp(appName string) *CommandHelp {
+	h := t.command.help(appName, t.path())
+	h.Aliases = t.aliasNames
+	if h.Description == "" {
+		h.Description = t.description
+	}
+	for _, persistent := range t.pathPersistentArgs() {
+		h.PersistentArgs = append(h.PersistentArgs, persistent.Args()...)
+	}
+	return h
+}
+
+// commandsHelp returns the help for the commands of the node
+// and all its sub nodes sorted by their path.
+// If the node is not the root and has no default command
+// but a description, then it is listed first with
+// the placeholder <command> as usage.
+func (t *CommandTree) commandsHelp(appName string) *CommandsHelp {
+	help := &CommandsHelp{AppName: appName}
+	if t.command == nil && t.parent != nil && t.description != "" {
+		var usage []string
+		for _, part := range []string{appName, t.path(), "<command>"} {
+			if part != "" {
+				usage = append(usage, part)
+			}
+		}
+		help.Commands = append(help.Commands, &CommandHelp{
+			AppName:     appName,
+			Command:     t.path(),
+			Usage:       strings.Join(usage, " "),
+			Aliases:     t.aliasNames,
+			Description: t.description,
+		})
+	}
+	var nodes []*CommandTree
+	t.walk(func(node *CommandTree) {
+		if node.command != nil {
+			nodes = append(nodes, node)
+		}
+	})
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].path() < nodes[j].path()
+	})
+	for _, node := range nodes {
+		help.Commands = append(help.Commands, node.commandHelp(appName))
+	}
+	return help
+}
+
+// walk calls f for the node and all its sub nodes
+func (t *CommandTree) walk(f func(*CommandTree)) {
+	f(t)
+	for _, child := range t.children {
+		child.walk(f)
+	}
+}
+
+// writeHelp writes the help of the node
+// reached by following the sub commands of path.
+func (t *CommandTree) writeHelp(path []string) error {
+	node := t
+	for _, name := range path {
+		child, found := node.lookupChild(name)
+		if !found {
+			return helpCommandNotFound(joinSuperCommand(node.path(), name))
+		}
+		node = child
+	}
+	return node.writeNodeHelp()
+}
+
+// writeNodeHelp writes the help page of the default command
+// if the node has no sub nodes, else the usage of all commands
+// of the node and its sub nodes.
+func (t *CommandTree) writeNodeHelp() error {
+	root := t.root()
+	if len(t.children) == 0 && t.command != nil {
+		return writeCommandHelp(root.help.writer, t.commandHelp(root.help.appName))
+	}
+	return writeCommandsHelp(root.help.writer, t.commandsHelp(root.help.appName))
+}
+
+func (t *CommandTree) isCompleteCommand(command string) bool {
+	_, registered := t.children[command]
+	return t.root().completion.enabled && command == CompleteCommand && !registered
+}
+
+func (t *CommandTree) writeCompletions(ctx context.Context, words []string) error {
+	candidates, err := t.Complete(ctx, words)
+	if err != nil {
+		return err
+	}
+	return t.root().completion.write(candidates)
+}
+
+// Complete returns the completion candidates for the last
+// of the command line words, which may be an empty string.
+func (t *CommandTree) Complete(ctx context.Context, words []string) ([]string, error) {
+	root := t.root()
+	preceding, current, candidates, done := splitCompletionWords(&root.flags, &root.help, words)
+	if done {
+		return candidates, nil
+	}
+	_, hasHelpCommand := t.children[HelpCommand]
+	isHelp := root.help.enabled && !hasHelpCommand
+	if isHelp && len(preceding) > 0 && preceding[0] == HelpCommand {
+		node, args, _, err := t.resolve(preceding[1:])
+		if err != nil || len(args) > 0 {
+			return nil, nil
+		}
+		return node.childNames(current), nil
+	}
+	node, args, _, err := t.resolve(preceding)
+	if err != nil {
+		return nil, nil
+	}
+	if len(args) == 0 {
+		candidates = node.childNames(current)
+		if isHelp && node == t && strings.HasPrefix(HelpCommand, current) {
+			candidates = append(candidates, HelpCommand)
+		}
+	}
+	if node.command != nil {
+		argCandidates, err := node.command.completeArg(ctx, len(args), current)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, argCandidates...)
+	}
+	return candidates, nil
+}
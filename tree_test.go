@@ -0,0 +1,168 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type treeTestClusterArgs struct {
+	ArgsDef
+
+	Cluster string `arg:"cluster" desc:"Cluster name" default:"local"`
+	Verbose bool   `arg:"verbose" desc:"Verbose output"`
+}
+
+type treeTestNameArgs struct {
+	ArgsDef
+
+	Name string `arg:"name" desc:"Node name"`
+}
+
+func newTestCommandTree(calls *[]string) *CommandTree {
+	tree := NewCommandTree()
+	tree.MustAddDefaultCommand("Greets", func(name string) { *calls = append(*calls, "greet "+name) }, new(treeTestNameArgs))
+
+	cluster := tree.MustAddNode("cluster", "Manages clusters")
+	cluster.MustSetPersistentArgs(new(treeTestClusterArgs))
+	node := cluster.MustAddNode("node", "Manages cluster nodes")
+	node.MustAddCommand("drain", "Drains a node", func(ctx context.Context, name string) {
+		var args treeTestClusterArgs
+		PersistentArgsFromContext(ctx, &args)
+		call := "drain " + args.Cluster + " " + name
+		if args.Verbose {
+			call += " verbose"
+		}
+		*calls = append(*calls, call)
+	}, new(treeTestNameArgs))
+	node.MustAddCommand("remove", "Removes a node", func(name string) { *calls = append(*calls, "remove "+name) }, new(treeTestNameArgs))
+	node.MustAddAlias("remove", "rm")
+	return tree
+}
+
+func Test_CommandTree(t *testing.T) {
+	var calls []string
+	tree := newTestCommandTree(&calls)
+	ctx := context.Background()
+
+	dispatch := func(line string) (string, error) {
+		return tree.DispatchCommandLine(ctx, line)
+	}
+
+	command, err := dispatch("cluster node drain n1")
+	assert.NoError(t, err)
+	assert.Equal(t, "cluster node drain", command)
+	_, err = dispatch("cluster --cluster=prod node drain n2")
+	assert.NoError(t, err)
+	_, err = dispatch("cluster node drain n3 --cluster prod")
+	assert.NoError(t, err)
+	_, err = dispatch("cluster node rm n4")
+	assert.NoError(t, err)
+	_, err = dispatch("World")
+	assert.NoError(t, err)
+	_, err = dispatch("-- cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"drain local n1", "drain prod n2", "drain prod n3", "remove n4", "greet World", "greet cluster"}, calls)
+
+	_, err = dispatch("cluster node drian n1")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.EqualError(t, err, "command 'cluster node drian' not found, did you mean 'cluster node drain'?")
+
+	_, err = dispatch("cluster")
+	assert.True(t, IsUsageError(err))
+	assert.EqualError(t, err, "command 'cluster' needs a sub command: command not found")
+
+	_, err = dispatch("cluster node drain n1 --cluster")
+	assert.True(t, IsUsageError(err), "missing flag value")
+
+	calls = nil
+	_, err = dispatch("cluster --verbose node drain n1")
+	assert.NoError(t, err)
+	_, err = dispatch("cluster node drain n2 --verbose")
+	assert.NoError(t, err)
+	_, err = dispatch("cluster --verbose=false node drain n3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"drain local n1 verbose", "drain local n2 verbose", "drain local n3"}, calls)
+	_, err = dispatch("cluster --verbose node drain n1 --verbose")
+	assert.True(t, IsUsageError(err), "switch passed twice")
+
+	calls = nil
+	_, err = dispatch("cl no dr n1")
+	assert.NoError(t, err)
+	tree.EnablePrefixMatching()
+	_, err = dispatch("cl no dr n1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"greet cl", "drain local n1"}, calls)
+}
+
+func Test_CommandTree_Help(t *testing.T) {
+	var (
+		calls []string
+		buf   bytes.Buffer
+	)
+	tree := newTestCommandTree(&calls)
+	tree.EnableHelp("app", &buf)
+	ctx := context.Background()
+
+	_, err := tree.DispatchCommandLine(ctx, "cluster node drain -h")
+	assert.NoError(t, err)
+	expected := `Usage:
+  app cluster node drain <name:string>
+
+Drains a node
+
+Arguments:
+  <name:string>  Node name
+
+Persistent arguments:
+  --cluster=<string>  Cluster name
+  --verbose=<bool>  Verbose output
+`
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	_, err = tree.DispatchCommandLine(ctx, "help cluster node")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "  app cluster node <command>\n      Manages cluster nodes\n\n  app cluster node drain <name:string>\n"), buf.String())
+	assert.Contains(t, buf.String(), "  app cluster node remove <name:string>\n      Removes a node\n      Aliases: rm\n")
+
+	_, err = tree.DispatchCommandLine(ctx, "help unknown")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	candidates, err := tree.Complete(ctx, []string{"cluster", "node", "r"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"remove", "rm"}, candidates)
+	candidates, err = tree.Complete(ctx, []string{"help", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cluster"}, candidates)
+}
+
+func Test_CommandTreeFromSuperDispatcher(t *testing.T) {
+	var calls []string
+	disp := NewSuperStringArgsDispatcher()
+	disp.MustAddDefaultCommand("Greets", func(name string) { calls = append(calls, "greet "+name) }, new(treeTestNameArgs))
+	sub := disp.MustAddSuperCommand("node")
+	sub.MustAddCommand("remove", "Removes a node", func(name string) { calls = append(calls, "remove "+name) }, new(treeTestNameArgs))
+	sub.MustAddAlias("remove", "rm")
+
+	tree, err := CommandTreeFromSuperDispatcher(disp)
+	assert.NoError(t, err)
+	ctx := context.Background()
+	_, err = tree.DispatchCommandLine(ctx, "node rm n1")
+	assert.NoError(t, err)
+	_, err = tree.DispatchCommandLine(ctx, "node")
+	assert.True(t, IsUsageError(err))
+	_, err = tree.DispatchCommandLine(ctx, "World")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"remove n1", "greet World"}, calls)
+
+	sub.MustAddDefaultCommand("Lists nodes", func() {}, new(struct{ ArgsDef }))
+	tree, err = CommandTreeFromDispatcher(sub)
+	assert.NoError(t, err)
+	assert.NotNil(t, tree.Node("rm"))
+	assert.NoError(t, tree.DispatchCommandLineArgs(ctx, nil))
+}
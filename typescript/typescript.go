@@ -0,0 +1,472 @@
+// Package typescript generates TypeScript types and a fetch based client
+// for the commands of a dispatcher mounted with gorillamux.MountDispatcher.
+package typescript
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/domonda/go-types/nullable"
+
+	"github.com/ungerik/go-command"
+	"github.com/ungerik/go-command/internal/names"
+)
+
+var (
+	typeOfTime          = reflect.TypeOf(time.Time{})
+	typeOfDuration      = reflect.TypeOf(time.Duration(0))
+	typeOfNullableTime  = reflect.TypeOf(nullable.Time{})
+	typeOfNullable      = reflect.TypeOf((*nullable.Nullable)(nil)).Elem()
+	typeOfTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	typeOfJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	identifierRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+)
+
+// Indent is used to indent the generated source
+var Indent = "  "
+
+// GenerateClient writes TypeScript source with an interface
+// for the arguments and named results of every command of disp,
+// an interface for every struct type used by them,
+// and a Client class calling the commands with fetch
+// where they were mounted with gorillamux.MountDispatcher.
+//
+// Go types are mapped to the TypeScript types of their JSON:
+// time.Time is a string, time.Duration are integer nanoseconds
+// except for arguments that are passed in the format of time.Duration.String,
+// pointers, nil slices and maps, and nullable types can be null,
+// and types that marshal themselves as JSON are unknown.
+func GenerateClient(writer io.Writer, disp command.CommandLister) error {
+	g := &generator{
+		typeNames: make(map[reflect.Type]string),
+		usedNames: map[string]bool{"Client": true, "CommandError": true},
+	}
+	infos := disp.Commands()
+	// The exported name of a command is used for its method and
+	// its Args and Results interfaces with the same numeric suffix
+	commandNames := make([]string, len(infos))
+	usedMethods := map[string]bool{"constructor": true, "call": true, "baseURL": true, "init": true}
+	for i, info := range infos {
+		name := names.Exported(info.Path)
+		for n := 2; usedMethods[names.Unexported(name)] || g.usedNames[name+"Args"] || g.usedNames[name+"Results"]; n++ {
+			name = names.Exported(info.Path) + strconv.Itoa(n)
+		}
+		commandNames[i] = name
+		usedMethods[names.Unexported(name)] = true
+		g.usedNames[name+"Args"] = true
+		g.usedNames[name+"Results"] = true
+	}
+
+	var (
+		commandTypes strings.Builder
+		client       strings.Builder
+	)
+	for i, info := range infos {
+		g.writeCommand(&commandTypes, &client, commandNames[i], info)
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by typescript.GenerateClient; DO NOT EDIT.\n\n")
+	b.WriteString(commandTypes.String())
+	// Writing named types can reference more named types
+	for written := 0; written < len(g.queue); written++ {
+		sort.Slice(g.queue[written:], func(i, j int) bool {
+			return g.typeNames[g.queue[written+i]] < g.typeNames[g.queue[written+j]]
+		})
+		g.writeStructInterface(&b, g.queue[written])
+	}
+	b.WriteString(strings.ReplaceAll(commandErrorSource, "\t", Indent))
+	b.WriteString(strings.ReplaceAll(clientSourceBegin, "\t", Indent))
+	b.WriteString(client.String())
+	b.WriteString(strings.ReplaceAll(clientSourceEnd, "\t", Indent))
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
+
+const commandErrorSource = `/**
+ * CommandError is thrown for HTTP responses with a status of 400 or above.
+ * The message is the body of the response.
+ */
+export class CommandError extends Error {
+	readonly status: number;
+
+	constructor(status: number, message: string) {
+		super(message);
+		this.name = "CommandError";
+		this.status = status;
+	}
+}
+
+`
+
+const clientSourceBegin = `/**
+ * Client calls the commands mounted at baseURL.
+ * init is used for all fetch requests.
+ */
+export class Client {
+	readonly baseURL: string;
+	readonly init: RequestInit;
+
+	constructor(baseURL: string, init: RequestInit = {}) {
+		this.baseURL = baseURL.replace(/\/+$/, "");
+		this.init = init;
+	}
+`
+
+const clientSourceEnd = `
+	protected async call<T>(path: string, args: object, multipleResults: boolean): Promise<T> {
+		const body: Record<string, unknown> = {};
+		for (const [name, value] of Object.entries(args)) {
+			// Arrays of strings are passed in the command line format [a,b]
+			const isStringArray = Array.isArray(value) && value.every((elem) => typeof elem === "string");
+			body[name] = isStringArray ? "[" + value.join(",") + "]" : value;
+		}
+		const headers = new Headers(this.init.headers);
+		headers.set("Content-Type", "application/json");
+		headers.set("Accept", multipleResults ? "application/x-ndjson" : "application/json");
+		const response = await fetch(this.baseURL + "/" + path, {
+			...this.init,
+			method: "POST",
+			headers,
+			body: JSON.stringify(body),
+		});
+		const text = await response.text();
+		if (!response.ok) {
+			throw new CommandError(response.status, text.trim() || response.statusText);
+		}
+		if (multipleResults) {
+			return text
+				.split("\n")
+				.filter((line) => line.trim() !== "")
+				.map((line) => JSON.parse(line)) as T;
+		}
+		return (text.trim() === "" ? undefined : JSON.parse(text)) as T;
+	}
+}
+`
+
+type generator struct {
+	// typeNames are the interface names of named struct types
+	typeNames map[reflect.Type]string
+	usedNames map[string]bool
+	// queue holds the named struct types to write as interfaces
+	queue []reflect.Type
+}
+
+func (g *generator) writeCommand(types, client *strings.Builder, name string, info *command.CommandInfo) {
+	method := names.Unexported(name)
+	cmd := info.Command()
+	what := fmt.Sprintf("the command %q", cmd)
+	if cmd == "" {
+		what = "the default command"
+	}
+
+	argsParam := ""
+	argsValue := "{}"
+	if info.Args.NumArgs() > 0 {
+		writeDoc(types, "", "Arguments of "+what)
+		fmt.Fprintf(types, "export interface %sArgs {\n", name)
+		optional := true
+		for _, arg := range info.Args.Args() {
+			var doc []string
+			if arg.Description != "" {
+				doc = append(doc, arg.Description)
+			}
+			if arg.Default != "" {
+				doc = append(doc, "@default "+arg.Default)
+			}
+			if arg.Example != "" {
+				doc = append(doc, "@example "+arg.Example)
+			}
+			writeDoc(types, Indent, doc...)
+			propType := g.argType(arg.Type)
+			if len(arg.Enum) > 0 && arg.Type.Kind() == reflect.String {
+				quoted := make([]string, len(arg.Enum))
+				for i, e := range arg.Enum {
+					quoted[i] = strconv.Quote(e)
+				}
+				propType = strings.Join(quoted, " | ")
+			}
+			required := arg.Required && arg.Default == "" && arg.Env == ""
+			optional = optional && !required
+			types.WriteString(Indent)
+			writeProperty(types, arg.Name, !required, propType)
+		}
+		types.WriteString("}\n\n")
+		argsParam = "args: " + name + "Args"
+		if optional {
+			argsParam += " = {}"
+		}
+		argsValue = "args"
+	}
+
+	resultType := "void"
+	multipleResults := false
+	switch {
+	case info.Results != nil && info.Results.NumResults() > 0:
+		writeDoc(types, "", "Results of "+what)
+		fmt.Fprintf(types, "export interface %sResults {\n", name)
+		for _, result := range info.Results.Results() {
+			writeDoc(types, Indent, result.Description)
+			types.WriteString(Indent)
+			writeProperty(types, result.Name, false, g.tsType(result.Type))
+		}
+		types.WriteString("}\n\n")
+		resultType = name + "Results"
+
+	case len(info.ResultTypes) == 1:
+		resultType = g.tsType(info.ResultTypes[0])
+
+	case len(info.ResultTypes) > 1:
+		elems := make([]string, len(info.ResultTypes))
+		for i, t := range info.ResultTypes {
+			elems[i] = g.tsType(t)
+		}
+		resultType = "[" + strings.Join(elems, ", ") + "]"
+		multipleResults = true
+	}
+
+	path := make([]string, len(info.Path))
+	for i, elem := range info.Path {
+		path[i] = url.PathEscape(elem)
+	}
+	client.WriteString("\n")
+	if info.Description != "" {
+		writeDoc(client, Indent, "Calls "+what+":", info.Description)
+	} else {
+		writeDoc(client, Indent, "Calls "+what)
+	}
+	fmt.Fprintf(client, "%s%s(%s): Promise<%s> {\n", Indent, method, argsParam, resultType)
+	fmt.Fprintf(client, "%s%sreturn this.call<%s>(%q, %s, %t);\n", Indent, Indent, resultType, strings.Join(path, "/"), argsValue, multipleResults)
+	fmt.Fprintf(client, "%s}\n", Indent)
+}
+
+func (g *generator) writeStructInterface(b *strings.Builder, t reflect.Type) {
+	writeDoc(b, "", fmt.Sprintf("%s is the JSON of the Go type %s", g.typeNames[t], t))
+	fmt.Fprintf(b, "export interface %s ", g.typeNames[t])
+	g.writeStructProperties(b, t, "")
+	b.WriteString("\n\n")
+}
+
+// writeStructProperties writes the properties of the struct type t
+// following the rules of encoding/json for field names and embedded structs.
+func (g *generator) writeStructProperties(b *strings.Builder, t reflect.Type, indent string) {
+	b.WriteString("{\n")
+	g.writeStructFields(b, t, indent+Indent)
+	b.WriteString(indent + "}")
+}
+
+func (g *generator) writeStructFields(b *strings.Builder, t reflect.Type, indent string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" && len(tag) == 1 {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				g.writeStructFields(b, fieldType, indent)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = field.Name
+		}
+		optional := false
+		fieldType := g.tsTypeIndent(field.Type, indent)
+		for _, option := range tag[1:] {
+			switch option {
+			case "omitempty":
+				optional = true
+			case "string":
+				fieldType = "string"
+			}
+		}
+		b.WriteString(indent)
+		writeProperty(b, name, optional, fieldType)
+	}
+}
+
+// argType returns the TypeScript type of an argument
+// as passed to a command mounted with gorillamux.MountDispatcher
+func (g *generator) argType(t reflect.Type) string {
+	switch t {
+	case typeOfDuration:
+		return "string"
+	case reflect.PtrTo(typeOfDuration):
+		return "string | null"
+	}
+	return g.tsType(t)
+}
+
+// tsType returns the TypeScript type of the JSON of t
+func (g *generator) tsType(t reflect.Type) string {
+	return g.tsTypeIndent(t, Indent)
+}
+
+func (g *generator) tsTypeIndent(t reflect.Type, indent string) string {
+	switch {
+	case t.Kind() == reflect.Interface:
+		return "unknown"
+
+	case t.Kind() == reflect.Ptr:
+		return nullableType(g.tsTypeIndent(t.Elem(), indent))
+
+	case t == typeOfTime:
+		return "string"
+
+	case t == typeOfNullableTime:
+		return "string | null"
+
+	case t == typeOfDuration:
+		return "number"
+
+	case t.Implements(typeOfNullable) || reflect.PtrTo(t).Implements(typeOfNullable):
+		if t.Kind() == reflect.Struct && !implements(t, typeOfTextMarshaler) {
+			return "unknown"
+		}
+		return nullableType(g.tsKindType(t, indent))
+
+	case implements(t, typeOfTextMarshaler) && !implements(t, typeOfJSONMarshaler):
+		return "string"
+
+	case implements(t, typeOfJSONMarshaler) && t.Kind() == reflect.Struct:
+		return "unknown"
+	}
+	return g.tsKindType(t, indent)
+}
+
+func (g *generator) tsKindType(t reflect.Type, indent string) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+
+	case reflect.String:
+		return "string"
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// base64
+			return "string"
+		}
+		// nil slices are marshalled as null
+		return arrayType(g.tsTypeIndent(t.Elem(), indent)) + " | null"
+
+	case reflect.Array:
+		return arrayType(g.tsTypeIndent(t.Elem(), indent))
+
+	case reflect.Map:
+		// JSON object keys are always strings
+		return "Record<string, " + g.tsTypeIndent(t.Elem(), indent) + "> | null"
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			var b strings.Builder
+			g.writeStructProperties(&b, t, indent)
+			return b.String()
+		}
+		return g.structInterfaceName(t)
+	}
+	// Channels, functions, and complex numbers
+	// can't be marshalled as JSON
+	return "unknown"
+}
+
+// structInterfaceName returns the name of the interface
+// for the named struct type t and queues it to be written
+func (g *generator) structInterfaceName(t reflect.Type) string {
+	if name, ok := g.typeNames[t]; ok {
+		return name
+	}
+	base := names.Exported([]string{t.Name()})
+	name := base
+	if g.usedNames[name] {
+		pkg := strings.SplitN(t.String(), ".", 2)[0]
+		base = names.Exported([]string{pkg}) + base
+		name = base
+	}
+	for i := 2; g.usedNames[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.usedNames[name] = true
+	g.typeNames[t] = name
+	g.queue = append(g.queue, t)
+	return name
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+// nullableType adds null to the union type ts
+func nullableType(ts string) string {
+	if ts == "unknown" || strings.HasSuffix(ts, " | null") {
+		return ts
+	}
+	return ts + " | null"
+}
+
+// arrayType returns the array type of elem
+// with parentheses for union types
+func arrayType(elem string) string {
+	if strings.Contains(elem, " | ") && !strings.HasPrefix(elem, "{") {
+		return "(" + elem + ")[]"
+	}
+	return elem + "[]"
+}
+
+func writeProperty(b *strings.Builder, name string, optional bool, ts string) {
+	if !identifierRegexp.MatchString(name) {
+		name = strconv.Quote(name)
+	}
+	if optional {
+		name += "?"
+	}
+	fmt.Fprintf(b, "%s: %s;\n", name, ts)
+}
+
+// writeDoc writes the non empty lines as JSDoc comment
+func writeDoc(b *strings.Builder, indent string, lines ...string) {
+	var docLines []string
+	for _, line := range lines {
+		line = strings.ReplaceAll(strings.TrimSpace(line), "*/", "*\\/")
+		if line != "" {
+			docLines = append(docLines, strings.Split(line, "\n")...)
+		}
+	}
+	switch len(docLines) {
+	case 0:
+		return
+	case 1:
+		fmt.Fprintf(b, "%s/** %s */\n", indent, docLines[0])
+	default:
+		fmt.Fprintf(b, "%s/**\n", indent)
+		for _, line := range docLines {
+			fmt.Fprintf(b, "%s * %s\n", indent, strings.TrimSpace(line))
+		}
+		fmt.Fprintf(b, "%s */\n", indent)
+	}
+}
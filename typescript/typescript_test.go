@@ -0,0 +1,90 @@
+package typescript
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/domonda/go-types/nullable"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ungerik/go-command"
+)
+
+type Address struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type User struct {
+	Address
+	Name     string                  `json:"name"`
+	Born     time.Time               `json:"born"`
+	Deleted  nullable.Time           `json:"deleted"`
+	Nick     nullable.NonEmptyString `json:"nick"`
+	Friends  []*User                 `json:"friends"`
+	Scores   map[string]float64      `json:"scores"`
+	Internal string                  `json:"-"`
+}
+
+type getUserArgs struct {
+	command.ArgsDef
+
+	Name    string        `arg:"name" desc:"Name of the user" required:"true"`
+	Format  string        `arg:"format" enum:"short,long" default:"short"`
+	Timeout time.Duration `arg:"timeout"`
+}
+
+type countResults struct {
+	command.ResultsDef
+
+	Count int       `result:"count" desc:"Number of users"`
+	Since time.Time `result:"since"`
+}
+
+type noArgs struct {
+	command.ArgsDef
+}
+
+func TestGenerateClient(t *testing.T) {
+	disp := command.NewSuperStringArgsDispatcher()
+	users := disp.MustAddSuperCommand("users")
+	users.MustAddCommand("get", "Returns a user", func(ctx context.Context, name, format string, timeout time.Duration) (*User, error) {
+		return nil, nil
+	}, new(getUserArgs))
+	users.MustAddCommandWithResults("count", "", func(ctx context.Context) (int, time.Time) {
+		return 0, time.Time{}
+	}, new(noArgs), new(countResults))
+	users.MustAddCommand("names", "", func(ctx context.Context) ([]string, int) {
+		return nil, 0
+	}, new(noArgs))
+	listUsers := func(ctx context.Context, name, format string, timeout time.Duration) ([]string, error) {
+		return nil, nil
+	}
+	users.MustAddCommand("name-list", "", listUsers, new(getUserArgs))
+	users.MustAddCommand("nameList", "", listUsers, new(getUserArgs))
+
+	var source bytes.Buffer
+	err := GenerateClient(&source, disp)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, expected := range []string{
+		"// Code generated by typescript.GenerateClient; DO NOT EDIT.\n\n",
+		"/** Arguments of the command \"users get\" */\nexport interface UsersGetArgs {\n  /** Name of the user */\n  name: string;\n  /** @default short */\n  format?: \"short\" | \"long\";\n  timeout?: string;\n}\n",
+		"/** Results of the command \"users count\" */\nexport interface UsersCountResults {\n  /** Number of users */\n  count: number;\n  since: string;\n}\n",
+		"export interface User {\n  street: string;\n  zip?: string;\n  name: string;\n  born: string;\n  deleted: string | null;\n  nick: string | null;\n  friends: (User | null)[] | null;\n  scores: Record<string, number> | null;\n}\n",
+		"  usersGet(args: UsersGetArgs): Promise<User | null> {\n    return this.call<User | null>(\"users/get\", args, false);\n  }\n",
+		"  usersCount(): Promise<UsersCountResults> {\n    return this.call<UsersCountResults>(\"users/count\", {}, false);\n  }\n",
+		"  usersNames(): Promise<[string[] | null, number]> {\n    return this.call<[string[] | null, number]>(\"users/names\", {}, true);\n  }\n",
+		"export interface UsersNameListArgs {\n",
+		"export interface UsersNameList2Args {\n",
+		"  usersNameList(args: UsersNameListArgs): Promise<string[] | null> {\n    return this.call<string[] | null>(\"users/name-list\", args, false);\n  }\n",
+		"  usersNameList2(args: UsersNameList2Args): Promise<string[] | null> {\n    return this.call<string[] | null>(\"users/nameList\", args, false);\n  }\n",
+	} {
+		assert.Contains(t, source.String(), expected)
+	}
+	assert.NotContains(t, source.String(), "interface Address", "embedded struct fields are inlined")
+	assert.NotContains(t, source.String(), "Internal", "ignored field")
+}